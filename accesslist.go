@@ -0,0 +1,250 @@
+package state
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/0xPolygon/eth-state-transition/helper"
+	"github.com/0xPolygon/eth-state-transition/runtime"
+	"github.com/0xPolygon/eth-state-transition/runtime/precompiled"
+	"github.com/0xPolygon/eth-state-transition/types"
+)
+
+var (
+	ErrAccessListNotStable = fmt.Errorf("access list is not stable")
+)
+
+// maxAccessListIterations is the maximum number of executions to find a stable access list
+const maxAccessListIterations = 16
+
+var precompiles = precompiled.NewPrecompiled()
+
+// AccessListResult is the result of CreateAccessList
+type AccessListResult struct {
+	// AccessList is the list of accounts and slots accessed by the transaction
+	AccessList AccessList
+
+	// GasUsed is the gas used by the transaction with the access list
+	GasUsed uint64
+
+	// GasUsedWithoutList is the gas used by the transaction without any access list
+	GasUsedWithoutList uint64
+
+	// Err is the error of the execution with the access list
+	Err error
+}
+
+// CreateAccessList executes the transaction as a read-only call and returns the
+// accounts and storage slots it accesses (i.e. eth_createAccessList). The sender,
+// the receiver and the precompiled contracts are not included in the list unless
+// their storage is accessed.
+// Since the list can change the execution of the transaction, the transaction is
+// executed with the resulting list until the list does not change.
+//
+// After Berlin the transaction pays the intrinsic cost of the list (EIP-2930) and the
+// accounts and slots in the list are cheaper to access (EIP-2929). Before Berlin the
+// list does not change the gas used.
+func (t *Transition) CreateAccessList(msg *Transaction, opts *CallOptions) (*AccessListResult, error) {
+	// the list does not include the accounts accessed by default
+	exclude := map[types.Address]struct{}{
+		msg.From: {},
+	}
+	if msg.To != nil {
+		exclude[*msg.To] = struct{}{}
+	} else {
+		exclude[helper.CreateAddress(msg.From, t.txn.GetNonce(msg.From))] = struct{}{}
+	}
+
+	// the executions are not traced
+	sim := t.untraced()
+	execute := func(list AccessList) (*runtime.ExecutionResult, *accessListRecorder, error) {
+		tt := *msg
+		tt.AccessList = list
+
+		var recorder *accessListRecorder
+		result, _, err := sim.simulate(&tt, opts, func(sim *Transition) runtime.Host {
+			recorder = newAccessListRecorder(sim, exclude)
+			return recorder
+		})
+		return result, recorder, err
+	}
+
+	result, _, err := execute(nil)
+	if err != nil {
+		return nil, err
+	}
+	gasUsedWithoutList := result.GasUsed
+
+	list := msg.AccessList
+	for i := 0; i < maxAccessListIterations; i++ {
+		result, recorder, err := execute(list)
+		if err != nil {
+			return nil, err
+		}
+
+		// the list of the execution includes the accounts and slots of the input list
+		recorder.addList(list)
+
+		next := recorder.accessList()
+		if accessListEqual(list, next) {
+			return &AccessListResult{
+				AccessList:         next,
+				GasUsed:            result.GasUsed,
+				GasUsedWithoutList: gasUsedWithoutList,
+				Err:                result.Err,
+			}, nil
+		}
+		list = next
+	}
+	return nil, ErrAccessListNotStable
+}
+
+// accessListRecorder is a host that records the accounts and storage slots
+// accessed during the execution
+type accessListRecorder struct {
+	*Transition
+
+	exclude map[types.Address]struct{}
+	slots   map[types.Address]map[types.Hash]struct{}
+}
+
+func newAccessListRecorder(t *Transition, exclude map[types.Address]struct{}) *accessListRecorder {
+	return &accessListRecorder{
+		Transition: t,
+		exclude:    exclude,
+		slots:      map[types.Address]map[types.Hash]struct{}{},
+	}
+}
+
+func (a *accessListRecorder) addAddress(addr types.Address) {
+	if _, ok := a.slots[addr]; ok {
+		return
+	}
+	if _, ok := a.exclude[addr]; ok {
+		return
+	}
+	if precompiles.IsPrecompiled(addr, &a.forks) {
+		return
+	}
+	a.slots[addr] = map[types.Hash]struct{}{}
+}
+
+// addSlot adds the storage slot. Unlike the accounts, the slots of the
+// excluded accounts are included since they are not accessed by default.
+func (a *accessListRecorder) addSlot(addr types.Address, key types.Hash) {
+	slots, ok := a.slots[addr]
+	if !ok {
+		slots = map[types.Hash]struct{}{}
+		a.slots[addr] = slots
+	}
+	slots[key] = struct{}{}
+}
+
+func (a *accessListRecorder) addList(list AccessList) {
+	for _, tuple := range list {
+		a.addAddress(tuple.Address)
+		for _, key := range tuple.StorageKeys {
+			a.addSlot(tuple.Address, key)
+		}
+	}
+}
+
+// accessList returns the recorded accounts and slots sorted
+func (a *accessListRecorder) accessList() AccessList {
+	list := make(AccessList, 0, len(a.slots))
+	for addr, slots := range a.slots {
+		tuple := AccessTuple{
+			Address:     addr,
+			StorageKeys: make([]types.Hash, 0, len(slots)),
+		}
+		for key := range slots {
+			tuple.StorageKeys = append(tuple.StorageKeys, key)
+		}
+		sort.Slice(tuple.StorageKeys, func(i, j int) bool {
+			return bytes.Compare(tuple.StorageKeys[i][:], tuple.StorageKeys[j][:]) < 0
+		})
+		list = append(list, tuple)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return bytes.Compare(list[i].Address[:], list[j].Address[:]) < 0
+	})
+	return list
+}
+
+func (a *accessListRecorder) AccountExists(addr types.Address) bool {
+	a.addAddress(addr)
+	return a.Transition.AccountExists(addr)
+}
+
+func (a *accessListRecorder) Empty(addr types.Address) bool {
+	a.addAddress(addr)
+	return a.Transition.Empty(addr)
+}
+
+func (a *accessListRecorder) GetBalance(addr types.Address) *big.Int {
+	a.addAddress(addr)
+	return a.Transition.GetBalance(addr)
+}
+
+func (a *accessListRecorder) GetNonce(addr types.Address) uint64 {
+	a.addAddress(addr)
+	return a.Transition.GetNonce(addr)
+}
+
+func (a *accessListRecorder) GetCode(addr types.Address) []byte {
+	a.addAddress(addr)
+	return a.Transition.GetCode(addr)
+}
+
+func (a *accessListRecorder) GetCodeSize(addr types.Address) int {
+	a.addAddress(addr)
+	return a.Transition.GetCodeSize(addr)
+}
+
+func (a *accessListRecorder) GetCodeHash(addr types.Address) types.Hash {
+	a.addAddress(addr)
+	return a.Transition.GetCodeHash(addr)
+}
+
+func (a *accessListRecorder) GetStorage(addr types.Address, key types.Hash) types.Hash {
+	a.addSlot(addr, key)
+	return a.Transition.GetStorage(addr, key)
+}
+
+func (a *accessListRecorder) SetStorage(addr types.Address, key types.Hash, value types.Hash, config *runtime.ForksInTime) runtime.StorageStatus {
+	a.addSlot(addr, key)
+	return a.Transition.SetStorage(addr, key, value, config)
+}
+
+func (a *accessListRecorder) Selfdestruct(addr types.Address, beneficiary types.Address) {
+	a.addAddress(beneficiary)
+	a.Transition.Selfdestruct(addr, beneficiary)
+}
+
+func (a *accessListRecorder) Callx(c *runtime.Contract, h runtime.Host) *runtime.ExecutionResult {
+	// the created contracts are accessed by default
+	if c.Type != runtime.Create && c.Type != runtime.Create2 {
+		a.addAddress(c.Address)
+		a.addAddress(c.CodeAddress)
+	}
+	return a.Transition.Callx(c, h)
+}
+
+func accessListEqual(a, b AccessList) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Address != b[i].Address || len(a[i].StorageKeys) != len(b[i].StorageKeys) {
+			return false
+		}
+		for j := range a[i].StorageKeys {
+			if a[i].StorageKeys[j] != b[i].StorageKeys[j] {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,181 @@
+package state
+
+import (
+	"fmt"
+
+	"github.com/0xPolygon/eth-state-transition/runtime"
+	"github.com/0xPolygon/eth-state-transition/types"
+)
+
+var (
+	ErrGasUsedMismatch      = fmt.Errorf("gas used mismatch")
+	ErrStateRootMismatch    = fmt.Errorf("state root mismatch")
+	ErrReceiptsRootMismatch = fmt.Errorf("receipts root mismatch")
+	ErrLogsBloomMismatch    = fmt.Errorf("logs bloom mismatch")
+	ErrDAOForkExtraData     = fmt.Errorf("bad DAO fork extra-data")
+	ErrInvalidUncle         = fmt.Errorf("invalid uncle")
+)
+
+// Executor processes full blocks on top of a state
+type Executor struct {
+	params *runtime.Params
+	state  State
+
+	// GetHash builds the function that resolves the BLOCKHASH opcode.
+	// If it is not set the default one of the transition is used.
+	GetHash GetHashByNumberHelper
+
+	// Workers is the number of goroutines that execute the transactions
+	// of a block in parallel (see Transition.WriteParallel). The
+	// transactions are executed sequentially if it is less than two.
+	Workers int
+
+	// PrefetchWorkers is the number of goroutines that load the state the
+	// transactions of a block are likely to access while the block is
+	// executed (see Prefetcher). The state is not prefetched if it is zero.
+	PrefetchWorkers int
+
+	// stats are the counters of the blocks executed in parallel
+	stats ParallelStats
+}
+
+// NewExecutor creates a new block executor
+func NewExecutor(params *runtime.Params, state State) *Executor {
+	return &Executor{
+		params: params,
+		state:  state,
+	}
+}
+
+// Stats returns the counters of the transactions of all the blocks
+// executed in parallel by the executor
+func (e *Executor) Stats() ParallelStats {
+	return e.stats.load()
+}
+
+// ApplyBlock executes the block on top of the state at parentRoot and commits the
+// resulting state. The senders of the block transactions must be set.
+// If the result does not match the header, the result is returned together
+// with an error that describes the mismatch.
+func (e *Executor) ApplyBlock(parentRoot types.Hash, block *types.Block) (*BlockResult, error) {
+	header := block.Header
+	if err := verifyDAOExtraData(e.params.Forks, header); err != nil {
+		return nil, err
+	}
+
+	snap, err := e.state.NewSnapshotAt(parentRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	forks := e.params.Forks.At(header.Number)
+	ctx := runtime.NewTxContext(header, int64(e.params.ChainID))
+
+	txs := make([]*Transaction, 0, len(block.Transactions))
+	for _, txn := range block.Transactions {
+		txs = append(txs, NewTransaction(txn))
+	}
+
+	// the prefetcher reads the snapshot concurrently, so it is stopped
+	// before the state is committed
+	var prefetcher *Prefetcher
+	if e.PrefetchWorkers > 0 {
+		prefetcher = NewPrefetcher(forks, ctx, snap)
+		prefetcher.Start(txs, e.PrefetchWorkers)
+	}
+	stopPrefetcher := func() {
+		if prefetcher != nil {
+			prefetcher.Stop()
+		}
+	}
+
+	transition := NewTransition(forks, ctx, snap)
+	if e.GetHash != nil {
+		transition.SetGetHash(e.GetHash)
+	}
+
+	if e.params.Forks.IsDAOForkBlock(header.Number) {
+		applyDAOHardFork(transition.Txn())
+	}
+
+	var receipts []*Result
+	if e.Workers > 1 && forks.Byzantium {
+		receipts, err = transition.WriteParallel(txs, e.Workers)
+		e.stats.add(transition.ParallelStats())
+		if err != nil {
+			stopPrefetcher()
+			i := len(receipts)
+			return nil, fmt.Errorf("failed to apply transaction %d (%s): %v", i, block.Transactions[i].Hash(), err)
+		}
+	}
+
+	for i := len(receipts); i < len(block.Transactions); i++ {
+		receipt, err := transition.Write(txs[i])
+		if err != nil {
+			stopPrefetcher()
+			return nil, fmt.Errorf("failed to apply transaction %d (%s): %v", i, block.Transactions[i].Hash(), err)
+		}
+
+		if !forks.Byzantium {
+			// commit the intermediate state to get the receipt root
+			stopPrefetcher()
+
+			var root []byte
+			snap, root = snap.Commit(transition.Commit())
+			transition.txn = NewTxn(snap)
+
+			receipt.Root = types.BytesToHash(root)
+		}
+		receipts = append(receipts, receipt)
+	}
+
+	stopPrefetcher()
+
+	// proof-of-stake blocks have no rewards, only the withdrawals after Shanghai
+	txn := transition.Txn()
+	if !isProofOfStake(header) {
+		if err := applyRewards(txn, e.rewards(), forks, header, block.Uncles); err != nil {
+			return nil, err
+		}
+	}
+	if header.WithdrawalsRoot != nil {
+		applyWithdrawals(txn, block.Withdrawals)
+	}
+	txn.CleanDeleteObjects(forks.EIP158)
+
+	_, root := snap.Commit(transition.Commit())
+
+	result := &BlockResult{
+		Root:     types.BytesToHash(root),
+		Receipts: receipts,
+		TotalGas: transition.TotalGas(),
+	}
+	if err := validateBlockResult(header, result, forks.Byzantium); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// rewards returns the reward schedule of the chain
+func (e *Executor) rewards() *runtime.RewardSchedule {
+	if e.params.Rewards != nil {
+		return e.params.Rewards
+	}
+	return runtime.MainnetRewards
+}
+
+func validateBlockResult(header *types.Header, result *BlockResult, byzantium bool) error {
+	if result.TotalGas != header.GasUsed {
+		return fmt.Errorf("%w: expected %d but found %d", ErrGasUsedMismatch, header.GasUsed, result.TotalGas)
+	}
+	if bloom := CreateBloom(result.Receipts); bloom != header.LogsBloom {
+		return fmt.Errorf("%w: expected %s but found %s", ErrLogsBloomMismatch, header.LogsBloom, bloom)
+	}
+	if root := ReceiptsRoot(result.Receipts, byzantium); root != header.ReceiptsRoot {
+		return fmt.Errorf("%w: expected %s but found %s", ErrReceiptsRootMismatch, header.ReceiptsRoot, root)
+	}
+	if result.Root != header.StateRoot {
+		return fmt.Errorf("%w: expected %s but found %s", ErrStateRootMismatch, header.StateRoot, result.Root)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package chain
+
+import (
+	"github.com/0xPolygon/eth-state-transition/runtime"
+)
+
+// Forks are the fork configurations of the state tests by name
+var Forks = map[string]*runtime.Forks{
+	"Frontier": {},
+	"Homestead": {
+		Homestead: runtime.NewFork(0),
+	},
+	"EIP150": {
+		Homestead: runtime.NewFork(0),
+		EIP150:    runtime.NewFork(0),
+	},
+	"EIP158": {
+		Homestead: runtime.NewFork(0),
+		EIP150:    runtime.NewFork(0),
+		EIP155:    runtime.NewFork(0),
+		EIP158:    runtime.NewFork(0),
+	},
+	"Byzantium": {
+		Homestead: runtime.NewFork(0),
+		EIP150:    runtime.NewFork(0),
+		EIP155:    runtime.NewFork(0),
+		EIP158:    runtime.NewFork(0),
+		Byzantium: runtime.NewFork(0),
+	},
+	"Constantinople": {
+		Homestead:      runtime.NewFork(0),
+		EIP150:         runtime.NewFork(0),
+		EIP155:         runtime.NewFork(0),
+		EIP158:         runtime.NewFork(0),
+		Byzantium:      runtime.NewFork(0),
+		Constantinople: runtime.NewFork(0),
+	},
+	"Istanbul": {
+		Homestead:      runtime.NewFork(0),
+		EIP150:         runtime.NewFork(0),
+		EIP155:         runtime.NewFork(0),
+		EIP158:         runtime.NewFork(0),
+		Byzantium:      runtime.NewFork(0),
+		Constantinople: runtime.NewFork(0),
+		Petersburg:     runtime.NewFork(0),
+		Istanbul:       runtime.NewFork(0),
+	},
+	"Berlin": {
+		Homestead:      runtime.NewFork(0),
+		EIP150:         runtime.NewFork(0),
+		EIP155:         runtime.NewFork(0),
+		EIP158:         runtime.NewFork(0),
+		Byzantium:      runtime.NewFork(0),
+		Constantinople: runtime.NewFork(0),
+		Petersburg:     runtime.NewFork(0),
+		Istanbul:       runtime.NewFork(0),
+		Berlin:         runtime.NewFork(0),
+	},
+	"FrontierToHomesteadAt5": {
+		Homestead: runtime.NewFork(5),
+	},
+	"HomesteadToEIP150At5": {
+		Homestead: runtime.NewFork(0),
+		EIP150:    runtime.NewFork(5),
+	},
+	"HomesteadToDaoAt5": {
+		Homestead: runtime.NewFork(0),
+	},
+	"EIP158ToByzantiumAt5": {
+		Homestead: runtime.NewFork(0),
+		EIP150:    runtime.NewFork(0),
+		EIP155:    runtime.NewFork(0),
+		EIP158:    runtime.NewFork(0),
+		Byzantium: runtime.NewFork(5),
+	},
+	"ByzantiumToConstantinopleAt5": {
+		Byzantium:      runtime.NewFork(0),
+		Constantinople: runtime.NewFork(5),
+	},
+	"ConstantinopleFix": {
+		Homestead:      runtime.NewFork(0),
+		EIP150:         runtime.NewFork(0),
+		EIP155:         runtime.NewFork(0),
+		EIP158:         runtime.NewFork(0),
+		Byzantium:      runtime.NewFork(0),
+		Constantinople: runtime.NewFork(0),
+		Petersburg:     runtime.NewFork(0),
+	},
+}
@@ -0,0 +1,63 @@
+// evmanalyze prints the control flow graph, the functions and the
+// issues found in the bytecode of a contract.
+//
+//	evmanalyze [-format json|dot] <file>
+//
+// The file has the bytecode in hex, with or without the 0x prefix.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/0xPolygon/eth-state-transition/helper"
+	"github.com/0xPolygon/eth-state-transition/runtime/evm/analysis"
+)
+
+func main() {
+	var format string
+	flag.StringVar(&format, "format", "json", "output format (json or dot)")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] <file>\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := run(flag.Arg(0), format); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(path, format string) error {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	str := strings.TrimSpace(string(content))
+	code, err := helper.ParseBytes(&str)
+	if err != nil {
+		return fmt.Errorf("invalid bytecode: %v", err)
+	}
+
+	a := analysis.Analyze(code)
+
+	switch format {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(a)
+	case "dot":
+		return a.WriteDOT(os.Stdout)
+	default:
+		return fmt.Errorf("unknown format '%s'", format)
+	}
+}
@@ -0,0 +1,252 @@
+// evmdebug runs a transaction of a state test with the interactive debugger.
+//
+//	evmdebug [flags] <file.json>
+//
+// The file has the format of the state tests, an object with the tests by name,
+// each one with the env, the pre state and the transaction.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"sort"
+
+	state "github.com/0xPolygon/eth-state-transition"
+	"github.com/0xPolygon/eth-state-transition/chain"
+	"github.com/0xPolygon/eth-state-transition/debugger"
+	"github.com/0xPolygon/eth-state-transition/helper"
+	itrie "github.com/0xPolygon/eth-state-transition/immutable-trie"
+	"github.com/0xPolygon/eth-state-transition/runtime"
+	"github.com/0xPolygon/eth-state-transition/types"
+)
+
+type stateTest struct {
+	Env         *testEnv                                `json:"env"`
+	Pre         map[types.Address]*chain.GenesisAccount `json:"pre"`
+	Transaction *testTransaction                        `json:"transaction"`
+}
+
+type testEnv struct {
+	Coinbase   types.Address `json:"currentCoinbase"`
+	Difficulty string        `json:"currentDifficulty"`
+	GasLimit   string        `json:"currentGasLimit"`
+	Number     string        `json:"currentNumber"`
+	Timestamp  string        `json:"currentTimestamp"`
+}
+
+func (e *testEnv) txContext() (runtime.TxContext, error) {
+	ctx := runtime.TxContext{
+		Coinbase: e.Coinbase,
+		ChainID:  1,
+	}
+
+	difficulty, err := helper.ParseUint256orHex(&e.Difficulty)
+	if err != nil {
+		return ctx, fmt.Errorf("invalid difficulty: %v", err)
+	}
+	ctx.Difficulty = types.BytesToHash(difficulty.Bytes())
+
+	for _, field := range []struct {
+		name string
+		str  string
+		dst  *int64
+	}{
+		{"gas limit", e.GasLimit, &ctx.GasLimit},
+		{"number", e.Number, &ctx.Number},
+		{"timestamp", e.Timestamp, &ctx.Timestamp},
+	} {
+		n, err := helper.ParseUint64orHex(&field.str)
+		if err != nil {
+			return ctx, fmt.Errorf("invalid %s: %v", field.name, err)
+		}
+		*field.dst = int64(n)
+	}
+	return ctx, nil
+}
+
+type testTransaction struct {
+	Data      []string `json:"data"`
+	GasLimit  []string `json:"gasLimit"`
+	Value     []string `json:"value"`
+	GasPrice  string   `json:"gasPrice"`
+	Nonce     string   `json:"nonce"`
+	SecretKey string   `json:"secretKey"`
+	Sender    string   `json:"sender"`
+	To        string   `json:"to"`
+}
+
+func (t *testTransaction) at(data, gas, value int) (*state.Transaction, error) {
+	if data >= len(t.Data) || gas >= len(t.GasLimit) || value >= len(t.Value) {
+		return nil, fmt.Errorf("index out of bounds (data %d, gas %d, value %d)", len(t.Data), len(t.GasLimit), len(t.Value))
+	}
+
+	msg := &state.Transaction{}
+
+	var err error
+	if msg.Input, err = helper.ParseBytes(&t.Data[data]); err != nil {
+		return nil, fmt.Errorf("invalid data: %v", err)
+	}
+	if msg.Gas, err = helper.ParseUint64orHex(&t.GasLimit[gas]); err != nil {
+		return nil, fmt.Errorf("invalid gas limit: %v", err)
+	}
+	if msg.Nonce, err = helper.ParseUint64orHex(&t.Nonce); err != nil {
+		return nil, fmt.Errorf("invalid nonce: %v", err)
+	}
+	if msg.GasPrice, err = helper.ParseUint256orHex(&t.GasPrice); err != nil {
+		return nil, fmt.Errorf("invalid gas price: %v", err)
+	}
+
+	msg.Value = new(big.Int)
+	if t.Value[value] != "0x" {
+		if msg.Value, err = helper.ParseUint256orHex(&t.Value[value]); err != nil {
+			return nil, fmt.Errorf("invalid value: %v", err)
+		}
+	}
+
+	if t.To != "" {
+		to := types.StringToAddress(t.To)
+		msg.To = &to
+	}
+
+	if t.SecretKey != "" {
+		secretKey, err := helper.ParseBytes(&t.SecretKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid secret key: %v", err)
+		}
+		key, err := helper.ParsePrivateKey(secretKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid secret key: %v", err)
+		}
+		msg.From = helper.PubKeyToAddress(&key.PublicKey)
+	} else {
+		msg.From = types.StringToAddress(t.Sender)
+	}
+	return msg, nil
+}
+
+func buildState(allocs map[types.Address]*chain.GenesisAccount) (state.Snapshot, error) {
+	s := itrie.NewArchiveState(itrie.NewMemoryStorage())
+	snap := s.NewSnapshot()
+
+	txn := state.NewTxn(snap)
+	for addr, alloc := range allocs {
+		txn.CreateAccount(addr)
+		txn.SetNonce(addr, alloc.Nonce)
+		txn.SetBalance(addr, alloc.Balance)
+
+		if len(alloc.Code) != 0 {
+			txn.SetCode(addr, alloc.Code)
+		}
+		for k, v := range alloc.Storage {
+			txn.SetState(addr, k, v)
+		}
+	}
+
+	_, root := snap.Commit(txn.Commit())
+	return s.NewSnapshotAt(types.BytesToHash(root))
+}
+
+func main() {
+	var (
+		fork  string
+		name  string
+		data  int
+		gas   int
+		value int
+	)
+	flag.StringVar(&fork, "fork", "Istanbul", "fork of the rules of the execution")
+	flag.StringVar(&name, "test", "", "name of the test if the file has more than one")
+	flag.IntVar(&data, "data", 0, "index of the data of the transaction")
+	flag.IntVar(&gas, "gas", 0, "index of the gas limit of the transaction")
+	flag.IntVar(&value, "value", 0, "index of the value of the transaction")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] <file.json>\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := run(flag.Arg(0), name, fork, data, gas, value); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(path, name, fork string, data, gas, value int) error {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var cases map[string]*stateTest
+	if err := json.Unmarshal(content, &cases); err != nil {
+		return err
+	}
+
+	if name == "" {
+		if len(cases) != 1 {
+			names := make([]string, 0, len(cases))
+			for name := range cases {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return fmt.Errorf("the file has %d tests, select one with -test: %v", len(cases), names)
+		}
+		for n := range cases {
+			name = n
+		}
+	}
+	c, ok := cases[name]
+	if !ok {
+		return fmt.Errorf("test %s not found", name)
+	}
+	if c.Env == nil || c.Transaction == nil {
+		return fmt.Errorf("test %s has no env or transaction", name)
+	}
+
+	config, ok := chain.Forks[fork]
+	if !ok {
+		return fmt.Errorf("fork %s not found", fork)
+	}
+
+	ctx, err := c.Env.txContext()
+	if err != nil {
+		return err
+	}
+	msg, err := c.Transaction.at(data, gas, value)
+	if err != nil {
+		return err
+	}
+	snap, err := buildState(c.Pre)
+	if err != nil {
+		return err
+	}
+
+	transition := state.NewTransition(config.At(uint64(ctx.Number)), ctx, snap)
+
+	d := debugger.New()
+	transition.SetTracer(d)
+
+	var (
+		result *state.Result
+		txErr  error
+	)
+	repl := debugger.NewREPL(d, os.Stdin, os.Stdout)
+	if err := repl.Run(func() {
+		result, txErr = transition.Write(msg)
+	}); err != nil {
+		return err
+	}
+
+	if txErr != nil {
+		return fmt.Errorf("transaction failed: %v", txErr)
+	}
+	fmt.Printf("success: %v\ngas used: %d\nreturn value: %s\n", result.Success, result.GasUsed, helper.EncodeToHex(result.ReturnValue))
+	return nil
+}
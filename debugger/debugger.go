@@ -0,0 +1,468 @@
+package debugger
+
+import (
+	"fmt"
+	"math/big"
+
+	state "github.com/0xPolygon/eth-state-transition"
+	"github.com/0xPolygon/eth-state-transition/runtime"
+	"github.com/0xPolygon/eth-state-transition/runtime/evm"
+	"github.com/0xPolygon/eth-state-transition/types"
+)
+
+var _ runtime.Tracer = &Debugger{}
+
+var (
+	ErrNotPaused          = fmt.Errorf("execution is not paused")
+	ErrRunning            = fmt.Errorf("execution is already running")
+	ErrBreakpointNotFound = fmt.Errorf("breakpoint not found")
+	ErrInvalidBreakpoint  = fmt.Errorf("invalid breakpoint")
+	ErrStackOutOfRange    = fmt.Errorf("stack index out of range")
+	ErrMemoryOutOfRange   = fmt.Errorf("memory range out of bounds")
+	ErrInvalidValue       = fmt.Errorf("value is not a 256 bits unsigned integer")
+	ErrStorageNotWritable = fmt.Errorf("storage of the host is not writable")
+	ErrStackNotWritable   = fmt.Errorf("stack of the scope is not writable")
+)
+
+// BreakpointKind is the condition of a breakpoint
+type BreakpointKind int
+
+const (
+	// BreakPC stops before executing the opcode at a position of the code
+	BreakPC BreakpointKind = iota
+	// BreakOp stops before executing an opcode
+	BreakOp
+	// BreakAddress stops at the first opcode of the frames running a contract
+	BreakAddress
+	// BreakStorageWrite stops before writing the storage
+	BreakStorageWrite
+)
+
+func (k BreakpointKind) String() string {
+	switch k {
+	case BreakPC:
+		return "pc"
+	case BreakOp:
+		return "op"
+	case BreakAddress:
+		return "addr"
+	case BreakStorageWrite:
+		return "sstore"
+	default:
+		panic("BUG: breakpoint kind not found")
+	}
+}
+
+// Breakpoint is a condition to pause the execution
+type Breakpoint struct {
+	Kind BreakpointKind
+
+	// Address is the contract of the breakpoint. It is required for the address
+	// breakpoints and matches any contract for the others if it is not set.
+	Address *types.Address
+
+	// PC is the position of the code for the pc breakpoints
+	PC uint64
+
+	// Op is the opcode for the opcode breakpoints
+	Op evm.OpCode
+
+	// Key is the storage slot for the storage breakpoints.
+	// If it is not set any slot matches.
+	Key *types.Hash
+}
+
+func (b *Breakpoint) String() string {
+	str := b.Kind.String()
+	switch b.Kind {
+	case BreakPC:
+		str += fmt.Sprintf(" %d", b.PC)
+	case BreakOp:
+		str += " " + b.Op.String()
+	}
+	if b.Address != nil {
+		str += " " + b.Address.String()
+	}
+	if b.Key != nil {
+		str += " " + b.Key.String()
+	}
+	return str
+}
+
+// StopReason is the reason the execution paused
+type StopReason int
+
+const (
+	// StopStep is a pause after a step command
+	StopStep StopReason = iota
+	// StopBreakpoint is a pause on a breakpoint
+	StopBreakpoint
+	// StopDone is the end of the execution
+	StopDone
+)
+
+// Stop is a pause of the execution before an opcode
+type Stop struct {
+	Reason StopReason
+
+	// Breakpoint is the id of the breakpoint hit
+	Breakpoint int
+
+	PC    uint64
+	Op    evm.OpCode
+	Depth int
+	Gas   uint64
+
+	// Address is the account of the frame and CodeAddress
+	// the account whose code is executed
+	Address     types.Address
+	CodeAddress types.Address
+}
+
+func (s *Stop) String() string {
+	if s.Reason == StopDone {
+		return "execution finished"
+	}
+	str := fmt.Sprintf("pc %d %s gas %d depth %d address %s", s.PC, s.Op.String(), s.Gas, s.Depth, s.Address)
+	if s.CodeAddress != s.Address {
+		str += " code " + s.CodeAddress.String()
+	}
+	if s.Reason == StopBreakpoint {
+		str = fmt.Sprintf("breakpoint %d: %s", s.Breakpoint, str)
+	}
+	return str
+}
+
+type stepMode int
+
+const (
+	// stepContinue runs until a breakpoint
+	stepContinue stepMode = iota
+	// stepInto pauses at the next opcode
+	stepInto
+	// stepOver pauses at the next opcode that is not in a child frame
+	stepOver
+	// stepOut pauses at the next opcode of a parent frame
+	stepOut
+	// stepDetach runs until the end ignoring the breakpoints
+	stepDetach
+)
+
+// Debugger is a tracer that pauses the execution of the EVM on breakpoints and
+// steps. The execution runs in its own goroutine and the debugger controls it
+// from the goroutine of the caller. While it is paused, the stack, memory and
+// storage of the current frame can be inspected and modified. The debugger is
+// not safe for concurrent use.
+type Debugger struct {
+	breakpoints map[int]*Breakpoint
+	nextID      int
+
+	mode      stepMode
+	stepDepth int
+
+	// newFrame is set when a frame starts and before its first opcode
+	newFrame bool
+
+	running bool
+	stops   chan *Stop
+	resume  chan struct{}
+
+	// scope and host are the current frame while the execution is paused
+	scope runtime.ScopeContext
+	host  runtime.Host
+}
+
+// New creates a new debugger
+func New() *Debugger {
+	return &Debugger{
+		breakpoints: map[int]*Breakpoint{},
+		nextID:      1,
+		stops:       make(chan *Stop),
+		resume:      make(chan struct{}),
+	}
+}
+
+// AddBreakpoint adds a breakpoint and returns its id
+func (d *Debugger) AddBreakpoint(b *Breakpoint) (int, error) {
+	if b.Kind == BreakAddress && b.Address == nil {
+		return 0, ErrInvalidBreakpoint
+	}
+	if b.Kind < BreakPC || b.Kind > BreakStorageWrite {
+		return 0, ErrInvalidBreakpoint
+	}
+
+	id := d.nextID
+	d.nextID++
+
+	bp := *b
+	d.breakpoints[id] = &bp
+	return id, nil
+}
+
+// RemoveBreakpoint removes a breakpoint
+func (d *Debugger) RemoveBreakpoint(id int) error {
+	if _, ok := d.breakpoints[id]; !ok {
+		return ErrBreakpointNotFound
+	}
+	delete(d.breakpoints, id)
+	return nil
+}
+
+// Breakpoints returns the breakpoints by id
+func (d *Debugger) Breakpoints() map[int]*Breakpoint {
+	res := make(map[int]*Breakpoint, len(d.breakpoints))
+	for id, b := range d.breakpoints {
+		bp := *b
+		res[id] = &bp
+	}
+	return res
+}
+
+// Run starts the execution of fn, which executes transactions on a transition
+// with the debugger as tracer, and pauses it before the first opcode
+func (d *Debugger) Run(fn func()) (*Stop, error) {
+	if d.running {
+		return nil, ErrRunning
+	}
+	d.running = true
+	d.mode = stepInto
+
+	go func() {
+		fn()
+		d.stops <- &Stop{Reason: StopDone}
+	}()
+	return d.wait(), nil
+}
+
+// Continue resumes the execution until the next breakpoint
+func (d *Debugger) Continue() (*Stop, error) {
+	return d.resumeWith(stepContinue)
+}
+
+// Step executes the opcode and pauses at the next one, which can be in a new frame
+func (d *Debugger) Step() (*Stop, error) {
+	return d.resumeWith(stepInto)
+}
+
+// StepOver executes the opcode and pauses at the next one in the same frame
+// or in a parent frame if the current one returns
+func (d *Debugger) StepOver() (*Stop, error) {
+	return d.resumeWith(stepOver)
+}
+
+// StepOut resumes the execution until the current frame returns
+func (d *Debugger) StepOut() (*Stop, error) {
+	return d.resumeWith(stepOut)
+}
+
+// Detach resumes the execution until the end ignoring the breakpoints
+func (d *Debugger) Detach() error {
+	_, err := d.resumeWith(stepDetach)
+	return err
+}
+
+func (d *Debugger) resumeWith(mode stepMode) (*Stop, error) {
+	if d.scope == nil {
+		return nil, ErrNotPaused
+	}
+	d.mode = mode
+	d.stepDepth = d.scope.Depth()
+
+	d.scope, d.host = nil, nil
+	d.resume <- struct{}{}
+
+	return d.wait(), nil
+}
+
+func (d *Debugger) wait() *Stop {
+	stop := <-d.stops
+	if stop.Reason == StopDone {
+		d.running = false
+	}
+	return stop
+}
+
+// TxStart implements the Tracer interface
+func (d *Debugger) TxStart(gasLimit uint64) {
+}
+
+// TxEnd implements the Tracer interface
+func (d *Debugger) TxEnd(gasLeft uint64) {
+}
+
+// CallStart implements the Tracer interface
+func (d *Debugger) CallStart(depth int, from, to types.Address, callType runtime.CallType, gas uint64, value *big.Int, input []byte) {
+	d.newFrame = true
+}
+
+// CallEnd implements the Tracer interface
+func (d *Debugger) CallEnd(depth int, output []byte, gasUsed uint64, err error) {
+	d.newFrame = false
+}
+
+// CaptureState implements the Tracer interface
+func (d *Debugger) CaptureState(scope runtime.ScopeContext, host runtime.Host) {
+	newFrame := d.newFrame
+	d.newFrame = false
+
+	if d.mode == stepDetach {
+		return
+	}
+
+	stop := &Stop{
+		Reason:      StopStep,
+		PC:          scope.PC(),
+		Op:          evm.OpCode(scope.Op()),
+		Depth:       scope.Depth(),
+		Gas:         scope.Gas(),
+		Address:     scope.Contract().Address,
+		CodeAddress: scope.Contract().CodeAddress,
+	}
+
+	if id, ok := d.matchBreakpoint(scope, newFrame); ok {
+		stop.Reason = StopBreakpoint
+		stop.Breakpoint = id
+	} else {
+		switch d.mode {
+		case stepContinue:
+			return
+		case stepOver:
+			if stop.Depth > d.stepDepth {
+				return
+			}
+		case stepOut:
+			if stop.Depth >= d.stepDepth {
+				return
+			}
+		}
+	}
+
+	// wait for the caller to resume the execution
+	d.scope, d.host = scope, host
+	d.stops <- stop
+	<-d.resume
+}
+
+// matchBreakpoint returns the lowest id of the breakpoints matching the opcode
+func (d *Debugger) matchBreakpoint(scope runtime.ScopeContext, newFrame bool) (int, bool) {
+	contract := scope.Contract()
+	op := evm.OpCode(scope.Op())
+
+	found := 0
+	for id, b := range d.breakpoints {
+		if found != 0 && id > found {
+			continue
+		}
+
+		match := false
+		switch b.Kind {
+		case BreakPC:
+			match = b.PC == scope.PC()
+		case BreakOp:
+			match = b.Op == op
+		case BreakAddress:
+			match = newFrame && (*b.Address == contract.Address || *b.Address == contract.CodeAddress)
+		case BreakStorageWrite:
+			match = op == evm.SSTORE
+			if match && b.Key != nil {
+				stack := scope.Stack()
+				match = len(stack) >= 1 && types.BytesToHash(stack[len(stack)-1].Bytes()) == *b.Key
+			}
+		}
+		if match && b.Kind != BreakAddress && b.Address != nil {
+			match = *b.Address == contract.Address
+		}
+		if match {
+			found = id
+		}
+	}
+	return found, found != 0
+}
+
+// ExecuteState implements the Tracer interface
+func (d *Debugger) ExecuteState(pc uint64, op byte, cost uint64) {
+}
+
+// CaptureFault implements the Tracer interface
+func (d *Debugger) CaptureFault(pc uint64, op byte, cost uint64, err error) {
+}
+
+// Stack returns a copy of the stack of the current frame,
+// the last item is the top of the stack
+func (d *Debugger) Stack() ([]*big.Int, error) {
+	if d.scope == nil {
+		return nil, ErrNotPaused
+	}
+	stack := d.scope.Stack()
+	res := make([]*big.Int, len(stack))
+	for i, v := range stack {
+		res[i] = new(big.Int).Set(v)
+	}
+	return res, nil
+}
+
+// SetStack sets an item of the stack of the current frame, the index 0 is the top of the stack
+func (d *Debugger) SetStack(i int, value *big.Int) error {
+	if d.scope == nil {
+		return ErrNotPaused
+	}
+	if value.Sign() < 0 || value.BitLen() > 256 {
+		return ErrInvalidValue
+	}
+	stack := d.scope.Stack()
+	if i < 0 || i >= len(stack) {
+		return ErrStackOutOfRange
+	}
+	// the scope returns a copy of the stack, the item is set with the
+	// setter of the scope
+	setter, ok := d.scope.(interface{ SetStack(i int, value *big.Int) })
+	if !ok {
+		return ErrStackNotWritable
+	}
+	setter.SetStack(len(stack)-1-i, value)
+	return nil
+}
+
+// Memory returns a copy of the memory of the current frame
+func (d *Debugger) Memory() ([]byte, error) {
+	if d.scope == nil {
+		return nil, ErrNotPaused
+	}
+	return append([]byte{}, d.scope.Memory()...), nil
+}
+
+// SetMemory writes data in the memory of the current frame. The memory
+// is not expanded so the range has to be already allocated.
+func (d *Debugger) SetMemory(offset uint64, data []byte) error {
+	if d.scope == nil {
+		return ErrNotPaused
+	}
+	memory := d.scope.Memory()
+	if offset > uint64(len(memory)) || uint64(len(data)) > uint64(len(memory))-offset {
+		return ErrMemoryOutOfRange
+	}
+	copy(memory[offset:], data)
+	return nil
+}
+
+// Storage returns a slot of the storage of the account of the current frame
+func (d *Debugger) Storage(key types.Hash) (types.Hash, error) {
+	if d.scope == nil {
+		return types.Hash{}, ErrNotPaused
+	}
+	return d.host.GetStorage(d.scope.Contract().Address, key), nil
+}
+
+// SetStorage sets a slot of the storage of the account of the current frame.
+// The host has to give access to its state (i.e. a state.Transition).
+func (d *Debugger) SetStorage(key, value types.Hash) error {
+	if d.scope == nil {
+		return ErrNotPaused
+	}
+	host, ok := d.host.(interface{ Txn() *state.Txn })
+	if !ok {
+		return ErrStorageNotWritable
+	}
+	host.Txn().SetState(d.scope.Contract().Address, key, value)
+	return nil
+}
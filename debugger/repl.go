@@ -0,0 +1,283 @@
+package debugger
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/0xPolygon/eth-state-transition/helper"
+	"github.com/0xPolygon/eth-state-transition/runtime/evm"
+	"github.com/0xPolygon/eth-state-transition/types"
+)
+
+const replHelp = `commands:
+  step, s                      execute the opcode and pause at the next one
+  next, n                      step over the calls and creations
+  out, o                       run until the current frame returns
+  continue, c                  run until the next breakpoint
+  break pc <pc> [addr]         pause at a position of the code
+  break op <opcode> [addr]     pause at an opcode
+  break addr <addr>            pause when a frame runs the code of a contract
+  break sstore [addr [key]]    pause before a storage write
+  delete <id>                  remove a breakpoint
+  breakpoints                  list the breakpoints
+  stack                        print the stack, the top first
+  memory                       print the memory
+  storage <key>                print a storage slot of the contract
+  setstack <index> <value>     set an item of the stack, 0 is the top
+  setmem <offset> <hex>        write the memory
+  setstorage <key> <value>     set a storage slot of the contract
+  help, h                      print this help
+  quit, q                      run until the end without pausing`
+
+// REPL is a command line interface for the debugger
+type REPL struct {
+	d   *Debugger
+	in  *bufio.Scanner
+	out io.Writer
+}
+
+// NewREPL creates a REPL that reads the commands from in and writes to out
+func NewREPL(d *Debugger, in io.Reader, out io.Writer) *REPL {
+	return &REPL{
+		d:   d,
+		in:  bufio.NewScanner(in),
+		out: out,
+	}
+}
+
+// Run executes fn with the debugger and runs the commands until the
+// execution finishes. The execution runs until the end without pausing
+// when the input ends.
+func (r *REPL) Run(fn func()) error {
+	stop, err := r.d.Run(fn)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(r.out, stop)
+
+	for stop.Reason != StopDone {
+		fmt.Fprint(r.out, "> ")
+		if !r.in.Scan() {
+			if err := r.d.Detach(); err != nil {
+				return err
+			}
+			break
+		}
+
+		args := strings.Fields(r.in.Text())
+		if len(args) == 0 {
+			continue
+		}
+
+		next, err := r.exec(args[0], args[1:])
+		if err != nil {
+			fmt.Fprintf(r.out, "error: %v\n", err)
+			continue
+		}
+		if next != nil {
+			stop = next
+			fmt.Fprintln(r.out, stop)
+		}
+	}
+	return r.in.Err()
+}
+
+// exec runs a command and returns the new stop if it resumed the execution
+func (r *REPL) exec(cmd string, args []string) (*Stop, error) {
+	switch cmd {
+	case "step", "s":
+		return r.d.Step()
+
+	case "next", "n":
+		return r.d.StepOver()
+
+	case "out", "o":
+		return r.d.StepOut()
+
+	case "continue", "c":
+		return r.d.Continue()
+
+	case "quit", "q":
+		if err := r.d.Detach(); err != nil {
+			return nil, err
+		}
+		return &Stop{Reason: StopDone}, nil
+
+	case "break", "b":
+		b, err := parseBreakpoint(args)
+		if err != nil {
+			return nil, err
+		}
+		id, err := r.d.AddBreakpoint(b)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(r.out, "breakpoint %d: %s\n", id, b)
+
+	case "delete", "d":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("expected the id of the breakpoint")
+		}
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return nil, r.d.RemoveBreakpoint(id)
+
+	case "breakpoints":
+		breakpoints := r.d.Breakpoints()
+		ids := make([]int, 0, len(breakpoints))
+		for id := range breakpoints {
+			ids = append(ids, id)
+		}
+		sort.Ints(ids)
+		for _, id := range ids {
+			fmt.Fprintf(r.out, "%d: %s\n", id, breakpoints[id])
+		}
+
+	case "stack":
+		stack, err := r.d.Stack()
+		if err != nil {
+			return nil, err
+		}
+		for i := len(stack) - 1; i >= 0; i-- {
+			fmt.Fprintf(r.out, "%d: 0x%s\n", len(stack)-1-i, stack[i].Text(16))
+		}
+
+	case "memory":
+		memory, err := r.d.Memory()
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < len(memory); i += 32 {
+			end := i + 32
+			if end > len(memory) {
+				end = len(memory)
+			}
+			fmt.Fprintf(r.out, "0x%04x: %s\n", i, helper.EncodeToString(memory[i:end]))
+		}
+
+	case "storage":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("expected the key of the slot")
+		}
+		value, err := r.d.Storage(types.StringToHash(args[0]))
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintln(r.out, value)
+
+	case "setstack":
+		if len(args) != 2 {
+			return nil, fmt.Errorf("expected the index and the value")
+		}
+		i, err := strconv.Atoi(args[0])
+		if err != nil {
+			return nil, err
+		}
+		value, err := parseBig(args[1])
+		if err != nil {
+			return nil, err
+		}
+		return nil, r.d.SetStack(i, value)
+
+	case "setmem":
+		if len(args) != 2 {
+			return nil, fmt.Errorf("expected the offset and the data")
+		}
+		offset, err := helper.ParseUint64orHex(&args[0])
+		if err != nil {
+			return nil, err
+		}
+		data, err := helper.ParseBytes(&args[1])
+		if err != nil {
+			return nil, err
+		}
+		return nil, r.d.SetMemory(offset, data)
+
+	case "setstorage":
+		if len(args) != 2 {
+			return nil, fmt.Errorf("expected the key and the value")
+		}
+		return nil, r.d.SetStorage(types.StringToHash(args[0]), types.StringToHash(args[1]))
+
+	case "help", "h":
+		fmt.Fprintln(r.out, replHelp)
+
+	default:
+		return nil, fmt.Errorf("unknown command '%s'", cmd)
+	}
+	return nil, nil
+}
+
+func parseBreakpoint(args []string) (*Breakpoint, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("expected the kind of the breakpoint")
+	}
+	b := &Breakpoint{}
+	rest := args[1:]
+
+	switch args[0] {
+	case "pc":
+		if len(rest) == 0 {
+			return nil, fmt.Errorf("expected the pc")
+		}
+		pc, err := helper.ParseUint64orHex(&rest[0])
+		if err != nil {
+			return nil, err
+		}
+		b.Kind, b.PC = BreakPC, pc
+		rest = rest[1:]
+
+	case "op":
+		if len(rest) == 0 {
+			return nil, fmt.Errorf("expected the opcode")
+		}
+		op, ok := evm.StringToOpCode(rest[0])
+		if !ok {
+			return nil, fmt.Errorf("opcode '%s' not found", rest[0])
+		}
+		b.Kind, b.Op = BreakOp, op
+		rest = rest[1:]
+
+	case "addr":
+		if len(rest) != 1 {
+			return nil, fmt.Errorf("expected the address")
+		}
+		b.Kind = BreakAddress
+
+	case "sstore":
+		b.Kind = BreakStorageWrite
+		if len(rest) == 2 {
+			key := types.StringToHash(rest[1])
+			b.Key = &key
+			rest = rest[:1]
+		}
+
+	default:
+		return nil, fmt.Errorf("unknown breakpoint '%s'", args[0])
+	}
+
+	switch len(rest) {
+	case 0:
+	case 1:
+		addr := types.StringToAddress(rest[0])
+		b.Address = &addr
+	default:
+		return nil, fmt.Errorf("too many arguments")
+	}
+	return b, nil
+}
+
+func parseBig(str string) (*big.Int, error) {
+	n, err := helper.ParseUint256orHex(&str)
+	if err != nil {
+		return nil, fmt.Errorf("invalid number '%s'", str)
+	}
+	return n, nil
+}
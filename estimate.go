@@ -0,0 +1,129 @@
+package state
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/0xPolygon/eth-state-transition/runtime"
+)
+
+var (
+	ErrGasRequiredExceedsAllowance = fmt.Errorf("gas required exceeds allowance")
+)
+
+// EstimateGas returns the lowest gas limit with which the transaction succeeds (i.e.
+// eth_estimateGas). Each trial is a read-only call on top of the current state.
+// The gas limit of the transaction (or the gas cap of the options if it is not set)
+// is the upper bound of the search. If the transaction has a gas price, the bound is
+// also limited by the funds of the sender.
+// If the transaction fails with the upper bound, the error includes the revert reason.
+func (t *Transition) EstimateGas(msg *Transaction, opts *CallOptions) (uint64, error) {
+	if opts == nil {
+		opts = &CallOptions{}
+	}
+
+	hi := uint64(t.ctx.GasLimit)
+	if msg.Gas >= TxGas && msg.Gas < hi {
+		hi = msg.Gas
+	}
+	if opts.GasCap != 0 && opts.GasCap < hi {
+		hi = opts.GasCap
+	}
+
+	// limit the gas to the funds of the sender
+	if msg.GasPrice != nil && msg.GasPrice.Sign() != 0 {
+		available := new(big.Int).Set(t.txn.GetBalance(msg.From))
+		if account, ok := opts.Overrides[msg.From]; ok && account.Balance != nil {
+			available.Set(account.Balance)
+		}
+		if msg.Value != nil {
+			if available.Cmp(msg.Value) < 0 {
+				return 0, ErrNotEnoughFunds
+			}
+			available.Sub(available, msg.Value)
+		}
+		allowance := available.Div(available, msg.GasPrice)
+		if allowance.IsUint64() && allowance.Uint64() < hi {
+			hi = allowance.Uint64()
+		}
+	}
+
+	intrinsicGasCost, err := TransactionGasCost(msg, t.forks.Homestead, t.forks.Istanbul, t.forks.Berlin)
+	if err != nil {
+		return 0, err
+	}
+	if hi < intrinsicGasCost {
+		return 0, fmt.Errorf("%w (%d)", ErrGasRequiredExceedsAllowance, hi)
+	}
+
+	// execute the transaction with the given gas limit, the trials are not traced
+	sim := t.untraced()
+	trial := func(gas uint64) (*runtime.ExecutionResult, uint64, error) {
+		tt := *msg
+		tt.Gas = gas
+
+		return sim.simulate(&tt, &CallOptions{
+			GasCap:       gas,
+			Overrides:    opts.Overrides,
+			CheckNonce:   opts.CheckNonce,
+			CheckBalance: opts.CheckBalance,
+		}, nil)
+	}
+
+	// the transaction must succeed with the upper bound
+	result, refund, err := trial(hi)
+	if err != nil {
+		return 0, err
+	}
+	if result.Failed() {
+		if result.Reverted() {
+			return 0, revertError(result.ReturnValue)
+		}
+		if result.Err == runtime.ErrOutOfGas {
+			return 0, fmt.Errorf("%w (%d)", ErrGasRequiredExceedsAllowance, hi)
+		}
+		return 0, result.Err
+	}
+
+	// the transaction needs at least the gas it used. However, the refund is only
+	// paid at the end and the calls forward at most 63/64 of the available gas
+	// (EIP-150), try first with that margin since it is usually enough.
+	lo := result.GasUsed - 1
+	if lo < intrinsicGasCost-1 {
+		lo = intrinsicGasCost - 1
+	}
+
+	optimistic := (result.GasUsed + refund + runtime.CallStipend) * 64 / 63
+	if optimistic > lo && optimistic < hi {
+		result, _, err := trial(optimistic)
+		if err == nil && !result.Failed() {
+			hi = optimistic
+		} else {
+			lo = optimistic
+		}
+	}
+
+	for lo+1 < hi {
+		mid := lo + (hi-lo)/2
+
+		result, _, err := trial(mid)
+		if err == nil && !result.Failed() {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+	return hi, nil
+}
+
+// revertError returns the error of a reverted transaction with the reason
+// of the revert if it is encoded as Error(string)
+func revertError(data []byte) error {
+	if reason, ok := runtime.UnpackRevertReason(data); ok {
+		return fmt.Errorf("%w: %s", runtime.ErrExecutionReverted, reason)
+	}
+	if len(data) != 0 {
+		return fmt.Errorf("%w: 0x%x", runtime.ErrExecutionReverted, data)
+	}
+	return runtime.ErrExecutionReverted
+}
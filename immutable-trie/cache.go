@@ -0,0 +1,130 @@
+package itrie
+
+import (
+	"container/list"
+	"sync"
+)
+
+// CacheStats are the statistics of a cache of the state
+type CacheStats struct {
+	// Hits is the number of lookups found in the cache
+	Hits uint64
+
+	// Misses is the number of lookups not found in the cache
+	Misses uint64
+
+	// Evictions is the number of entries removed to make room for new ones
+	Evictions uint64
+
+	// Entries is the number of entries in the cache
+	Entries int
+
+	// Size is the size in bytes of the entries in the cache
+	Size int
+
+	// Capacity is the maximum size in bytes of the cache
+	Capacity int
+}
+
+// sizedCache is a LRU cache bounded by the size in bytes of its entries.
+// It is safe for concurrent use.
+type sizedCache struct {
+	lock     sync.Mutex
+	items    map[string]*list.Element
+	order    *list.List
+	size     int
+	capacity int
+
+	hits      uint64
+	misses    uint64
+	evictions uint64
+}
+
+type sizedEntry struct {
+	key   string
+	value interface{}
+	size  int
+}
+
+// newSizedCache creates a cache of at most capacity bytes,
+// the cache does not store anything if the capacity is zero
+func newSizedCache(capacity int) *sizedCache {
+	return &sizedCache{
+		items:    map[string]*list.Element{},
+		order:    list.New(),
+		capacity: capacity,
+	}
+}
+
+// Get returns the value of a key and marks it as recently used
+func (c *sizedCache) Get(key []byte) (interface{}, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	elem, ok := c.items[string(key)]
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+	c.hits++
+	c.order.MoveToFront(elem)
+	return elem.Value.(*sizedEntry).value, true
+}
+
+// Add adds a value of the given size, the least recently used
+// values are removed until the cache fits its capacity
+func (c *sizedCache) Add(key []byte, value interface{}, size int) {
+	// a cache without capacity is disabled, even for the empty entries
+	if c.capacity == 0 || size > c.capacity {
+		return
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if elem, ok := c.items[string(key)]; ok {
+		entry := elem.Value.(*sizedEntry)
+		c.size += size - entry.size
+		entry.value, entry.size = value, size
+		c.order.MoveToFront(elem)
+	} else {
+		entry := &sizedEntry{key: string(key), value: value, size: size}
+		c.items[entry.key] = c.order.PushFront(entry)
+		c.size += size
+	}
+
+	for c.size > c.capacity {
+		elem := c.order.Back()
+		entry := elem.Value.(*sizedEntry)
+
+		c.order.Remove(elem)
+		delete(c.items, entry.key)
+		c.size -= entry.size
+		c.evictions++
+	}
+}
+
+// Purge removes all the entries of the cache
+func (c *sizedCache) Purge() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.items = map[string]*list.Element{}
+	c.order.Init()
+	c.size = 0
+}
+
+// Stats returns the statistics of the cache
+func (c *sizedCache) Stats() CacheStats {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return CacheStats{
+		Hits:      c.hits,
+		Misses:    c.misses,
+		Evictions: c.evictions,
+		Entries:   len(c.items),
+		Size:      c.size,
+		Capacity:  c.capacity,
+	}
+}
@@ -0,0 +1,105 @@
+package itrie
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/0xPolygon/eth-state-transition/types"
+)
+
+func TestSizedCache(t *testing.T) {
+	c := newSizedCache(10)
+
+	c.Add([]byte{1}, 1, 4)
+	c.Add([]byte{2}, 2, 4)
+
+	// 1 is the most recently used
+	_, ok := c.Get([]byte{1})
+	assert.True(t, ok)
+
+	// 2 is removed to fit 3
+	c.Add([]byte{3}, 3, 4)
+	_, ok = c.Get([]byte{2})
+	assert.False(t, ok)
+
+	// a value larger than the cache is not added
+	c.Add([]byte{4}, 4, 11)
+	_, ok = c.Get([]byte{4})
+	assert.False(t, ok)
+
+	// replace a value with a larger one
+	c.Add([]byte{3}, 3, 6)
+	val, ok := c.Get([]byte{3})
+	assert.True(t, ok)
+	assert.Equal(t, 3, val)
+
+	assert.Equal(t, CacheStats{
+		Hits:      2,
+		Misses:    2,
+		Evictions: 1,
+		Entries:   2,
+		Size:      10,
+		Capacity:  10,
+	}, c.Stats())
+
+	c.Purge()
+	assert.Equal(t, 0, c.Stats().Entries)
+	assert.Equal(t, 0, c.Stats().Size)
+}
+
+func TestStateCacheConfig(t *testing.T) {
+	storage := NewMemoryStorage()
+	root := buildAccounts(NewArchiveState(storage), 100)
+
+	// without caches all the nodes are loaded from the storage
+	s := NewArchiveStateWithCache(storage, &CacheConfig{})
+	snap, err := s.NewSnapshotAt(root)
+	assert.NoError(t, err)
+
+	addr := types.BytesToAddress([]byte{0, 10})
+	for i := 0; i < 2; i++ {
+		account, err := snap.GetAccount(addr)
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(10), account.Balance.Uint64())
+	}
+	assert.Equal(t, 0, s.Stats().Nodes.Entries)
+	assert.Zero(t, s.Stats().Nodes.Hits)
+
+	// the nodes of the path are loaded once
+	s = NewArchiveStateWithCache(storage, &CacheConfig{NodeCacheSize: 1024 * 1024})
+	snap, err = s.NewSnapshotAt(root)
+	assert.NoError(t, err)
+
+	_, err = snap.GetAccount(addr)
+	assert.NoError(t, err)
+	misses := s.Stats().Nodes.Misses
+
+	_, err = snap.GetAccount(addr)
+	assert.NoError(t, err)
+
+	stats := s.Stats().Nodes
+	assert.Equal(t, misses, stats.Misses)
+	assert.Equal(t, misses-1, stats.Hits)
+	assert.NotZero(t, stats.Size)
+
+	// the tries are not cached
+	snap.Commit(nil)
+	assert.Equal(t, 0, s.Stats().Tries.Entries)
+}
+
+func TestStateTrieCache(t *testing.T) {
+	s := NewArchiveState(NewMemoryStorage())
+	assert.Equal(t, DefaultCacheConfig.TrieCacheSize, s.Stats().Tries.Capacity)
+
+	// the committed tries are cached with the size of their nodes
+	root := buildAccounts(s, 100)
+	tries := s.Stats().Tries
+	assert.Equal(t, 101, tries.Entries)
+	assert.NotZero(t, tries.Size)
+
+	_, err := s.NewSnapshotAt(root)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), s.Stats().Tries.Hits)
+	assert.Zero(t, s.Stats().Nodes.Entries)
+}
@@ -0,0 +1,209 @@
+package itrie
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+
+	state "github.com/0xPolygon/eth-state-transition"
+	"github.com/0xPolygon/eth-state-transition/helper"
+	"github.com/0xPolygon/eth-state-transition/types"
+)
+
+// errDumpLimit stops the iteration of a dump when it reaches the limit
+var errDumpLimit = errors.New("dump limit reached")
+
+// DumpConfig are the options of a dump of the state
+type DumpConfig struct {
+	// Start is the hash of the address of the first account
+	Start types.Hash
+
+	// Limit is the maximum number of accounts, there is no limit if it is zero
+	Limit int
+
+	// SkipCode does not include the code of the contracts
+	SkipCode bool
+
+	// SkipStorage does not include the storage of the accounts
+	SkipStorage bool
+}
+
+// DumpAccount is an account of a dump. The tries only store the hash of the
+// addresses and storage keys, so the accounts and slots are identified by them.
+type DumpAccount struct {
+	Balance  *big.Int
+	Nonce    uint64
+	Root     types.Hash
+	CodeHash types.Hash
+	Code     []byte
+	Storage  map[types.Hash]types.Hash
+}
+
+type dumpAccountJSON struct {
+	Balance  string                    `json:"balance"`
+	Nonce    uint64                    `json:"nonce"`
+	Root     types.Hash                `json:"root"`
+	CodeHash types.Hash                `json:"codeHash"`
+	Code     string                    `json:"code,omitempty"`
+	Storage  map[types.Hash]types.Hash `json:"storage,omitempty"`
+}
+
+// MarshalJSON encodes the account in the format of the dumps of geth
+func (d *DumpAccount) MarshalJSON() ([]byte, error) {
+	acc := &dumpAccountJSON{
+		Balance:  d.Balance.String(),
+		Nonce:    d.Nonce,
+		Root:     d.Root,
+		CodeHash: d.CodeHash,
+		Storage:  d.Storage,
+	}
+	if len(d.Code) != 0 {
+		acc.Code = helper.EncodeToHex(d.Code)
+	}
+	return json.Marshal(acc)
+}
+
+// Dump is a page of the accounts of a state, like the result
+// of debug_dumpBlock and debug_accountRange
+type Dump struct {
+	Root     types.Hash                  `json:"root"`
+	Accounts map[types.Hash]*DumpAccount `json:"accounts"`
+
+	// Next is the hash of the address of the first account
+	// of the next page, it is nil if there are no more accounts
+	Next *types.Hash `json:"next,omitempty"`
+}
+
+// StorageRange is a page of the storage of an account, like
+// the result of debug_storageRangeAt
+type StorageRange struct {
+	Storage map[types.Hash]types.Hash `json:"storage"`
+
+	// Next is the hash of the first key of the next page,
+	// it is nil if there are no more slots
+	Next *types.Hash `json:"nextKey"`
+}
+
+// DumpTo streams the accounts of the state at a root in the order of the
+// hashes of their addresses. It returns the hash of the address of the next
+// account if the dump stopped at the limit.
+func (s *State) DumpTo(root types.Hash, config *DumpConfig, fn func(key types.Hash, account *DumpAccount) error) (*types.Hash, error) {
+	it, err := s.NewIterator(root, config.Start.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	count := 0
+	for it.Next() {
+		key := types.BytesToHash(it.Key())
+		if config.Limit != 0 && count == config.Limit {
+			return &key, nil
+		}
+
+		var account state.Account
+		if err := account.UnmarshalRlp(it.Value()); err != nil {
+			return nil, err
+		}
+
+		dump := &DumpAccount{
+			Balance:  account.Balance,
+			Nonce:    account.Nonce,
+			Root:     account.Root,
+			CodeHash: types.BytesToHash(account.CodeHash),
+		}
+		if !config.SkipCode {
+			dump.Code, _ = s.GetCode(dump.CodeHash)
+		}
+		if !config.SkipStorage {
+			dump.Storage = map[types.Hash]types.Hash{}
+			err := s.storageTo(account.Root, types.Hash{}, func(k, v types.Hash) error {
+				dump.Storage[k] = v
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if err := fn(key, dump); err != nil {
+			return nil, err
+		}
+		count++
+	}
+	return nil, it.Err()
+}
+
+// Dump returns a page of the accounts of the state at a root
+func (s *State) Dump(root types.Hash, config *DumpConfig) (*Dump, error) {
+	dump := &Dump{
+		Root:     root,
+		Accounts: map[types.Hash]*DumpAccount{},
+	}
+
+	next, err := s.DumpTo(root, config, func(key types.Hash, account *DumpAccount) error {
+		dump.Accounts[key] = account
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	dump.Next = next
+	return dump, nil
+}
+
+// StorageRange returns a page of the storage of an account in the state at
+// a root, starting at the hash of a key. There is no limit if it is zero and
+// the page is empty if the account does not exist.
+func (s *State) StorageRange(root types.Hash, addr types.Address, start types.Hash, limit int) (*StorageRange, error) {
+	snap, err := s.NewSnapshotAt(root)
+	if err != nil {
+		return nil, err
+	}
+	account, err := snap.GetAccount(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &StorageRange{
+		Storage: map[types.Hash]types.Hash{},
+	}
+	if account == nil {
+		return res, nil
+	}
+
+	err = s.storageTo(account.Root, start, func(k, v types.Hash) error {
+		if limit != 0 && len(res.Storage) == limit {
+			res.Next = &k
+			return errDumpLimit
+		}
+		res.Storage[k] = v
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDumpLimit) {
+		return nil, err
+	}
+	return res, nil
+}
+
+// storageTo streams the slots of a storage trie in the order of the hashes
+// of their keys, the iteration stops when fn returns an error
+func (s *State) storageTo(root types.Hash, start types.Hash, fn func(k, v types.Hash) error) error {
+	if root == state.EmptyRootHash {
+		return nil
+	}
+
+	it, err := s.NewIterator(root, start.Bytes())
+	if err != nil {
+		return err
+	}
+	for it.Next() {
+		value, err := decodeStorageValue(it.Value())
+		if err != nil {
+			return err
+		}
+		if err := fn(types.BytesToHash(it.Key()), types.BytesToHash(value.Bytes())); err != nil {
+			return err
+		}
+	}
+	return it.Err()
+}
@@ -0,0 +1,109 @@
+package itrie
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	state "github.com/0xPolygon/eth-state-transition"
+	"github.com/0xPolygon/eth-state-transition/helper"
+	"github.com/0xPolygon/eth-state-transition/types"
+)
+
+func TestDump(t *testing.T) {
+	s := NewArchiveState(NewMemoryStorage())
+	root := buildAccounts(s, 100)
+
+	all, err := s.Dump(root, &DumpConfig{})
+	assert.NoError(t, err)
+	assert.Len(t, all.Accounts, 100)
+	assert.Nil(t, all.Next)
+
+	addr := types.BytesToAddress([]byte{0, 10})
+	account := all.Accounts[types.BytesToHash(helper.Keccak256(addr.Bytes()))]
+	assert.Equal(t, big.NewInt(10), account.Balance)
+	assert.Equal(t, map[types.Hash]types.Hash{
+		types.BytesToHash(helper.Keccak256(types.BytesToHash([]byte{1}).Bytes())): types.BytesToHash([]byte{10}),
+	}, account.Storage)
+
+	// the pages have all the accounts in order
+	found := map[types.Hash]*DumpAccount{}
+	config := &DumpConfig{Limit: 30}
+	pages := 0
+	for {
+		page, err := s.Dump(root, config)
+		assert.NoError(t, err)
+		for k, v := range page.Accounts {
+			found[k] = v
+		}
+		pages++
+		if page.Next == nil {
+			break
+		}
+		config.Start = *page.Next
+	}
+	assert.Equal(t, 4, pages)
+	assert.Equal(t, all.Accounts, found)
+
+	// the dump streams the accounts in order
+	prev := types.Hash{}
+	_, err = s.DumpTo(root, &DumpConfig{SkipStorage: true}, func(key types.Hash, account *DumpAccount) error {
+		assert.True(t, key.String() > prev.String())
+		assert.Nil(t, account.Storage)
+		prev = key
+		return nil
+	})
+	assert.NoError(t, err)
+
+	data, err := json.Marshal(all)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"balance":"10"`)
+}
+
+func TestStorageRange(t *testing.T) {
+	s := NewArchiveState(NewMemoryStorage())
+
+	addr := types.StringToAddress("0x1000")
+	obj := &state.Object{
+		Address:  addr,
+		Balance:  big.NewInt(1),
+		CodeHash: types.BytesToHash(state.EmptyCodeHash),
+		Root:     state.EmptyRootHash,
+	}
+	for i := 1; i <= 50; i++ {
+		obj.Storage = append(obj.Storage, &state.StorageObject{
+			Key: types.BytesToHash([]byte{byte(i)}).Bytes(),
+			Val: []byte{byte(i)},
+		})
+	}
+	_, hash := s.NewSnapshot().Commit([]*state.Object{obj})
+	root := types.BytesToHash(hash)
+
+	all, err := s.StorageRange(root, addr, types.Hash{}, 0)
+	assert.NoError(t, err)
+	assert.Len(t, all.Storage, 50)
+	assert.Nil(t, all.Next)
+
+	found := map[types.Hash]types.Hash{}
+	start := types.Hash{}
+	for {
+		page, err := s.StorageRange(root, addr, start, 20)
+		assert.NoError(t, err)
+		assert.LessOrEqual(t, len(page.Storage), 20)
+		for k, v := range page.Storage {
+			found[k] = v
+		}
+		if page.Next == nil {
+			break
+		}
+		start = *page.Next
+	}
+	assert.Equal(t, all.Storage, found)
+
+	// account that does not exist
+	empty, err := s.StorageRange(root, types.StringToAddress("0x2000"), types.Hash{}, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, empty.Storage)
+}
@@ -39,6 +39,20 @@ func keybytesToHex(str []byte) []byte {
 	return nibbles
 }
 
+// validCompact returns whether a key is in the compact encoding, which
+// starts with the flags of the terminator and of the odd length.
+func validCompact(compact []byte) bool {
+	if len(compact) == 0 {
+		return false
+	}
+	flags := compact[0] >> 4
+	if flags > 3 {
+		return false
+	}
+	// the padding nibble of the even keys is zero
+	return flags&1 == 1 || compact[0]&0xf == 0
+}
+
 func compactToHex(compact []byte) []byte {
 	base := keybytesToHex(compact)
 	// delete terminator flag
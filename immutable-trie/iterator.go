@@ -0,0 +1,151 @@
+package itrie
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/0xPolygon/eth-state-transition/types"
+)
+
+// Iterator iterates over the leaves of a trie in the order of their keys.
+// It does not modify the nodes, so many iterators can run concurrently.
+type Iterator struct {
+	storage TxnState
+
+	// start is the first key, hexStart is the key in hex nibbles
+	start    []byte
+	hexStart []byte
+
+	// stack are the nodes left to visit, the next one is the last
+	stack []iteratorEntry
+
+	key   []byte
+	value []byte
+	err   error
+}
+
+type iteratorEntry struct {
+	node Node
+	path []byte
+}
+
+// NewIterator returns an iterator over the leaves of the trie
+// with a key equal or greater than start
+func (t *Txn) NewIterator(start []byte) *Iterator {
+	hexStart := keybytesToHex(start)
+
+	it := &Iterator{
+		storage:  t.storage,
+		start:    start,
+		hexStart: hexStart[:len(hexStart)-1],
+	}
+
+	if t.root != nil {
+		it.stack = append(it.stack, iteratorEntry{node: t.root})
+	}
+	return it
+}
+
+// NewIterator returns an iterator over the leaves of the trie at a root with
+// a key equal or greater than start. The root can be the root of the state
+// or the storage root of an account.
+func (s *State) NewIterator(root types.Hash, start []byte) (*Iterator, error) {
+	snap, err := s.NewSnapshotAt(root)
+	if err != nil {
+		return nil, err
+	}
+	return snap.(*Snapshot).trieRoot.Txn().NewIterator(start), nil
+}
+
+// Next moves to the next leaf, it returns false when there
+// are no more leaves or the iteration failed
+func (it *Iterator) Next() bool {
+	for len(it.stack) != 0 && it.err == nil {
+		entry := it.stack[len(it.stack)-1]
+		it.stack = it.stack[:len(it.stack)-1]
+
+		// skip the nodes before the start key
+		if it.before(entry.path) {
+			continue
+		}
+
+		switch n := entry.node.(type) {
+		case *ValueNode:
+			if n.hash {
+				nc, ok, err := it.storage.GetNode(n.buf)
+				if err != nil {
+					it.err = err
+					return false
+				}
+				if !ok {
+					it.err = fmt.Errorf("node %s not found", types.BytesToHash(n.buf))
+					return false
+				}
+				it.stack = append(it.stack, iteratorEntry{node: nc, path: entry.path})
+				continue
+			}
+
+			key := hexToKeybytes(entry.path)
+			if bytes.Compare(key, it.start) < 0 {
+				continue
+			}
+			it.key, it.value = key, n.buf
+			return true
+
+		case *ShortNode:
+			it.stack = append(it.stack, iteratorEntry{node: n.child, path: concat(entry.path, n.key)})
+
+		case *FullNode:
+			// the children are visited in order and after the value
+			for i := 15; i >= 0; i-- {
+				if child := n.children[i]; child != nil {
+					it.stack = append(it.stack, iteratorEntry{node: child, path: concat(entry.path, []byte{byte(i)})})
+				}
+			}
+			if n.value != nil {
+				it.stack = append(it.stack, iteratorEntry{node: n.value, path: entry.path})
+			}
+
+		default:
+			panic(fmt.Sprintf("unknown node type %v", n))
+		}
+	}
+	return false
+}
+
+// before returns whether all the keys under a path are before the start key
+func (it *Iterator) before(path []byte) bool {
+	if hasTerm(path) {
+		path = path[:len(path)-1]
+	}
+	l := len(path)
+	if l > len(it.hexStart) {
+		l = len(it.hexStart)
+	}
+	return bytes.Compare(path[:l], it.hexStart[:l]) < 0
+}
+
+// Key returns the key of the current leaf
+func (it *Iterator) Key() []byte {
+	return it.key
+}
+
+// Value returns the value of the current leaf
+func (it *Iterator) Value() []byte {
+	return it.value
+}
+
+// Err returns the error of the iteration if it failed
+func (it *Iterator) Err() error {
+	return it.err
+}
+
+// hexToKeybytes converts hex nibbles to the key bytes
+func hexToKeybytes(hex []byte) []byte {
+	if hasTerm(hex) {
+		hex = hex[:len(hex)-1]
+	}
+	key := make([]byte, len(hex)/2)
+	decodeNibbles(hex[:len(key)*2], key)
+	return key
+}
@@ -0,0 +1,69 @@
+package itrie
+
+import (
+	"bytes"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/0xPolygon/eth-state-transition/types"
+)
+
+func TestIterator(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	// keys of different lengths so that some are prefixes of others
+	values := map[string][]byte{}
+	for i := 0; i < 500; i++ {
+		key := make([]byte, 1+r.Intn(4))
+		r.Read(key)
+		values[string(key)] = []byte{byte(i), byte(i >> 8)}
+	}
+
+	keys := []string{}
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	storage := NewMemoryStorage()
+	batch := storage.Batch()
+
+	txn := NewTrie().Txn()
+	txn.batch = batch
+	for k, v := range values {
+		txn.Insert([]byte(k), v)
+	}
+	hash, err := txn.Hash()
+	assert.NoError(t, err)
+	batch.Write()
+
+	iterate := func(it *Iterator) []string {
+		found := []string{}
+		for it.Next() {
+			assert.Equal(t, values[string(it.Key())], it.Value())
+			found = append(found, string(it.Key()))
+		}
+		assert.NoError(t, it.Err())
+		return found
+	}
+
+	// the trie in memory and the trie loaded from the storage
+	reloaded, err := NewArchiveState(storage).NewIterator(types.BytesToHash(hash), nil)
+	assert.NoError(t, err)
+
+	assert.Equal(t, keys, iterate(txn.NewIterator(nil)))
+	assert.Equal(t, keys, iterate(reloaded))
+
+	for _, start := range [][]byte{{0x00}, {0x80}, {0x80, 0x01, 0x02}, {0xff, 0xff, 0xff, 0xff, 0xff}, []byte(keys[100])} {
+		i := sort.Search(len(keys), func(i int) bool {
+			return bytes.Compare([]byte(keys[i]), start) >= 0
+		})
+		assert.Equal(t, keys[i:], iterate(txn.NewIterator(start)), "start %x", start)
+	}
+
+	// empty trie
+	assert.False(t, NewTrie().Txn().NewIterator(nil).Next())
+}
@@ -96,6 +96,10 @@ var parserPool fastrlp.ParserPool
 func DecodeNode(data []byte) (Node, error) {
 	// NOTE. We dont need to make copies of the bytes because the nodes
 	// take the reference from data itself which is a safe copy.
+	if err := checkRlp(data); err != nil {
+		return nil, err
+	}
+
 	p := parserPool.Get()
 	defer parserPool.Put(p)
 
@@ -122,17 +126,35 @@ func DecodeNode(data []byte) (Node, error) {
 func decodeNodeImpl(v *fastrlp.Value) (Node, error) {
 	var err error
 
+	if v.Type() == fastrlp.TypeBytes {
+		// reference to a stored node
+		if len(v.Raw()) != 32 {
+			return nil, fmt.Errorf("node reference expected to be a hash")
+		}
+		vv := &ValueNode{
+			hash: true,
+		}
+		vv.buf = append(vv.buf[:0], v.Raw()...)
+		return vv, nil
+	}
+
 	ll := v.Elems()
 	if ll == 2 {
 		key := v.Get(0)
 		if key.Type() != fastrlp.TypeBytes {
 			return nil, fmt.Errorf("short key expected to be bytes")
 		}
+		if !validCompact(key.Raw()) {
+			return nil, fmt.Errorf("short key is not a valid compact key")
+		}
 
 		// this can be either an array (extension node)
 		// or bytes (leaf node)
 		nc := &ShortNode{}
 		nc.key = compactToHex(key.Raw())
+		if len(nc.key) == 0 {
+			return nil, fmt.Errorf("extension node with an empty key")
+		}
 		if hasTerm(nc.key) {
 			// value node
 			if v.Get(1).Type() != fastrlp.TypeBytes {
@@ -174,3 +196,65 @@ func decodeNodeImpl(v *fastrlp.Value) (Node, error) {
 	}
 	return nil, fmt.Errorf("node has incorrect number of leafs")
 }
+
+// checkRlp checks that data is a single RLP value and that the lengths of
+// all its items fit in it. The parser reads the long lengths without
+// checking them, so the nodes that are not trusted are checked first.
+func checkRlp(data []byte) error {
+	size, err := rlpItemSize(data)
+	if err != nil {
+		return err
+	}
+	if size != uint64(len(data)) {
+		return fmt.Errorf("rlp value has trailing bytes")
+	}
+	return nil
+}
+
+// rlpItemSize returns the size of the first RLP item of b
+func rlpItemSize(b []byte) (uint64, error) {
+	if len(b) == 0 {
+		return 0, fmt.Errorf("rlp value is empty")
+	}
+
+	cur := b[0]
+	if cur < 0x80 {
+		return 1, nil
+	}
+
+	var header, size uint64
+	switch {
+	case cur < 0xb8:
+		header, size = 1, uint64(cur-0x80)
+	case cur < 0xc0:
+		header = 1 + uint64(cur-0xb7)
+	case cur < 0xf8:
+		header, size = 1, uint64(cur-0xc0)
+	default:
+		header = 1 + uint64(cur-0xf7)
+	}
+	if header > uint64(len(b)) {
+		return 0, fmt.Errorf("rlp length is too short")
+	}
+	if header > 1 {
+		for _, c := range b[1:header] {
+			size = size<<8 | uint64(c)
+		}
+	}
+	if size > uint64(len(b))-header {
+		return 0, fmt.Errorf("rlp value is too short")
+	}
+
+	if cur >= 0xc0 {
+		// the items of the list
+		items := b[header : header+size]
+		for len(items) != 0 {
+			n, err := rlpItemSize(items)
+			if err != nil {
+				return 0, err
+			}
+			items = items[n:]
+		}
+	}
+	return header + size, nil
+}
@@ -0,0 +1,441 @@
+package itrie
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	state "github.com/0xPolygon/eth-state-transition"
+	"github.com/0xPolygon/eth-state-transition/helper"
+	"github.com/0xPolygon/eth-state-transition/types"
+	"github.com/umbracle/fastrlp"
+)
+
+var (
+	// ErrProofMissingNode is returned when a node of the path of a key is not in the proof
+	ErrProofMissingNode = errors.New("proof is missing a node")
+
+	// ErrProofMismatch is returned when a proof does not match the values it proves
+	ErrProofMismatch = errors.New("proof does not match the values")
+)
+
+// Prove returns the RLP encoded nodes of the path of a key from the root of
+// the trie. The path proves the value of the key or, if the key is not in the
+// trie, its absence. Like in Ethereum, the nodes embedded in their parents are
+// not included.
+func (t *Txn) Prove(key []byte) ([][]byte, error) {
+	if t.root == nil {
+		return [][]byte{}, nil
+	}
+
+	arena := arenaPool.Get()
+	defer arenaPool.Put(arena)
+
+	proof := [][]byte{}
+
+	var node Node = t.root
+	path := keybytesToHex(key)
+	root := true
+
+	for node != nil {
+		if v, ok := node.(*ValueNode); ok {
+			if !v.hash {
+				break
+			}
+			nc, ok, err := t.storage.GetNode(v.buf)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				return nil, fmt.Errorf("node %s not found", helper.EncodeToHex(v.buf))
+			}
+			node, root = nc, true
+			continue
+		}
+
+		val := encodeNode(node, arena)
+		if root || val.Len() >= 32 {
+			proof = append(proof, val.MarshalTo(nil))
+		}
+		arena.Reset()
+		root = false
+
+		switch n := node.(type) {
+		case *ShortNode:
+			plen := len(n.key)
+			if plen > len(path) || !bytes.Equal(path[:plen], n.key) {
+				return proof, nil
+			}
+			node, path = n.child, path[plen:]
+
+		case *FullNode:
+			if len(path) == 0 {
+				node = n.value
+			} else {
+				node, path = n.getEdge(path[0]), path[1:]
+			}
+
+		default:
+			panic(fmt.Sprintf("unknown node type %v", n))
+		}
+	}
+	return proof, nil
+}
+
+// encodeNode returns the RLP encoding of a node. Unlike the hasher it does
+// not modify the nodes, so it can be used on the tries of a snapshot.
+func encodeNode(node Node, a *fastrlp.Arena) *fastrlp.Value {
+	val := a.NewArray()
+
+	switch n := node.(type) {
+	case *ShortNode:
+		val.Set(a.NewBytes(hexToCompact(n.key)))
+		val.Set(encodeRef(n.child, a))
+
+	case *FullNode:
+		for _, i := range n.children {
+			if i == nil {
+				val.Set(a.NewNull())
+			} else {
+				val.Set(encodeRef(i, a))
+			}
+		}
+		if n.value == nil {
+			val.Set(a.NewNull())
+		} else {
+			val.Set(encodeRef(n.value, a))
+		}
+
+	default:
+		panic(fmt.Sprintf("unknown node type %v", n))
+	}
+	return val
+}
+
+// encodeRef returns the reference to a node in its parent, which is
+// either the hash of the node or the node itself if it is short
+func encodeRef(node Node, a *fastrlp.Arena) *fastrlp.Value {
+	if h, ok := node.Hash(); ok {
+		return a.NewCopyBytes(h)
+	}
+	if v, ok := node.(*ValueNode); ok {
+		return a.NewCopyBytes(v.buf)
+	}
+
+	val := encodeNode(node, a)
+	if val.Len() < 32 {
+		return val
+	}
+	return a.NewCopyBytes(helper.Keccak256(val.MarshalTo(nil)))
+}
+
+// VerifyProof checks the proof of a key against the root of a trie. It returns
+// the value of the key or nil if the proof shows that the key is not in the trie.
+func VerifyProof(root types.Hash, key []byte, proof [][]byte) ([]byte, error) {
+	if root == state.EmptyRootHash {
+		return nil, nil
+	}
+
+	nodes := map[string][]byte{}
+	for _, data := range proof {
+		nodes[string(helper.Keccak256(data))] = data
+	}
+
+	hash := root.Bytes()
+	path := keybytesToHex(key)
+
+	for {
+		data, ok := nodes[string(hash)]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrProofMissingNode, helper.EncodeToHex(hash))
+		}
+		node, err := DecodeNode(data)
+		if err != nil {
+			return nil, fmt.Errorf("%w: node %s: %v", ErrProofMismatch, helper.EncodeToHex(hash), err)
+		}
+
+	WALK:
+		for {
+			switch n := node.(type) {
+			case nil:
+				return nil, nil
+
+			case *ValueNode:
+				if n.hash {
+					hash = n.buf
+					break WALK
+				}
+				if len(path) != 0 {
+					return nil, nil
+				}
+				return n.buf, nil
+
+			case *ShortNode:
+				plen := len(n.key)
+				if plen > len(path) || !bytes.Equal(path[:plen], n.key) {
+					return nil, nil
+				}
+				node, path = n.child, path[plen:]
+
+			case *FullNode:
+				if len(path) == 0 {
+					node = n.value
+				} else {
+					node, path = n.getEdge(path[0]), path[1:]
+				}
+
+			default:
+				return nil, fmt.Errorf("%w: unknown node type %T", ErrProofMismatch, n)
+			}
+		}
+	}
+}
+
+// AccountProof is the proof of an account and some of its storage slots
+// under a state root, like the result of eth_getProof
+type AccountProof struct {
+	Address      types.Address
+	AccountProof [][]byte
+	Balance      *big.Int
+	CodeHash     types.Hash
+	Nonce        uint64
+	StorageHash  types.Hash
+	StorageProof []*StorageProof
+}
+
+// StorageProof is the proof of a storage slot under the storage root of an account
+type StorageProof struct {
+	Key   types.Hash
+	Value *big.Int
+	Proof [][]byte
+}
+
+// GetProof returns the proof of an account and of the given storage slots.
+// The proof of an account that does not exist shows its absence and
+// has empty values.
+func (s *Snapshot) GetProof(addr types.Address, keys []types.Hash) (*AccountProof, error) {
+	proof, err := s.trieRoot.Txn().Prove(hashit(addr.Bytes()))
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := s.GetAccount(addr)
+	if err != nil {
+		return nil, err
+	}
+	if account == nil {
+		account = &state.Account{
+			Balance:  big.NewInt(0),
+			CodeHash: state.EmptyCodeHash,
+			Root:     state.EmptyRootHash,
+		}
+	}
+
+	res := &AccountProof{
+		Address:      addr,
+		AccountProof: proof,
+		Balance:      account.Balance,
+		CodeHash:     types.BytesToHash(account.CodeHash),
+		Nonce:        account.Nonce,
+		StorageHash:  account.Root,
+		StorageProof: []*StorageProof{},
+	}
+
+	var storage *Txn
+	if account.Root != state.EmptyRootHash {
+		snap, err := s.state.NewSnapshotAt(account.Root)
+		if err != nil {
+			return nil, err
+		}
+		storage = snap.(*Snapshot).trieRoot.Txn()
+	}
+
+	for _, key := range keys {
+		slot := &StorageProof{
+			Key:   key,
+			Value: big.NewInt(0),
+			Proof: [][]byte{},
+		}
+		if storage != nil {
+			k := hashit(key.Bytes())
+			if slot.Proof, err = storage.Prove(k); err != nil {
+				return nil, err
+			}
+			if slot.Value, err = decodeStorageValue(storage.Lookup(k)); err != nil {
+				return nil, err
+			}
+		}
+		res.StorageProof = append(res.StorageProof, slot)
+	}
+	return res, nil
+}
+
+// VerifyAccountProof checks the proof of an account and of its storage
+// slots against a state root
+func VerifyAccountProof(root types.Hash, proof *AccountProof) error {
+	data, err := VerifyProof(root, hashit(proof.Address.Bytes()), proof.AccountProof)
+	if err != nil {
+		return err
+	}
+
+	account := state.Account{
+		Balance: big.NewInt(0),
+		Root:    state.EmptyRootHash,
+	}
+	if data != nil {
+		// the code hash is decoded in place, so it does not start as the shared empty hash
+		if err := account.UnmarshalRlp(data); err != nil {
+			return err
+		}
+	} else {
+		account.CodeHash = state.EmptyCodeHash
+	}
+
+	if proof.Nonce != account.Nonce ||
+		proof.Balance == nil || proof.Balance.Cmp(account.Balance) != 0 ||
+		proof.CodeHash != types.BytesToHash(account.CodeHash) ||
+		proof.StorageHash != account.Root {
+		return fmt.Errorf("%w: account %s", ErrProofMismatch, proof.Address)
+	}
+
+	for _, slot := range proof.StorageProof {
+		data, err := VerifyProof(proof.StorageHash, hashit(slot.Key.Bytes()), slot.Proof)
+		if err != nil {
+			return err
+		}
+		value, err := decodeStorageValue(data)
+		if err != nil {
+			return err
+		}
+		if slot.Value == nil || slot.Value.Cmp(value) != 0 {
+			return fmt.Errorf("%w: slot %s", ErrProofMismatch, slot.Key)
+		}
+	}
+	return nil
+}
+
+// decodeStorageValue decodes the RLP encoded value of a storage slot
+func decodeStorageValue(data []byte) (*big.Int, error) {
+	if data == nil {
+		return big.NewInt(0), nil
+	}
+
+	p := stateStateParserPool.Get()
+	defer stateStateParserPool.Put(p)
+
+	v, err := p.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	buf, err := v.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(buf), nil
+}
+
+type accountProofJSON struct {
+	Address      types.Address       `json:"address"`
+	AccountProof []string            `json:"accountProof"`
+	Balance      string              `json:"balance"`
+	CodeHash     types.Hash          `json:"codeHash"`
+	Nonce        string              `json:"nonce"`
+	StorageHash  types.Hash          `json:"storageHash"`
+	StorageProof []*storageProofJSON `json:"storageProof"`
+}
+
+type storageProofJSON struct {
+	Key   types.Hash `json:"key"`
+	Value string     `json:"value"`
+	Proof []string   `json:"proof"`
+}
+
+// MarshalJSON encodes the proof in the format of eth_getProof
+func (p *AccountProof) MarshalJSON() ([]byte, error) {
+	res := &accountProofJSON{
+		Address:      p.Address,
+		AccountProof: encodeProofJSON(p.AccountProof),
+		Balance:      encodeQuantityJSON(p.Balance),
+		CodeHash:     p.CodeHash,
+		Nonce:        encodeQuantityJSON(new(big.Int).SetUint64(p.Nonce)),
+		StorageHash:  p.StorageHash,
+		StorageProof: []*storageProofJSON{},
+	}
+	for _, slot := range p.StorageProof {
+		res.StorageProof = append(res.StorageProof, &storageProofJSON{
+			Key:   slot.Key,
+			Value: encodeQuantityJSON(slot.Value),
+			Proof: encodeProofJSON(slot.Proof),
+		})
+	}
+	return json.Marshal(res)
+}
+
+// UnmarshalJSON decodes the proof from the format of eth_getProof
+func (p *AccountProof) UnmarshalJSON(data []byte) error {
+	var res accountProofJSON
+	if err := json.Unmarshal(data, &res); err != nil {
+		return err
+	}
+
+	var err error
+	if p.AccountProof, err = decodeProofJSON(res.AccountProof); err != nil {
+		return err
+	}
+	if p.Balance, err = helper.ParseUint256orHex(&res.Balance); err != nil {
+		return err
+	}
+	if p.Nonce, err = helper.ParseUint64orHex(&res.Nonce); err != nil {
+		return err
+	}
+	p.Address = res.Address
+	p.CodeHash = res.CodeHash
+	p.StorageHash = res.StorageHash
+
+	p.StorageProof = []*StorageProof{}
+	for _, slot := range res.StorageProof {
+		value, err := helper.ParseUint256orHex(&slot.Value)
+		if err != nil {
+			return err
+		}
+		proof, err := decodeProofJSON(slot.Proof)
+		if err != nil {
+			return err
+		}
+		p.StorageProof = append(p.StorageProof, &StorageProof{
+			Key:   slot.Key,
+			Value: value,
+			Proof: proof,
+		})
+	}
+	return nil
+}
+
+func encodeQuantityJSON(b *big.Int) string {
+	if b == nil {
+		return "0x0"
+	}
+	return "0x" + b.Text(16)
+}
+
+func encodeProofJSON(proof [][]byte) []string {
+	res := []string{}
+	for _, node := range proof {
+		res = append(res, helper.EncodeToHex(node))
+	}
+	return res
+}
+
+func decodeProofJSON(proof []string) ([][]byte, error) {
+	res := [][]byte{}
+	for _, node := range proof {
+		buf, err := helper.DecodeHex(node)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, buf)
+	}
+	return res, nil
+}
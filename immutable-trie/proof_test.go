@@ -0,0 +1,224 @@
+package itrie
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	state "github.com/0xPolygon/eth-state-transition"
+	"github.com/0xPolygon/eth-state-transition/helper"
+	"github.com/0xPolygon/eth-state-transition/types"
+)
+
+func TestProofTrie(t *testing.T) {
+	// short keys and values so that some nodes are embedded in their parents
+	values := map[string]string{
+		"a":     "1",
+		"ab":    "2",
+		"abc":   "3",
+		"b":     "value of b that is longer than thirty two bytes",
+		"bcdef": "5",
+		"z":     "6",
+	}
+
+	txn := NewTrie().Txn()
+	for k, v := range values {
+		txn.Insert([]byte(k), []byte(v))
+	}
+	hash, err := txn.Hash()
+	assert.NoError(t, err)
+	root := types.BytesToHash(hash)
+
+	for k, v := range values {
+		proof, err := txn.Prove([]byte(k))
+		assert.NoError(t, err)
+
+		found, err := VerifyProof(root, []byte(k), proof)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte(v), found, k)
+	}
+
+	for _, k := range []string{"", "c", "abcd", "bc", "zz"} {
+		proof, err := txn.Prove([]byte(k))
+		assert.NoError(t, err)
+
+		found, err := VerifyProof(root, []byte(k), proof)
+		assert.NoError(t, err)
+		assert.Nil(t, found, k)
+	}
+
+	// empty trie
+	proof, err := NewTrie().Txn().Prove([]byte("a"))
+	assert.NoError(t, err)
+
+	found, err := VerifyProof(state.EmptyRootHash, []byte("a"), proof)
+	assert.NoError(t, err)
+	assert.Nil(t, found)
+}
+
+func TestGetProof(t *testing.T) {
+	storage := NewMemoryStorage()
+	s := NewArchiveState(storage)
+	root := buildAccounts(s, 300)
+
+	keys := []types.Hash{types.BytesToHash([]byte{1}), types.BytesToHash([]byte{2})}
+
+	// the cached tries and the tries loaded from the storage prove the same
+	reloaded := NewArchiveState(storage)
+
+	for _, st := range []*State{s, reloaded} {
+		snap, err := st.NewSnapshotAt(root)
+		assert.NoError(t, err)
+
+		for _, i := range []int{1, 100, 299} {
+			addr := types.BytesToAddress([]byte{byte(i >> 8), byte(i)})
+
+			proof, err := snap.(*Snapshot).GetProof(addr, keys)
+			assert.NoError(t, err)
+			assert.NoError(t, VerifyAccountProof(root, proof))
+
+			assert.Equal(t, big.NewInt(int64(i)), proof.Balance)
+			assert.Equal(t, big.NewInt(int64(byte(i))), proof.StorageProof[0].Value)
+			assert.NotEmpty(t, proof.StorageProof[0].Proof)
+			assert.Equal(t, big.NewInt(0), proof.StorageProof[1].Value)
+		}
+
+		// proof of absence of an account
+		proof, err := snap.(*Snapshot).GetProof(types.StringToAddress("0x1000"), keys)
+		assert.NoError(t, err)
+		assert.NoError(t, VerifyAccountProof(root, proof))
+
+		assert.NotEmpty(t, proof.AccountProof)
+		assert.Equal(t, types.BytesToHash(state.EmptyCodeHash), proof.CodeHash)
+		assert.Equal(t, state.EmptyRootHash, proof.StorageHash)
+		assert.Empty(t, proof.StorageProof[0].Proof)
+	}
+}
+
+func TestVerifyAccountProofInvalid(t *testing.T) {
+	s := NewArchiveState(NewMemoryStorage())
+	root := buildAccounts(s, 300)
+
+	snap, err := s.NewSnapshotAt(root)
+	assert.NoError(t, err)
+
+	addr := types.BytesToAddress([]byte{0, 100})
+	keys := []types.Hash{types.BytesToHash([]byte{1})}
+
+	getProof := func() *AccountProof {
+		proof, err := snap.(*Snapshot).GetProof(addr, keys)
+		assert.NoError(t, err)
+		return proof
+	}
+
+	// wrong balance
+	proof := getProof()
+	proof.Balance = big.NewInt(1)
+	assert.ErrorIs(t, VerifyAccountProof(root, proof), ErrProofMismatch)
+
+	// wrong storage value
+	proof = getProof()
+	proof.StorageProof[0].Value = big.NewInt(1)
+	assert.ErrorIs(t, VerifyAccountProof(root, proof), ErrProofMismatch)
+
+	// missing node
+	proof = getProof()
+	proof.AccountProof = proof.AccountProof[:len(proof.AccountProof)-1]
+	assert.ErrorIs(t, VerifyAccountProof(root, proof), ErrProofMissingNode)
+
+	// modified node
+	proof = getProof()
+	proof.StorageProof[0].Proof[0][5]++
+	assert.ErrorIs(t, VerifyAccountProof(root, proof), ErrProofMissingNode)
+
+	// wrong root
+	proof = getProof()
+	assert.ErrorIs(t, VerifyAccountProof(types.StringToHash("0x1"), proof), ErrProofMissingNode)
+}
+
+func TestVerifyAccountProofCode(t *testing.T) {
+	s := NewArchiveState(NewMemoryStorage())
+
+	addr := types.StringToAddress("0x1000")
+	codeHash := types.StringToHash("0x1234")
+
+	_, root := s.NewSnapshot().Commit([]*state.Object{
+		{
+			Address:  addr,
+			Balance:  big.NewInt(1),
+			CodeHash: codeHash,
+			Root:     state.EmptyRootHash,
+		},
+	})
+
+	snap, err := s.NewSnapshotAt(types.BytesToHash(root))
+	assert.NoError(t, err)
+
+	proof, err := snap.(*Snapshot).GetProof(addr, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, VerifyAccountProof(types.BytesToHash(root), proof))
+	assert.Equal(t, codeHash, proof.CodeHash)
+
+	// the code hash of the account is not decoded in the empty code hash
+	assert.Equal(t, helper.Keccak256(nil), state.EmptyCodeHash)
+}
+
+func TestVerifyProofMalformed(t *testing.T) {
+	cases := []struct {
+		name string
+		node string
+	}{
+		{"not rlp", "0xff"},
+		{"truncated list item", "0xc1b9"},
+		{"not a list", "0x8180"},
+		{"wrong number of items", "0xc3808080"},
+		{"empty key", "0xc28080"},
+		{"invalid key flags", "0xc24080"},
+		{"invalid key padding", "0xc4820112c0"},
+		{"extension with an empty key", "0xc200c0"},
+		{"short reference", "0xc51183010203"},
+		{"short reference in a full node", "0xd48301020380808080808080808080808080808080"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			node, err := helper.DecodeHex(c.node)
+			assert.NoError(t, err)
+
+			root := types.BytesToHash(helper.Keccak256(node))
+			_, err = VerifyProof(root, []byte("a"), [][]byte{node})
+			assert.ErrorIs(t, err, ErrProofMismatch)
+		})
+	}
+}
+
+func TestAccountProofJSON(t *testing.T) {
+	s := NewArchiveState(NewMemoryStorage())
+	root := buildAccounts(s, 20)
+
+	snap, err := s.NewSnapshotAt(root)
+	assert.NoError(t, err)
+
+	proof, err := snap.(*Snapshot).GetProof(types.BytesToAddress([]byte{0, 10}), []types.Hash{types.BytesToHash([]byte{1})})
+	assert.NoError(t, err)
+
+	data, err := json.Marshal(proof)
+	assert.NoError(t, err)
+
+	var fields map[string]interface{}
+	assert.NoError(t, json.Unmarshal(data, &fields))
+	assert.Equal(t, "0xa", fields["balance"])
+	assert.Equal(t, "0x0", fields["nonce"])
+	assert.Equal(t, "0x000000000000000000000000000000000000000a", fields["address"])
+
+	slot := fields["storageProof"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "0x0000000000000000000000000000000000000000000000000000000000000001", slot["key"])
+	assert.Equal(t, "0xa", slot["value"])
+
+	found := &AccountProof{}
+	assert.NoError(t, json.Unmarshal(data, found))
+	assert.Equal(t, proof, found)
+	assert.NoError(t, VerifyAccountProof(root, found))
+}
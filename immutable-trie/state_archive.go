@@ -3,8 +3,6 @@ package itrie
 import (
 	"fmt"
 
-	lru "github.com/hashicorp/golang-lru"
-
 	"bytes"
 
 	state "github.com/0xPolygon/eth-state-transition"
@@ -14,19 +12,63 @@ import (
 	"golang.org/x/crypto/sha3"
 )
 
+// CacheConfig are the sizes of the caches of a state
+type CacheConfig struct {
+	// TrieCacheSize is the size in bytes of the cache of the committed tries
+	// by root, the size of a trie is the size of the nodes it committed
+	TrieCacheSize int
+
+	// NodeCacheSize is the size in bytes of the cache of the decoded nodes
+	// by hash, the size of a node is the size of its encoding
+	NodeCacheSize int
+}
+
+// DefaultCacheConfig are the sizes of the caches of the states created
+// with NewArchiveState
+var DefaultCacheConfig = &CacheConfig{
+	TrieCacheSize: 16 * 1024 * 1024,
+	NodeCacheSize: 64 * 1024 * 1024,
+}
+
+// StateStats are the statistics of the caches of a state
+type StateStats struct {
+	Tries CacheStats
+	Nodes CacheStats
+}
+
+// State is the archive of the tries stored in a storage. The state and its
+// snapshots are safe for concurrent use by many readers and one writer
+// that commits the snapshots.
 type State struct {
 	storage Storage
-	cache   *lru.Cache
+
+	// tries are the committed tries by root
+	tries *sizedCache
+
+	// nodes are the decoded nodes of the storage by hash
+	nodes *sizedCache
 }
 
+// NewArchiveState creates a state with the default sizes of the caches
 func NewArchiveState(storage Storage) *State {
-	cache, _ := lru.New(128)
+	return NewArchiveStateWithCache(storage, DefaultCacheConfig)
+}
 
-	s := &State{
+// NewArchiveStateWithCache creates a state with the given sizes of the caches
+func NewArchiveStateWithCache(storage Storage, config *CacheConfig) *State {
+	return &State{
 		storage: storage,
-		cache:   cache,
+		tries:   newSizedCache(config.TrieCacheSize),
+		nodes:   newSizedCache(config.NodeCacheSize),
+	}
+}
+
+// Stats returns the statistics of the caches of the state
+func (s *State) Stats() StateStats {
+	return StateStats{
+		Tries: s.tries.Stats(),
+		Nodes: s.nodes.Stats(),
 	}
-	return s
 }
 
 func (s *State) SetCode(hash types.Hash, code []byte) {
@@ -53,7 +95,7 @@ func (s *State) NewSnapshotAt(root types.Hash) (state.SnapshotWriter, error) {
 		return s.NewSnapshot(), nil
 	}
 
-	tt, ok := s.cache.Get(root)
+	tt, ok := s.tries.Get(root.Bytes())
 	if ok {
 		return &Snapshot{state: s, trieRoot: tt.(*Trie)}, nil
 	}
@@ -76,7 +118,13 @@ func (s *State) NewSnapshotAt(root types.Hash) (state.SnapshotWriter, error) {
 	}, nil
 }
 
+// GetNode returns the decoded node of a hash, the decoded nodes are
+// cached and shared by the tries since they are not modified
 func (s *State) GetNode(root []byte) (Node, bool, error) {
+	if node, ok := s.nodes.Get(root); ok {
+		return node.(Node), true, nil
+	}
+
 	data, ok := s.storage.Get(root)
 	if !ok {
 		return nil, false, nil
@@ -85,11 +133,27 @@ func (s *State) GetNode(root []byte) (Node, bool, error) {
 	if err != nil {
 		return nil, false, err
 	}
+	// the hash is set before the node is shared, so the hashers
+	// of the tries do not set it concurrently
+	node.SetHash(root)
+	s.nodes.Add(root, node, len(data))
 	return node, true, nil
 }
 
-func (s *State) AddState(root types.Hash, t *Trie) {
-	s.cache.Add(root, t)
+// addTrie adds a committed trie to the cache of tries
+func (s *State) addTrie(root types.Hash, t *Trie, size int) {
+	s.tries.Add(root.Bytes(), t, size)
+}
+
+// sizedBatch is a batch that counts the size of the entries written
+type sizedBatch struct {
+	Batch
+	size int
+}
+
+func (b *sizedBatch) Put(k, v []byte) {
+	b.size += len(k) + len(v)
+	b.Batch.Put(k, v)
 }
 
 // this is a wrapper to represent the new snapshot entity
@@ -163,7 +227,7 @@ func (s *Snapshot) GetAccount(addr types.Address) (*state.Account, error) {
 func (s *Snapshot) Commit(objs []*state.Object) (state.SnapshotWriter, []byte) {
 
 	// Create an insertion batch for all the entries
-	batch := s.state.storage.Batch()
+	batch := &sizedBatch{Batch: s.state.storage.Batch()}
 
 	tt := s.trieRoot.Txn()
 	tt.batch = batch
@@ -194,6 +258,7 @@ func (s *Snapshot) Commit(objs []*state.Object) (state.SnapshotWriter, []byte) {
 
 				localTxn := localSnapshot.(*Snapshot).trieRoot.Txn()
 				localTxn.batch = batch
+				size := batch.size
 
 				for _, entry := range obj.Storage {
 					k := hashit(entry.Key)
@@ -209,7 +274,7 @@ func (s *Snapshot) Commit(objs []*state.Object) (state.SnapshotWriter, []byte) {
 				accountStateTrie := localTxn.Commit()
 
 				// Add this to the cache
-				s.state.AddState(types.BytesToHash(accountStateRoot), accountStateTrie)
+				s.state.addTrie(types.BytesToHash(accountStateRoot), accountStateTrie, batch.size-size)
 
 				account.Root = types.BytesToHash(accountStateRoot)
 			}
@@ -226,6 +291,7 @@ func (s *Snapshot) Commit(objs []*state.Object) (state.SnapshotWriter, []byte) {
 		}
 	}
 
+	size := batch.size
 	root, _ := tt.Hash()
 
 	nTrie := tt.Commit()
@@ -234,7 +300,7 @@ func (s *Snapshot) Commit(objs []*state.Object) (state.SnapshotWriter, []byte) {
 	// Write all the entries to db
 	batch.Write()
 
-	s.state.AddState(types.BytesToHash(root), nTrie)
+	s.state.addTrie(types.BytesToHash(root), nTrie, batch.size-size)
 	return &Snapshot{state: s.state, trieRoot: nTrie}, root
 }
 
@@ -1,9 +1,14 @@
 package itrie
 
 import (
+	"math/big"
+	"sync"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
+
 	state "github.com/0xPolygon/eth-state-transition"
+	"github.com/0xPolygon/eth-state-transition/types"
 )
 
 func TestState(t *testing.T) {
@@ -17,3 +22,123 @@ func buildPreState(pre state.PreStates) state.SnapshotWriter {
 
 	return snap
 }
+
+// buildAccounts commits n accounts with a storage slot each
+func buildAccounts(s *State, n int) types.Hash {
+	objs := []*state.Object{}
+	for i := 0; i < n; i++ {
+		objs = append(objs, &state.Object{
+			Address:  types.BytesToAddress([]byte{byte(i >> 8), byte(i)}),
+			Balance:  big.NewInt(int64(i)),
+			CodeHash: types.BytesToHash(state.EmptyCodeHash),
+			Root:     state.EmptyRootHash,
+			Storage: []*state.StorageObject{
+				{Key: types.BytesToHash([]byte{1}).Bytes(), Val: []byte{byte(i)}},
+			},
+		})
+	}
+	_, root := s.NewSnapshot().Commit(objs)
+	return types.BytesToHash(root)
+}
+
+func TestSnapshotAtStorage(t *testing.T) {
+	storage := NewMemoryStorage()
+	root := buildAccounts(NewArchiveState(storage), 300)
+
+	// the state is loaded from the storage without the cache of the tries
+	snap, err := NewArchiveState(storage).NewSnapshotAt(root)
+	assert.NoError(t, err)
+
+	for i := 1; i < 300; i++ {
+		account, err := snap.GetAccount(types.BytesToAddress([]byte{byte(i >> 8), byte(i)}))
+		assert.NoError(t, err)
+		assert.Equal(t, big.NewInt(int64(i)), account.Balance)
+		assert.Equal(t, types.BytesToHash([]byte{byte(i)}), snap.GetStorage(account.Root, types.BytesToHash([]byte{1})))
+	}
+}
+
+func TestSnapshotConcurrentReads(t *testing.T) {
+	storage := NewMemoryStorage()
+	root := buildAccounts(NewArchiveState(storage), 300)
+
+	s := NewArchiveState(storage)
+	snap, err := s.NewSnapshotAt(root)
+	assert.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 299; i > 0; i-- {
+				account, err := snap.GetAccount(types.BytesToAddress([]byte{byte(i >> 8), byte(i)}))
+				if assert.NoError(t, err) {
+					assert.Equal(t, types.BytesToHash([]byte{byte(i)}), snap.GetStorage(account.Root, types.BytesToHash([]byte{1})))
+				}
+			}
+		}()
+	}
+
+	// a writer commits on top of the same snapshot
+	snap.Commit([]*state.Object{
+		{
+			Address:  types.BytesToAddress([]byte{1}),
+			Balance:  big.NewInt(1000),
+			CodeHash: types.BytesToHash(state.EmptyCodeHash),
+			Root:     state.EmptyRootHash,
+		},
+	})
+	wg.Wait()
+}
+
+func TestSnapshotConcurrentProofs(t *testing.T) {
+	storage := NewMemoryStorage()
+	root := buildAccounts(NewArchiveState(storage), 300)
+
+	// the commits hash the storage of the accounts without changes
+	objs := []*state.Object{}
+	for i := 1; i < 300; i++ {
+		objs = append(objs, &state.Object{
+			Address:  types.BytesToAddress([]byte{byte(i >> 8), byte(i)}),
+			Balance:  big.NewInt(int64(i)),
+			CodeHash: types.BytesToHash(state.EmptyCodeHash),
+			Storage: []*state.StorageObject{
+				{Key: types.BytesToHash([]byte{2}).Bytes(), Deleted: true},
+			},
+		})
+	}
+
+	keys := []types.Hash{types.BytesToHash([]byte{1})}
+
+	// the nodes loaded from the storage are shared by the proofs and the commits
+	for round := 0; round < 4; round++ {
+		snap, err := NewArchiveState(storage).NewSnapshotAt(root)
+		assert.NoError(t, err)
+
+		for _, obj := range objs {
+			account, err := snap.GetAccount(obj.Address)
+			assert.NoError(t, err)
+			obj.Root = account.Root
+		}
+
+		var wg sync.WaitGroup
+		for w := 0; w < 4; w++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				for _, obj := range objs {
+					proof, err := snap.(*Snapshot).GetProof(obj.Address, keys)
+					if assert.NoError(t, err) {
+						assert.NoError(t, VerifyAccountProof(root, proof))
+					}
+				}
+			}()
+			go func() {
+				defer wg.Done()
+				_, found := snap.Commit(objs)
+				assert.Equal(t, root.Bytes(), found)
+			}()
+		}
+		wg.Wait()
+	}
+}
@@ -1,6 +1,8 @@
 package itrie
 
 import (
+	"sync"
+
 	"github.com/0xPolygon/eth-state-transition/helper"
 	"github.com/0xPolygon/eth-state-transition/types"
 )
@@ -21,12 +23,13 @@ type Storage interface {
 }
 
 type memStorage struct {
+	lock sync.RWMutex
 	db   map[string][]byte
 	code map[string][]byte
 }
 
 type memBatch struct {
-	db *map[string][]byte
+	storage *memStorage
 }
 
 // NewMemoryStorage creates an inmemory trie storage
@@ -37,10 +40,16 @@ func NewMemoryStorage() Storage {
 func (m *memStorage) Put(p []byte, v []byte) {
 	buf := make([]byte, len(v))
 	copy(buf[:], v[:])
+
+	m.lock.Lock()
 	m.db[helper.EncodeToHex(p)] = buf
+	m.lock.Unlock()
 }
 
 func (m *memStorage) Get(p []byte) ([]byte, bool) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
 	v, ok := m.db[helper.EncodeToHex(p)]
 	if !ok {
 		return []byte{}, false
@@ -49,16 +58,21 @@ func (m *memStorage) Get(p []byte) ([]byte, bool) {
 }
 
 func (m *memStorage) SetCode(hash types.Hash, code []byte) {
+	m.lock.Lock()
 	m.code[hash.String()] = code
+	m.lock.Unlock()
 }
 
 func (m *memStorage) GetCode(hash types.Hash) ([]byte, bool) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
 	code, ok := m.code[hash.String()]
 	return code, ok
 }
 
 func (m *memStorage) Batch() Batch {
-	return &memBatch{db: &m.db}
+	return &memBatch{storage: m}
 }
 
 func (m *memStorage) Close() error {
@@ -66,9 +80,7 @@ func (m *memStorage) Close() error {
 }
 
 func (m *memBatch) Put(p, v []byte) {
-	buf := make([]byte, len(v))
-	copy(buf[:], v[:])
-	(*m.db)[helper.EncodeToHex(p)] = buf
+	m.storage.Put(p, v)
 }
 
 func (m *memBatch) Write() {
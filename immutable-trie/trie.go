@@ -36,8 +36,7 @@ func (t *Trie) Hash() types.Hash {
 		return state.EmptyRootHash
 	}
 
-	hash, cached, _ := t.hashRoot()
-	t.root = cached
+	hash, _, _ := t.hashRoot()
 	return types.BytesToHash(hash)
 }
 
@@ -66,14 +65,15 @@ func (t *Txn) Commit() *Trie {
 }
 
 func (t *Txn) Lookup(key []byte) []byte {
-	_, res := t.lookup(t.root, keybytesToHex(key))
-	return res
+	return t.lookup(t.root, keybytesToHex(key))
 }
 
-func (t *Txn) lookup(node interface{}, key []byte) (Node, []byte) {
+// lookup does not modify the nodes, so the tries that share nodes
+// can be read concurrently
+func (t *Txn) lookup(node interface{}, key []byte) []byte {
 	switch n := node.(type) {
 	case nil:
-		return nil, nil
+		return nil
 
 	case *ValueNode:
 		if n.hash {
@@ -82,37 +82,28 @@ func (t *Txn) lookup(node interface{}, key []byte) (Node, []byte) {
 				panic(err)
 			}
 			if !ok {
-				return nil, nil
+				return nil
 			}
-			_, res := t.lookup(nc, key)
-			return nc, res
+			return t.lookup(nc, key)
 		}
 		if len(key) == 0 {
-			return nil, n.buf
+			return n.buf
 		} else {
-			return nil, nil
+			return nil
 		}
 
 	case *ShortNode:
 		plen := len(n.key)
 		if plen > len(key) || !bytes.Equal(key[:plen], n.key) {
-			return nil, nil
-		}
-		child, res := t.lookup(n.child, key[plen:])
-		if child != nil {
-			n.child = child
+			return nil
 		}
-		return nil, res
+		return t.lookup(n.child, key[plen:])
 
 	case *FullNode:
 		if len(key) == 0 {
 			return t.lookup(n.value, key)
 		}
-		child, res := t.lookup(n.getEdge(key[0]), key[1:])
-		if child != nil {
-			n.children[key[0]] = child
-		}
-		return nil, res
+		return t.lookup(n.getEdge(key[0]), key[1:])
 
 	default:
 		panic(fmt.Sprintf("unknown node type %v", n))
@@ -239,8 +230,6 @@ func (t *Txn) delete(node Node, search []byte) (Node, bool) {
 		return nil, false
 
 	case *ShortNode:
-		n.hash = n.hash[:0]
-
 		plen := prefixLen(search, n.key)
 		if plen == len(search) {
 			return nil, true
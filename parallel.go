@@ -0,0 +1,543 @@
+package state
+
+import (
+	"bytes"
+	"math/big"
+	"sync"
+	"sync/atomic"
+
+	iradix "github.com/hashicorp/go-immutable-radix"
+
+	"github.com/0xPolygon/eth-state-transition/runtime"
+	"github.com/0xPolygon/eth-state-transition/types"
+)
+
+// slotKey is a storage slot of an account
+type slotKey struct {
+	addr types.Address
+	key  types.Hash
+}
+
+// accessSet records the accounts and storage slots read and written by
+// a transaction. The fields of an account (i.e. nonce, balance and code)
+// and its storage are tracked separately, so the transactions that only
+// change the storage of a contract do not conflict with the ones that
+// only read its balance.
+type accessSet struct {
+	// accounts are the accounts whose fields were read
+	accounts map[types.Address]struct{}
+
+	// storages are the accounts whose storage was read or written
+	storages map[types.Address]struct{}
+
+	// slots are the storage slots read
+	slots map[slotKey]struct{}
+
+	// touched are the accounts whose object was written
+	touched map[types.Address]struct{}
+
+	// written are the storage slots written
+	written map[slotKey]struct{}
+
+	// resets are the accounts whose storage was replaced
+	resets map[types.Address]struct{}
+}
+
+func newAccessSet() *accessSet {
+	return &accessSet{
+		accounts: map[types.Address]struct{}{},
+		storages: map[types.Address]struct{}{},
+		slots:    map[slotKey]struct{}{},
+		touched:  map[types.Address]struct{}{},
+		written:  map[slotKey]struct{}{},
+		resets:   map[types.Address]struct{}{},
+	}
+}
+
+func (a *accessSet) readAccount(addr types.Address) {
+	if a != nil {
+		a.accounts[addr] = struct{}{}
+	}
+}
+
+func (a *accessSet) readSlot(addr types.Address, key types.Hash) {
+	if a != nil {
+		a.storages[addr] = struct{}{}
+		a.slots[slotKey{addr, key}] = struct{}{}
+	}
+}
+
+func (a *accessSet) touchAccount(addr types.Address) {
+	if a != nil {
+		a.touched[addr] = struct{}{}
+	}
+}
+
+func (a *accessSet) writeSlot(addr types.Address, key types.Hash) {
+	if a != nil {
+		a.storages[addr] = struct{}{}
+		a.written[slotKey{addr, key}] = struct{}{}
+	}
+}
+
+func (a *accessSet) resetStorage(addr types.Address) {
+	if a != nil {
+		a.resets[addr] = struct{}{}
+	}
+}
+
+// accountWrite is the change of an account made by a transaction
+type accountWrite struct {
+	// object is the object of the account after the transaction
+	object *stateObject
+
+	// fields is true if the nonce, balance, code or existence changed
+	fields bool
+
+	// reset is true if the account was created or deleted, or its storage
+	// was replaced. The storage of the object replaces the previous one.
+	reset bool
+
+	// slots are the storage slots changed if the storage was not reset
+	slots map[types.Hash]types.Hash
+}
+
+// lookupObject returns the object of an account in a tree of changes
+// on top of the snapshot, the object must not be modified
+func lookupObject(snap Snapshot, tree *iradix.Tree, addr types.Address) (*stateObject, bool) {
+	if val, ok := tree.Get(addr.Bytes()); ok {
+		obj := val.(*stateObject)
+		return obj, !obj.Deleted
+	}
+	account, err := snap.GetAccount(addr)
+	if err != nil || account == nil {
+		return nil, false
+	}
+	return &stateObject{Account: account}, true
+}
+
+// storageValue returns the value of a storage slot of an object
+func storageValue(snap Snapshot, obj *stateObject, key types.Hash) types.Hash {
+	if obj.Txn != nil {
+		if val, ok := obj.Txn.Get(key.Bytes()); ok {
+			if val == nil {
+				return types.Hash{}
+			}
+			return types.BytesToHash(val.([]byte))
+		}
+	}
+	return snap.GetStorage(obj.Account.Root, key)
+}
+
+func sameFields(a, b *stateObject) bool {
+	return a.Account.Nonce == b.Account.Nonce &&
+		a.Account.Balance.Cmp(b.Account.Balance) == 0 &&
+		bytes.Equal(a.Account.CodeHash, b.Account.CodeHash) &&
+		a.Account.Root == b.Account.Root &&
+		a.Suicide == b.Suicide &&
+		a.DirtyCode == b.DirtyCode
+}
+
+// diffWrites returns the accounts changed between two trees, only the
+// accounts and slots of the access set are compared
+func diffWrites(snap Snapshot, base, final *iradix.Tree, access *accessSet) map[types.Address]*accountWrite {
+	writes := map[types.Address]*accountWrite{}
+
+	for addr := range access.touched {
+		prev, prevOk := lookupObject(snap, base, addr)
+		obj, ok := lookupObject(snap, final, addr)
+
+		w := &accountWrite{object: obj}
+		if prevOk != ok {
+			w.fields, w.reset = true, true
+		} else if ok {
+			_, reset := access.resets[addr]
+			w.fields = reset || !sameFields(prev, obj)
+			w.reset = reset
+		}
+		if w.fields {
+			writes[addr] = w
+		}
+	}
+
+	for slot := range access.written {
+		w := writes[slot.addr]
+		if w != nil && w.reset {
+			// the whole storage is replaced
+			continue
+		}
+		prev, prevOk := lookupObject(snap, base, slot.addr)
+		obj, ok := lookupObject(snap, final, slot.addr)
+		if !prevOk || !ok {
+			continue
+		}
+
+		value := storageValue(snap, obj, slot.key)
+		if storageValue(snap, prev, slot.key) == value {
+			continue
+		}
+		if w == nil {
+			w = &accountWrite{object: obj}
+			writes[slot.addr] = w
+		}
+		if w.slots == nil {
+			w.slots = map[types.Hash]types.Hash{}
+		}
+		w.slots[slot.key] = value
+	}
+	return writes
+}
+
+// applyWrite applies the change of an account on top of the current state
+func (txn *Txn) applyWrite(addr types.Address, w *accountWrite) {
+	if w.reset {
+		txn.access.touchAccount(addr)
+		txn.access.resetStorage(addr)
+		txn.txn.Insert(addr.Bytes(), w.object)
+		return
+	}
+
+	object, _ := txn.lookupStateObject(addr)
+	if w.fields {
+		object.Account.Nonce = w.object.Account.Nonce
+		object.Account.Balance = new(big.Int).Set(w.object.Account.Balance)
+		object.Account.CodeHash = w.object.Account.CodeHash
+		object.Suicide = w.object.Suicide
+		object.DirtyCode = w.object.DirtyCode
+		object.Code = w.object.Code
+	}
+	if len(w.slots) != 0 && object.Txn == nil {
+		object.Txn = iradix.New().Txn()
+	}
+	for key, value := range w.slots {
+		txn.access.writeSlot(addr, key)
+		if value == zeroHash {
+			object.Txn.Insert(key.Bytes(), nil)
+		} else {
+			object.Txn.Insert(key.Bytes(), value.Bytes())
+		}
+	}
+	txn.insertObject(addr.Bytes(), object)
+}
+
+// syncSnapshot serializes the reads of a snapshot, since the snapshots
+// are not required to be safe for concurrent use
+type syncSnapshot struct {
+	lock sync.Mutex
+	snap Snapshot
+}
+
+func (s *syncSnapshot) GetCode(hash types.Hash) ([]byte, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.snap.GetCode(hash)
+}
+
+func (s *syncSnapshot) GetStorage(root types.Hash, key types.Hash) types.Hash {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.snap.GetStorage(root, key)
+}
+
+func (s *syncSnapshot) GetAccount(addr types.Address) (*Account, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.snap.GetAccount(addr)
+}
+
+// writeKind is the kind of data of an account that is written
+type writeKind int
+
+const (
+	fieldsWrite writeKind = iota
+	storageWrite
+	slotWrite
+)
+
+// writeKey is the data written by a transaction
+type writeKey struct {
+	kind writeKind
+	addr types.Address
+	key  types.Hash
+}
+
+// ParallelStats are the counters of the transactions written in parallel
+type ParallelStats struct {
+	// Committed is the number of speculative executions committed
+	Committed uint64
+
+	// Reexecuted is the number of transactions executed again on top of
+	// the committed state because their speculative execution was not valid
+	Reexecuted uint64
+}
+
+func (s *ParallelStats) add(o ParallelStats) {
+	atomic.AddUint64(&s.Committed, o.Committed)
+	atomic.AddUint64(&s.Reexecuted, o.Reexecuted)
+}
+
+func (s *ParallelStats) load() ParallelStats {
+	return ParallelStats{
+		Committed:  atomic.LoadUint64(&s.Committed),
+		Reexecuted: atomic.LoadUint64(&s.Reexecuted),
+	}
+}
+
+// speculation is the result of the speculative execution of a transaction
+type speculation struct {
+	done chan struct{}
+
+	// version is the number of transactions committed in the
+	// state the transaction was executed on
+	version int
+
+	receipt *Result
+	err     error
+	access  *accessSet
+	writes  map[types.Address]*accountWrite
+}
+
+// parallelExecutor executes the transactions speculatively on many
+// goroutines and commits them in order
+type parallelExecutor struct {
+	t    *Transition
+	txs  []*Transaction
+	snap Snapshot
+
+	// ctx is the block context of the speculative transitions
+	ctx runtime.TxContext
+
+	results []*speculation
+
+	// lastWrite is the index of the last committed transaction
+	// that wrote each data
+	lastWrite map[writeKey]int
+
+	lock sync.Mutex
+	cond *sync.Cond
+
+	// tree is the state after the committed transactions
+	tree      *iradix.Tree
+	committed int
+	next      int
+	window    int
+	aborted   bool
+}
+
+// WriteParallel writes the transactions speculatively on many goroutines
+// (Block-STM). Each transaction is executed on the state after the last
+// committed one, and it is committed in order if the accounts and slots it
+// read were not written since then, otherwise it is executed again on top
+// of the committed state. The receipts and the state are the same as with
+// Write.
+//
+// The transactions are written one at a time if there are less than two
+// workers, a tracer is set or the receipts have intermediate state roots
+// (i.e. before Byzantium). Only the EVM and the precompiled contracts are
+// available to the speculative transactions. If a transaction fails, the
+// receipts of the previous ones are returned with the error.
+func (t *Transition) WriteParallel(txs []*Transaction, workers int) ([]*Result, error) {
+	receipts := make([]*Result, 0, len(txs))
+
+	if workers < 2 || t.tracer != nil || !t.forks.Byzantium {
+		for _, tx := range txs {
+			receipt, err := t.Write(tx)
+			if err != nil {
+				return receipts, err
+			}
+			receipts = append(receipts, receipt)
+		}
+		return receipts, nil
+	}
+
+	snap := &syncSnapshot{snap: t.txn.snapshot}
+	t.txn.snapshot = snap
+	defer func() {
+		t.txn.snapshot = snap.snap
+	}()
+
+	p := &parallelExecutor{
+		t:         t,
+		txs:       txs,
+		snap:      snap,
+		ctx:       t.ctx,
+		results:   make([]*speculation, len(txs)),
+		lastWrite: map[writeKey]int{},
+		tree:      t.txn.txn.CommitOnly(),
+		window:    2 * workers,
+	}
+	p.cond = sync.NewCond(&p.lock)
+	for i := range p.results {
+		p.results[i] = &speculation{done: make(chan struct{})}
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.work()
+		}()
+	}
+	defer func() {
+		p.lock.Lock()
+		p.aborted = true
+		p.lock.Unlock()
+		p.cond.Broadcast()
+		wg.Wait()
+	}()
+
+	for i, tx := range txs {
+		res := p.results[i]
+		<-res.done
+
+		receipt, err := p.commit(i, tx, res)
+		if err != nil {
+			return receipts, err
+		}
+		receipts = append(receipts, receipt)
+	}
+	return receipts, nil
+}
+
+// work executes speculatively the next transactions
+func (p *parallelExecutor) work() {
+	t := p.t
+
+	spec := NewTransition(t.forks, p.ctx, p.snap)
+	spec.SetCodeCache(t.codeCache)
+	spec.SetExecutionMode(t.evmRuntime.ExecutionMode())
+	spec.getHash = t.getHash
+	spec.deferFee = true
+
+	for {
+		p.lock.Lock()
+		for !p.aborted && p.next < len(p.txs) && p.next >= p.committed+p.window {
+			p.cond.Wait()
+		}
+		if p.aborted || p.next >= len(p.txs) {
+			p.lock.Unlock()
+			return
+		}
+		i := p.next
+		p.next++
+		version, tree := p.committed, p.tree
+		p.lock.Unlock()
+
+		txn := newTxnAt(p.snap, tree)
+		txn.access = newAccessSet()
+
+		spec.txn = txn
+		spec.gasPool = uint64(spec.ctx.GasLimit)
+		spec.totalGas = 0
+
+		res := p.results[i]
+		res.version = version
+		res.access = txn.access
+		res.receipt, res.err = spec.Write(p.txs[i])
+		if res.err == nil {
+			res.writes = diffWrites(p.snap, tree, txn.txn.CommitOnly(), txn.access)
+		}
+		close(res.done)
+	}
+}
+
+// valid returns true if the speculative execution of a transaction
+// can be committed on top of the committed state
+func (p *parallelExecutor) valid(tx *Transaction, res *speculation) bool {
+	if res.err != nil || p.t.gasPool < tx.Gas {
+		return false
+	}
+	if w, ok := res.writes[p.t.ctx.Coinbase]; ok && w.reset {
+		// the fee is paid to the coinbase before it is deleted or created
+		return false
+	}
+
+	stale := func(key writeKey) bool {
+		last, ok := p.lastWrite[key]
+		return ok && last >= res.version
+	}
+	for addr := range res.access.accounts {
+		if stale(writeKey{kind: fieldsWrite, addr: addr}) {
+			return false
+		}
+	}
+	for addr := range res.access.storages {
+		if stale(writeKey{kind: storageWrite, addr: addr}) {
+			return false
+		}
+	}
+	for slot := range res.access.slots {
+		if stale(writeKey{kind: slotWrite, addr: slot.addr, key: slot.key}) {
+			return false
+		}
+	}
+	for addr, w := range res.writes {
+		if w.fields && stale(writeKey{kind: fieldsWrite, addr: addr}) {
+			return false
+		}
+		if w.reset && stale(writeKey{kind: storageWrite, addr: addr}) {
+			return false
+		}
+	}
+	return true
+}
+
+// commit commits the transaction at index i on top of the committed state,
+// it is executed again if its speculative execution is not valid
+func (p *parallelExecutor) commit(i int, tx *Transaction, res *speculation) (*Result, error) {
+	t := p.t
+
+	base := t.txn.txn.CommitOnly()
+	t.txn.access = newAccessSet()
+	defer func() {
+		t.txn.access = nil
+	}()
+
+	var receipt *Result
+	if p.valid(tx, res) {
+		receipt = res.receipt
+		for addr, w := range res.writes {
+			t.txn.applyWrite(addr, w)
+		}
+
+		// pay the coinbase for the transaction
+		coinbaseFee := new(big.Int).Mul(new(big.Int).SetUint64(receipt.GasUsed), tx.GasPrice)
+		t.txn.AddBalance(t.ctx.Coinbase, coinbaseFee)
+		t.txn.CleanDeleteObjects(true)
+
+		t.gasPool -= receipt.GasUsed
+		t.totalGas += receipt.GasUsed
+		receipt.CumulativeGasUsed = t.totalGas
+
+		t.parallelStats.Committed++
+	} else {
+		var err error
+		if receipt, err = t.Write(tx); err != nil {
+			return nil, err
+		}
+
+		t.parallelStats.Reexecuted++
+	}
+
+	tree := t.txn.txn.CommitOnly()
+	for addr, w := range diffWrites(p.snap, base, tree, t.txn.access) {
+		if w.fields {
+			p.lastWrite[writeKey{kind: fieldsWrite, addr: addr}] = i
+		}
+		if w.reset {
+			p.lastWrite[writeKey{kind: storageWrite, addr: addr}] = i
+		}
+		for key := range w.slots {
+			p.lastWrite[writeKey{kind: slotWrite, addr: addr, key: key}] = i
+		}
+	}
+
+	p.lock.Lock()
+	p.tree = tree
+	p.committed = i + 1
+	p.lock.Unlock()
+	p.cond.Broadcast()
+
+	return receipt, nil
+}
@@ -0,0 +1,160 @@
+package state
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/0xPolygon/eth-state-transition/runtime"
+	"github.com/0xPolygon/eth-state-transition/runtime/evm"
+	"github.com/0xPolygon/eth-state-transition/types"
+)
+
+// Prefetcher loads concurrently the accounts and storage slots that the
+// transactions of a block are likely to access, so the nodes of their
+// paths in the trie are cached when the block is executed. The accounts
+// are the senders, the recipients, the coinbase and the access lists, and
+// each transaction is also run as a call on a throwaway Txn to find the
+// accounts and slots of the contracts. The snapshot must be safe for
+// concurrent use (i.e. itrie.Snapshot).
+type Prefetcher struct {
+	forks runtime.ForksInTime
+	ctx   runtime.TxContext
+	snap  Snapshot
+
+	codeCache *evm.CodeCache
+
+	start sync.Once
+	stop  sync.Once
+	quit  chan struct{}
+	wg    sync.WaitGroup
+
+	transactions uint64
+	accounts     uint64
+	slots        uint64
+}
+
+// PrefetchStats are the statistics of a prefetcher
+type PrefetchStats struct {
+	// Transactions is the number of transactions run
+	Transactions uint64
+
+	// Accounts is the number of accounts loaded
+	Accounts uint64
+
+	// Slots is the number of storage slots loaded
+	Slots uint64
+}
+
+// NewPrefetcher creates a prefetcher of the state of a snapshot
+func NewPrefetcher(forks runtime.ForksInTime, ctx runtime.TxContext, snap Snapshot) *Prefetcher {
+	return &Prefetcher{
+		forks:     forks,
+		ctx:       ctx,
+		snap:      snap,
+		codeCache: evm.DefaultCodeCache,
+		quit:      make(chan struct{}),
+	}
+}
+
+// SetCodeCache sets the cache of the code of the contracts, it should be
+// the cache of the transition that executes the transactions
+func (p *Prefetcher) SetCodeCache(cache *evm.CodeCache) {
+	p.codeCache = cache
+}
+
+// Start prefetches the state of the transactions on the given number of
+// goroutines, it does not wait for them. A prefetcher can only be started once.
+func (p *Prefetcher) Start(txs []*Transaction, workers int) {
+	p.start.Do(func() {
+		if workers < 1 {
+			workers = 1
+		}
+
+		queue := make(chan *Transaction, len(txs))
+		for _, tx := range txs {
+			queue <- tx
+		}
+		close(queue)
+
+		p.wg.Add(workers + 1)
+		for i := 0; i < workers; i++ {
+			go func() {
+				defer p.wg.Done()
+				p.work(queue)
+			}()
+		}
+		go func() {
+			defer p.wg.Done()
+			p.fetchAccount(p.ctx.Coinbase)
+		}()
+	})
+}
+
+// Wait waits until the state of all the transactions is prefetched
+func (p *Prefetcher) Wait() {
+	p.wg.Wait()
+}
+
+// Stop stops prefetching the state of the next transactions and waits
+// for the ones being prefetched
+func (p *Prefetcher) Stop() {
+	p.stop.Do(func() {
+		close(p.quit)
+	})
+	p.wg.Wait()
+}
+
+// Stats returns the statistics of the prefetcher
+func (p *Prefetcher) Stats() PrefetchStats {
+	return PrefetchStats{
+		Transactions: atomic.LoadUint64(&p.transactions),
+		Accounts:     atomic.LoadUint64(&p.accounts),
+		Slots:        atomic.LoadUint64(&p.slots),
+	}
+}
+
+func (p *Prefetcher) work(queue <-chan *Transaction) {
+	// the runtimes are not safe for concurrent use
+	transition := NewTransition(p.forks, p.ctx, p.snap)
+	transition.SetCodeCache(p.codeCache)
+
+	for tx := range queue {
+		select {
+		case <-p.quit:
+			return
+		default:
+		}
+
+		p.fetchAccount(tx.From)
+		if tx.To != nil {
+			p.fetchAccount(*tx.To)
+		}
+		for _, tuple := range tx.AccessList {
+			account := p.fetchAccount(tuple.Address)
+			for _, key := range tuple.StorageKeys {
+				p.fetchSlot(account, key)
+			}
+		}
+
+		// the errors are ignored, the call only loads the state it reads
+		transition.Simulate(tx, nil)
+		atomic.AddUint64(&p.transactions, 1)
+	}
+}
+
+func (p *Prefetcher) fetchAccount(addr types.Address) *Account {
+	account, err := p.snap.GetAccount(addr)
+	if err != nil {
+		return nil
+	}
+	atomic.AddUint64(&p.accounts, 1)
+	return account
+}
+
+func (p *Prefetcher) fetchSlot(account *Account, key types.Hash) {
+	if account == nil {
+		return
+	}
+	p.snap.GetStorage(account.Root, key)
+	atomic.AddUint64(&p.slots, 1)
+}
@@ -0,0 +1,88 @@
+package state
+
+import (
+	"github.com/umbracle/fastrlp"
+
+	"github.com/0xPolygon/eth-state-transition/types"
+)
+
+// MarshalWith encodes the log as an RLP value
+func (l *Log) MarshalWith(ar *fastrlp.Arena) *fastrlp.Value {
+	v := ar.NewArray()
+	v.Set(ar.NewBytes(l.Address.Bytes()))
+
+	topics := ar.NewArray()
+	for _, t := range l.Topics {
+		topics.Set(ar.NewCopyBytes(t.Bytes()))
+	}
+	v.Set(topics)
+	v.Set(ar.NewBytes(l.Data))
+	return v
+}
+
+// MarshalWith encodes the result as a receipt. Before Byzantium the receipt
+// includes the intermediate state root instead of the status.
+func (r *Result) MarshalWith(ar *fastrlp.Arena, byzantium bool) *fastrlp.Value {
+	v := ar.NewArray()
+	if byzantium {
+		if r.Success {
+			v.Set(ar.NewUint(1))
+		} else {
+			v.Set(ar.NewUint(0))
+		}
+	} else {
+		v.Set(ar.NewBytes(r.Root.Bytes()))
+	}
+	v.Set(ar.NewUint(r.CumulativeGasUsed))
+	v.Set(ar.NewCopyBytes(r.LogsBloom[:]))
+
+	if len(r.Logs) == 0 {
+		v.Set(ar.NewNullArray())
+	} else {
+		logs := ar.NewArray()
+		for _, l := range r.Logs {
+			logs.Set(l.MarshalWith(ar))
+		}
+		v.Set(logs)
+	}
+	return v
+}
+
+var receiptArenaPool fastrlp.ArenaPool
+
+// MarshalRlpTo appends the encoding of the receipt to dst. The receipts of
+// typed transactions are prefixed with the type of the transaction (EIP-2718).
+func (r *Result) MarshalRlpTo(dst []byte, byzantium bool) []byte {
+	ar := receiptArenaPool.Get()
+	defer receiptArenaPool.Put(ar)
+
+	if r.Type != types.LegacyTxType {
+		dst = append(dst, r.Type)
+	}
+	return r.MarshalWith(ar, byzantium).MarshalTo(dst)
+}
+
+// ReceiptsRoot returns the root of the trie of receipts
+func ReceiptsRoot(receipts []*Result, byzantium bool) types.Hash {
+	return types.DeriveRoot(len(receipts), func(i int) []byte {
+		return receipts[i].MarshalRlpTo(nil, byzantium)
+	})
+}
+
+// CreateBloom returns the bloom of the logs of all the receipts
+func CreateBloom(receipts []*Result) (bloom types.Bloom) {
+	for _, r := range receipts {
+		bloom.Or(&r.LogsBloom)
+	}
+	return
+}
+
+func logsBloom(logs []*Log) (bloom types.Bloom) {
+	for _, l := range logs {
+		bloom.Add(l.Address.Bytes())
+		for _, t := range l.Topics {
+			bloom.Add(t.Bytes())
+		}
+	}
+	return
+}
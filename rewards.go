@@ -0,0 +1,61 @@
+package state
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/0xPolygon/eth-state-transition/runtime"
+	"github.com/0xPolygon/eth-state-transition/types"
+)
+
+var (
+	big8  = big.NewInt(8)
+	big32 = big.NewInt(32)
+
+	// gwei is the unit of the withdrawal amounts
+	gwei = big.NewInt(1e9)
+)
+
+// isProofOfStake returns whether the block was produced after the merge,
+// those blocks have a zero difficulty (EIP-3675)
+func isProofOfStake(header *types.Header) bool {
+	return header.Difficulty == nil || header.Difficulty.Sign() == 0
+}
+
+// applyRewards pays the block reward to the sealer of the block and to the
+// sealers of the included uncles. The sealer gets an additional 1/32 of the
+// block reward for each uncle included. The uncles must be one of the 6
+// blocks before the block.
+func applyRewards(txn *Txn, schedule *runtime.RewardSchedule, forks runtime.ForksInTime, header *types.Header, uncles []*types.Header) error {
+	for i, uncle := range uncles {
+		if uncle.Number >= header.Number || uncle.Number+6 < header.Number {
+			return fmt.Errorf("%w: uncle %d has number %d", ErrInvalidUncle, i, uncle.Number)
+		}
+	}
+
+	blockReward := schedule.BlockReward(forks)
+	if blockReward.Sign() == 0 {
+		return nil
+	}
+
+	reward := new(big.Int).Set(blockReward)
+	for _, uncle := range uncles {
+		// the uncle gets (8 - (number - uncle number)) / 8 of the block reward
+		uncleReward := new(big.Int).SetUint64(uncle.Number + 8 - header.Number)
+		uncleReward.Mul(uncleReward, blockReward)
+		uncleReward.Div(uncleReward, big8)
+		txn.AddSealingReward(uncle.Miner, uncleReward)
+
+		reward.Add(reward, new(big.Int).Div(blockReward, big32))
+	}
+	txn.AddSealingReward(header.Miner, reward)
+	return nil
+}
+
+// applyWithdrawals credits the beacon chain withdrawals (EIP-4895)
+func applyWithdrawals(txn *Txn, withdrawals []*types.Withdrawal) {
+	for _, w := range withdrawals {
+		amount := new(big.Int).SetUint64(w.Amount)
+		txn.AddBalance(w.Address, amount.Mul(amount, gwei))
+	}
+}
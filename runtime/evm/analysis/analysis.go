@@ -0,0 +1,374 @@
+package analysis
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/0xPolygon/eth-state-transition/helper"
+	"github.com/0xPolygon/eth-state-transition/runtime/evm"
+	"github.com/0xPolygon/eth-state-transition/runtime/evm/asm"
+)
+
+// EdgeKind is the kind of a transition between blocks
+type EdgeKind string
+
+const (
+	// EdgeFallthrough is the execution of the next block
+	EdgeFallthrough EdgeKind = "fallthrough"
+	// EdgeJump is a jump with a constant destination
+	EdgeJump EdgeKind = "jump"
+)
+
+// Edge is a transition to a block
+type Edge struct {
+	To   uint64   `json:"to"`
+	Kind EdgeKind `json:"kind"`
+}
+
+// Block is a sequence of instructions that is only entered at the first one
+// and only leaves at the last one
+type Block struct {
+	// Start is the position of the first instruction and End
+	// the position after the last one
+	Start uint64
+	End   uint64
+
+	Instructions []*asm.Instruction
+	Successors   []Edge
+
+	// DynamicJump is set if the block ends with a jump
+	// whose destination is not a constant
+	DynamicJump bool
+
+	// Reachable is set if the block can be executed
+	Reachable bool
+}
+
+type instructionJSON struct {
+	PC          uint64 `json:"pc"`
+	Instruction string `json:"instruction"`
+}
+
+type blockJSON struct {
+	Start        uint64            `json:"start"`
+	End          uint64            `json:"end"`
+	Instructions []instructionJSON `json:"instructions"`
+	Successors   []Edge            `json:"successors"`
+	DynamicJump  bool              `json:"dynamicJump,omitempty"`
+	Reachable    bool              `json:"reachable"`
+}
+
+// MarshalJSON implements the json.Marshaler interface
+func (b *Block) MarshalJSON() ([]byte, error) {
+	res := &blockJSON{
+		Start:        b.Start,
+		End:          b.End,
+		Instructions: []instructionJSON{},
+		Successors:   b.Successors,
+		DynamicJump:  b.DynamicJump,
+		Reachable:    b.Reachable,
+	}
+	if res.Successors == nil {
+		res.Successors = []Edge{}
+	}
+	for _, ins := range b.Instructions {
+		res.Instructions = append(res.Instructions, instructionJSON{PC: ins.PC, Instruction: ins.String()})
+	}
+	return json.Marshal(res)
+}
+
+func (b *Block) last() *asm.Instruction {
+	return b.Instructions[len(b.Instructions)-1]
+}
+
+// DataSection is a range of the code that is not executed
+// (i.e. the metadata after the code of a contract)
+type DataSection struct {
+	Start uint64 `json:"start"`
+	End   uint64 `json:"end"`
+}
+
+// Function is a public function found in the dispatcher of a Solidity contract
+type Function struct {
+	Selector string `json:"selector"`
+
+	// Entry is the position of the code of the function
+	Entry uint64 `json:"entry"`
+}
+
+// FindingKind is the kind of an issue found in a code
+type FindingKind string
+
+const (
+	// FindingDangerousOpcode is the use of an opcode that can
+	// change or destroy the contract (i.e. SELFDESTRUCT, DELEGATECALL)
+	FindingDangerousOpcode FindingKind = "dangerous-opcode"
+	// FindingUnreachableCode is a block that is never executed
+	FindingUnreachableCode FindingKind = "unreachable-code"
+	// FindingInvalidJump is a jump to a constant destination that is not a JUMPDEST
+	FindingInvalidJump FindingKind = "invalid-jump"
+)
+
+// Finding is an issue found in a code
+type Finding struct {
+	Kind    FindingKind `json:"kind"`
+	PC      uint64      `json:"pc"`
+	Message string      `json:"message"`
+}
+
+// Analysis is the result of the static analysis of a code
+type Analysis struct {
+	Blocks       []*Block       `json:"blocks"`
+	DataSections []*DataSection `json:"dataSections"`
+	Functions    []*Function    `json:"functions"`
+	Findings     []*Finding     `json:"findings"`
+
+	blocks map[uint64]*Block
+}
+
+// Block returns the block that starts at a position
+func (a *Analysis) Block(start uint64) (*Block, bool) {
+	b, ok := a.blocks[start]
+	return b, ok
+}
+
+var dangerousOpcodes = map[evm.OpCode]string{
+	evm.SELFDESTRUCT: "can destroy the contract",
+	evm.DELEGATECALL: "runs external code with the storage of the contract",
+	evm.CALLCODE:     "runs external code with the storage of the contract",
+}
+
+// Analyze splits a code into basic blocks and builds its control flow graph.
+// The jumps whose destination is not a constant pushed in the same block
+// are dynamic, and they are assumed to go to any JUMPDEST whose position is
+// pushed by the reachable code (i.e. the return address of an internal call).
+// The metadata that Solidity appends to the code is a data section.
+func Analyze(code []byte) *Analysis {
+	a := &Analysis{
+		Blocks:       []*Block{},
+		DataSections: []*DataSection{},
+		Functions:    []*Function{},
+		Findings:     []*Finding{},
+		blocks:       map[uint64]*Block{},
+	}
+	jumpDests := evm.NewJumpDests(code)
+
+	// the metadata is not decoded since its bytes could start blocks
+	instructions := code
+	start, ok := metadataStart(code)
+	if ok {
+		instructions = code[:start]
+	}
+
+	// split the code in blocks
+	var block *Block
+	for _, ins := range asm.Disassemble(instructions) {
+		if ins.IsData() {
+			block = nil
+			a.DataSections = append(a.DataSections, &DataSection{
+				Start: ins.PC,
+				End:   ins.PC + uint64(ins.Size()),
+			})
+			continue
+		}
+		if block == nil || ins.Op == evm.JUMPDEST {
+			block = &Block{Start: ins.PC}
+			a.Blocks = append(a.Blocks, block)
+			a.blocks[block.Start] = block
+		}
+		block.Instructions = append(block.Instructions, ins)
+		block.End = ins.PC + uint64(ins.Size())
+
+		if ins.Op == evm.JUMP || ins.Op == evm.JUMPI || halts(ins.Op) {
+			block = nil
+		}
+	}
+
+	if ok {
+		if last := len(a.DataSections) - 1; last >= 0 && a.DataSections[last].End == uint64(start) {
+			a.DataSections[last].End = uint64(len(code))
+		} else {
+			a.DataSections = append(a.DataSections, &DataSection{Start: uint64(start), End: uint64(len(code))})
+		}
+	}
+
+	// resolve the edges
+	for _, b := range a.Blocks {
+		last := b.last()
+
+		if last.Op == evm.JUMP || last.Op == evm.JUMPI {
+			if dst, ok := b.jumpDestination(); !ok {
+				b.DynamicJump = true
+			} else if jumpDests.IsJumpDest(dst) {
+				b.Successors = append(b.Successors, Edge{To: dst, Kind: EdgeJump})
+			} else {
+				a.addFinding(FindingInvalidJump, last.PC, "jump to %d which is not a JUMPDEST", dst)
+			}
+		}
+		if last.Op != evm.JUMP && !halts(last.Op) {
+			if _, ok := a.blocks[b.End]; ok {
+				b.Successors = append(b.Successors, Edge{To: b.End, Kind: EdgeFallthrough})
+			}
+		}
+	}
+
+	a.markReachable(jumpDests)
+
+	for _, b := range a.Blocks {
+		if !b.Reachable {
+			a.addFinding(FindingUnreachableCode, b.Start, "block %d-%d is unreachable", b.Start, b.End)
+		}
+		for i, ins := range b.Instructions {
+			// the unreachable blocks are already reported
+			if reason, ok := dangerousOpcodes[ins.Op]; ok && b.Reachable {
+				a.addFinding(FindingDangerousOpcode, ins.PC, "%s %s", ins.Op.String(), reason)
+			}
+			if fn, ok := matchSelector(b.Instructions[i:]); ok {
+				a.addFunction(fn)
+			}
+		}
+	}
+
+	sort.SliceStable(a.Findings, func(i, j int) bool {
+		return a.Findings[i].PC < a.Findings[j].PC
+	})
+	return a
+}
+
+// metadataStart returns the position of the CBOR encoded metadata that Solidity
+// appends to the code, whose length is in the last two bytes. The metadata is a
+// map whose first key is a text string (i.e. "ipfs", "bzzr0" or "solc").
+func metadataStart(code []byte) (int, bool) {
+	if len(code) < 2 {
+		return 0, false
+	}
+	size := int(binary.BigEndian.Uint16(code[len(code)-2:]))
+	start := len(code) - 2 - size
+	if size < 2 || start < 0 {
+		return 0, false
+	}
+	if code[start]&0xe0 != 0xa0 || code[start+1]&0xe0 != 0x60 {
+		return 0, false
+	}
+	return start, true
+}
+
+// jumpDestination returns the destination of the jump at the end of
+// the block if it is a constant pushed right before it
+func (b *Block) jumpDestination() (uint64, bool) {
+	if len(b.Instructions) < 2 {
+		return 0, false
+	}
+	push := b.Instructions[len(b.Instructions)-2]
+	if len(push.Immediate) == 0 {
+		return 0, false
+	}
+	dst := new(big.Int).SetBytes(push.Immediate)
+	if !dst.IsUint64() {
+		return ^uint64(0), true
+	}
+	return dst.Uint64(), true
+}
+
+// markReachable walks the graph from the first block. The dynamic jumps
+// go to the JUMPDEST positions pushed by the blocks reached.
+func (a *Analysis) markReachable(jumpDests *evm.JumpDests) {
+	if len(a.Blocks) == 0 || a.Blocks[0].Start != 0 {
+		return
+	}
+
+	queue := []*Block{}
+	visit := func(b *Block) {
+		if !b.Reachable {
+			b.Reachable = true
+			queue = append(queue, b)
+		}
+	}
+
+	dynamic := false
+	pushed := []*Block{}
+
+	visit(a.Blocks[0])
+	for len(queue) != 0 {
+		b := queue[0]
+		queue = queue[1:]
+
+		for _, e := range b.Successors {
+			visit(a.blocks[e.To])
+		}
+		for _, ins := range b.Instructions {
+			if len(ins.Immediate) == 0 || len(ins.Immediate) > 8 {
+				continue
+			}
+			dst := new(big.Int).SetBytes(ins.Immediate).Uint64()
+			if target, ok := a.blocks[dst]; ok && jumpDests.IsJumpDest(dst) {
+				pushed = append(pushed, target)
+				if dynamic {
+					visit(target)
+				}
+			}
+		}
+		if b.DynamicJump && !dynamic {
+			dynamic = true
+			for _, target := range pushed {
+				visit(target)
+			}
+		}
+	}
+}
+
+// matchSelector matches the comparison of the selector of the dispatcher
+// of Solidity at the start of the instructions, either
+// DUP1 PUSH4 selector EQ PUSH tag JUMPI or PUSH4 selector DUP2 EQ PUSH tag JUMPI
+func matchSelector(instructions []*asm.Instruction) (*Function, bool) {
+	if len(instructions) < 4 || len(instructions[0].Immediate) != 4 {
+		return nil, false
+	}
+	selector := instructions[0]
+
+	// DUP2 copies the selector of the call data
+	rest := instructions[1:]
+	if rest[0].Op == evm.DUP1+1 {
+		rest = rest[1:]
+	}
+	if len(rest) < 3 || rest[0].Op != evm.EQ || len(rest[1].Immediate) == 0 || rest[2].Op != evm.JUMPI {
+		return nil, false
+	}
+	entry := new(big.Int).SetBytes(rest[1].Immediate)
+	if !entry.IsUint64() {
+		return nil, false
+	}
+	return &Function{
+		Selector: helper.EncodeToHex(selector.Immediate),
+		Entry:    entry.Uint64(),
+	}, true
+}
+
+func (a *Analysis) addFunction(fn *Function) {
+	for _, f := range a.Functions {
+		if f.Selector == fn.Selector {
+			return
+		}
+	}
+	a.Functions = append(a.Functions, fn)
+}
+
+func (a *Analysis) addFinding(kind FindingKind, pc uint64, format string, args ...interface{}) {
+	a.Findings = append(a.Findings, &Finding{
+		Kind:    kind,
+		PC:      pc,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// halts returns true if the next instruction is not executed after the opcode
+func halts(op evm.OpCode) bool {
+	switch op {
+	case evm.STOP, evm.JUMP, evm.RETURN, evm.REVERT, evm.INVALID, evm.SELFDESTRUCT:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,139 @@
+package analysis
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/0xPolygon/eth-state-transition/runtime/evm/asm"
+)
+
+// dispatcherCode is a contract with the dispatcher of Solidity
+var dispatcherCode = asm.MustAssemble(`
+	PUSH1 0x80 PUSH1 0x40 MSTORE
+	PUSH1 0x04 CALLDATASIZE LT PUSH @fallback JUMPI
+	PUSH1 0x00 CALLDATALOAD PUSH1 0xe0 SHR
+	DUP1 PUSH4 0x2e64cec1 EQ PUSH @retrieve JUMPI
+	PUSH4 0x6057361d DUP2 EQ PUSH @kill JUMPI
+fallback:                                       ; 41
+	JUMPDEST PUSH1 0x00 DUP1 REVERT
+retrieve:                                       ; 46
+	JUMPDEST PUSH @ret PUSH @getter JUMP        ; internal call
+ret:                                            ; 54
+	JUMPDEST STOP
+getter:                                         ; 56
+	JUMPDEST PUSH1 0x00 SLOAD POP JUMP          ; returns to the caller
+kill:                                           ; 62
+	JUMPDEST CALLER SELFDESTRUCT
+dead:                                           ; 65
+	JUMPDEST PUSH1 0x00 DUP1 DUP1 DUP1 DUP1 GAS DELEGATECALL
+	PUSH1 0x03 JUMP
+	INVALID
+	#data 0xa2646970667358
+`)
+
+func TestAnalyze(t *testing.T) {
+	a := Analyze(dispatcherCode)
+
+	starts := []uint64{}
+	for _, b := range a.Blocks {
+		starts = append(starts, b.Start)
+	}
+	assert.Equal(t, []uint64{0, 13, 30, 41, 46, 54, 56, 62, 65}, starts)
+
+	// the jumpi has the jump and the fallthrough
+	b, ok := a.Block(13)
+	assert.True(t, ok)
+	assert.Equal(t, []Edge{{To: 46, Kind: EdgeJump}, {To: 30, Kind: EdgeFallthrough}}, b.Successors)
+
+	// the return of the internal call is a dynamic jump to the return address
+	getter, _ := a.Block(56)
+	assert.True(t, getter.DynamicJump)
+	assert.Empty(t, getter.Successors)
+
+	ret, _ := a.Block(54)
+	assert.True(t, ret.Reachable)
+
+	dead, _ := a.Block(65)
+	assert.False(t, dead.Reachable)
+
+	// the code after the jump is data
+	assert.Equal(t, []*DataSection{{Start: 77, End: 85}}, a.DataSections)
+
+	assert.Equal(t, []*Function{
+		{Selector: "0x2e64cec1", Entry: 46},
+		{Selector: "0x6057361d", Entry: 62},
+	}, a.Functions)
+
+	kinds := []string{}
+	for _, f := range a.Findings {
+		kinds = append(kinds, string(f.Kind)+" "+f.Message)
+	}
+	assert.Equal(t, []string{
+		"dangerous-opcode SELFDESTRUCT can destroy the contract",
+		"unreachable-code block 65-77 is unreachable",
+		"invalid-jump jump to 3 which is not a JUMPDEST",
+	}, kinds)
+}
+
+func TestAnalyzeMetadata(t *testing.T) {
+	code := asm.MustAssemble(`
+		PUSH1 0x00 DUP1 RETURN INVALID
+		#data 0xa164697066734a5b33ff00000000000000
+		#data 0x0011
+	`)
+	a := Analyze(code)
+
+	// the JUMPDEST and the SELFDESTRUCT in the hash of the metadata are not code
+	assert.Len(t, a.Blocks, 1)
+	assert.Equal(t, uint64(4), a.Blocks[0].End)
+
+	// the INVALID before the metadata is in the same data section
+	assert.Equal(t, []*DataSection{{Start: 4, End: uint64(len(code))}}, a.DataSections)
+	assert.Empty(t, a.Findings)
+}
+
+func TestAnalyzeOutput(t *testing.T) {
+	a := Analyze(asm.MustAssemble(`
+		PUSH @end JUMP
+	end:
+		JUMPDEST STOP
+	`))
+
+	data, err := json.Marshal(a)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"blocks": [
+			{
+				"start": 0, "end": 4,
+				"instructions": [{"pc": 0, "instruction": "PUSH2 0x0004"}, {"pc": 3, "instruction": "JUMP"}],
+				"successors": [{"to": 4, "kind": "jump"}],
+				"reachable": true
+			},
+			{
+				"start": 4, "end": 6,
+				"instructions": [{"pc": 4, "instruction": "JUMPDEST"}, {"pc": 5, "instruction": "STOP"}],
+				"successors": [],
+				"reachable": true
+			}
+		],
+		"dataSections": [],
+		"functions": [],
+		"findings": []
+	}`, string(data))
+
+	var buf bytes.Buffer
+	assert.NoError(t, a.WriteDOT(&buf))
+	assert.Equal(t, strings.Join([]string{
+		"digraph cfg {",
+		"\tnode [shape=box fontname=monospace];",
+		`	b0 [label="0: PUSH2 0x0004\l3: JUMP\l"];`,
+		`	b4 [label="4: JUMPDEST\l5: STOP\l"];`,
+		"\tb0 -> b4;",
+		"}",
+		"",
+	}, "\n"), buf.String())
+}
@@ -0,0 +1,54 @@
+package analysis
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteDOT writes the control flow graph in the DOT format of Graphviz. The
+// unreachable blocks are dashed and the blocks with dangerous opcodes are red.
+func (a *Analysis) WriteDOT(w io.Writer) error {
+	dangerous := map[uint64]bool{}
+	for _, f := range a.Findings {
+		if f.Kind == FindingDangerousOpcode {
+			dangerous[f.PC] = true
+		}
+	}
+
+	buf := bufio.NewWriter(w)
+	fmt.Fprintln(buf, "digraph cfg {")
+	fmt.Fprintln(buf, "\tnode [shape=box fontname=monospace];")
+
+	for _, b := range a.Blocks {
+		var label strings.Builder
+		attrs := ""
+		for _, ins := range b.Instructions {
+			fmt.Fprintf(&label, "%d: %s\\l", ins.PC, ins.String())
+			if dangerous[ins.PC] {
+				attrs += " color=red"
+			}
+		}
+		if b.DynamicJump {
+			label.WriteString("(dynamic jump)\\l")
+		}
+		if !b.Reachable {
+			attrs += " style=dashed"
+		}
+		fmt.Fprintf(buf, "\tb%d [label=\"%s\"%s];\n", b.Start, label.String(), attrs)
+	}
+
+	for _, b := range a.Blocks {
+		for _, e := range b.Successors {
+			style := ""
+			if e.Kind == EdgeFallthrough {
+				style = " [style=dotted]"
+			}
+			fmt.Fprintf(buf, "\tb%d -> b%d%s;\n", b.Start, e.To, style)
+		}
+	}
+
+	fmt.Fprintln(buf, "}")
+	return buf.Flush()
+}
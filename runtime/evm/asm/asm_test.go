@@ -0,0 +1,111 @@
+package asm
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/0xPolygon/eth-state-transition/helper"
+	"github.com/0xPolygon/eth-state-transition/runtime/evm"
+)
+
+func TestAssemble(t *testing.T) {
+	cases := []struct {
+		src  string
+		code string
+	}{
+		{
+			"PUSH1 0x01 PUSH1 0 SSTORE",
+			"0x6001600055",
+		},
+		{
+			// the size of the push is the size of the value
+			"PUSH 1 PUSH 300 PUSH 0x0001 push 0",
+			"0x600161012c6100016000",
+		},
+		{
+			"PUSH2 1 PUSH32 0xff",
+			"0x6100017f00000000000000000000000000000000000000000000000000000000000000ff",
+		},
+		{
+			`
+			; jumps over the invalid opcode
+			PUSH @end JUMP
+			INVALID
+			end:
+				JUMPDEST
+				PUSH1 @end ; the position of the label
+				#data 0xdead
+			`,
+			"0x61000556fe5b6005dead",
+		},
+	}
+
+	for _, c := range cases {
+		code, err := Assemble(c.src)
+		assert.NoError(t, err)
+		assert.Equal(t, c.code, helper.EncodeToHex(code))
+	}
+}
+
+func TestAssembleErrors(t *testing.T) {
+	cases := []struct {
+		src string
+		err string
+	}{
+		{"ADD\nFOO", "line 2: unknown opcode 'FOO'"},
+		{"PUSH1", "line 1: expected an operand for PUSH1"},
+		{"PUSH1\n1", "line 1: expected an operand for PUSH1"},
+		{"PUSH1 256", "line 1: value '256' does not fit in 1 bytes"},
+		{"PUSH1 -1", "line 1: invalid value '-1'"},
+		{"PUSH @foo", "line 1: label 'foo' not found"},
+		{"a: a:", "line 1: label 'a' already defined"},
+		{"#data 0xz", "line 1: invalid data '0xz'"},
+		{"PUSH33 1", "line 1: unknown opcode 'PUSH33'"},
+	}
+
+	for _, c := range cases {
+		_, err := Assemble(c.src)
+		assert.EqualError(t, err, c.err, c.src)
+	}
+
+	// the position of the label does not fit in a PUSH1
+	_, err := Assemble("#data 0x" + strings.Repeat("00", 256) + "\nend: PUSH1 @end")
+	assert.EqualError(t, err, "line 2: position of label 'end' does not fit in PUSH1")
+}
+
+func TestDisassemble(t *testing.T) {
+	code := helper.MustDecodeHex("0x6001600055" + "61000a56" + "00" + "fe6000" + "5b" + "a5" + "6101")
+
+	instructions := Disassemble(code)
+	assert.Equal(t, []*Instruction{
+		{PC: 0, Op: evm.PUSH1, Immediate: []byte{0x01}},
+		{PC: 2, Op: evm.PUSH1, Immediate: []byte{0x00}},
+		{PC: 4, Op: evm.SSTORE},
+		{PC: 5, Op: evm.PUSH1 + 1, Immediate: []byte{0x00, 0x0a}},
+		{PC: 8, Op: evm.JUMP},
+		// the code after a halting opcode is data until the next JUMPDEST
+		{PC: 9, Data: []byte{0x00, 0xfe, 0x60, 0x00}},
+		{PC: 13, Op: evm.JUMPDEST},
+		// the unknown opcodes and the truncated pushes are data
+		{PC: 14, Data: []byte{0xa5, 0x61, 0x01}},
+	}, instructions)
+
+	assert.Equal(t, "PUSH1 0x01\nPUSH1 0x00\nSSTORE\nPUSH2 0x000a\nJUMP\n#data 0x00fe6000\nJUMPDEST\n#data 0xa56101\n", DisassembleString(code))
+
+	// the JUMPDEST bytes in the immediates do not end a data section
+	assert.Equal(t, []*Instruction{
+		{PC: 0, Op: evm.STOP},
+		{PC: 1, Data: []byte{0x60, 0x5b}},
+	}, Disassemble([]byte{0x00, 0x60, 0x5b}))
+}
+
+func TestAssembleRoundTrip(t *testing.T) {
+	code := helper.MustDecodeHex("0x608060405234801561001057600080fd5b50600436106100365760003560e01c80632e64cec11461003b5780636057361d14610059575b600080fd5bfea26469706673582212")
+
+	src := DisassembleString(code)
+	res, err := Assemble(src)
+	assert.NoError(t, err)
+	assert.Equal(t, code, res)
+}
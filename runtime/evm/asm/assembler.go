@@ -0,0 +1,230 @@
+package asm
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/0xPolygon/eth-state-transition/helper"
+	"github.com/0xPolygon/eth-state-transition/runtime/evm"
+)
+
+// dataDirective includes raw bytes in the code
+const dataDirective = "#data"
+
+// labelPushSize is the size of the immediate of a PUSH of a label without size
+const labelPushSize = 2
+
+type item struct {
+	line int
+
+	// op is the opcode, not used for the data sections
+	op evm.OpCode
+
+	isData bool
+
+	// data is the immediate of a push or the bytes of a data section
+	data []byte
+
+	// label is the label whose position is pushed
+	label string
+}
+
+func (i *item) size() int {
+	if i.isData {
+		return len(i.data)
+	}
+	if size, ok := isPush(i.op); ok {
+		return 1 + size
+	}
+	return 1
+}
+
+// Assemble encodes a program into its code. The program is a sequence of
+// opcodes separated by spaces or new lines, with the text after a ';' as comment:
+//
+//	start:              a label with the position of the next instruction
+//	PUSH1 0x2a          a PUSH with its value, in hex or decimal
+//	PUSH 300            a PUSH of the smallest size for its value
+//	PUSH2 @start        a PUSH of the position of a label
+//	PUSH @start         a PUSH2 of the position of a label
+//	#data 0xdeadbeef    raw bytes
+//
+// The labels do not include a JUMPDEST, it has to be written before the
+// instructions that are the destination of a jump.
+func Assemble(src string) ([]byte, error) {
+	tokens := tokenize(src)
+
+	items := []*item{}
+	labels := map[string]int{}
+
+	pc := 0
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+
+		if strings.HasSuffix(tok.text, ":") {
+			name := strings.TrimSuffix(tok.text, ":")
+			if name == "" {
+				return nil, fmt.Errorf("line %d: empty label", tok.line)
+			}
+			if _, ok := labels[name]; ok {
+				return nil, fmt.Errorf("line %d: label '%s' already defined", tok.line, name)
+			}
+			labels[name] = pc
+			continue
+		}
+
+		// the directives and the pushes take an operand
+		operand := func() (string, error) {
+			if i+1 >= len(tokens) || tokens[i+1].line != tok.line {
+				return "", fmt.Errorf("line %d: expected an operand for %s", tok.line, tok.text)
+			}
+			i++
+			return tokens[i].text, nil
+		}
+
+		it := &item{line: tok.line}
+		name := strings.ToUpper(tok.text)
+
+		switch {
+		case name == strings.ToUpper(dataDirective):
+			str, err := operand()
+			if err != nil {
+				return nil, err
+			}
+			data, err := helper.ParseBytes(&str)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid data '%s'", tok.line, str)
+			}
+			it.data, it.isData = data, true
+
+		case strings.HasPrefix(name, "PUSH"):
+			str, err := operand()
+			if err != nil {
+				return nil, err
+			}
+			if err := it.setPush(name, str); err != nil {
+				return nil, fmt.Errorf("line %d: %v", tok.line, err)
+			}
+
+		default:
+			op, ok := evm.StringToOpCode(name)
+			if !ok {
+				return nil, fmt.Errorf("line %d: unknown opcode '%s'", tok.line, tok.text)
+			}
+			it.op = op
+		}
+
+		items = append(items, it)
+		pc += it.size()
+	}
+
+	code := make([]byte, 0, pc)
+	for _, it := range items {
+		if it.label != "" {
+			dst, ok := labels[it.label]
+			if !ok {
+				return nil, fmt.Errorf("line %d: label '%s' not found", it.line, it.label)
+			}
+			size, _ := isPush(it.op)
+			value := big.NewInt(int64(dst)).Bytes()
+			if len(value) > size {
+				return nil, fmt.Errorf("line %d: position of label '%s' does not fit in %s", it.line, it.label, it.op)
+			}
+			it.data = leftPad(value, size)
+		}
+
+		if it.isData {
+			code = append(code, it.data...)
+			continue
+		}
+		code = append(code, byte(it.op))
+		code = append(code, it.data...)
+	}
+	return code, nil
+}
+
+// MustAssemble is like Assemble but panics if the program is not valid
+func MustAssemble(src string) []byte {
+	code, err := Assemble(src)
+	if err != nil {
+		panic(err)
+	}
+	return code
+}
+
+func (it *item) setPush(name string, operand string) error {
+	size := 0
+	if name != "PUSH" {
+		op, ok := evm.StringToOpCode(name)
+		if !ok {
+			return fmt.Errorf("unknown opcode '%s'", name)
+		}
+		size, _ = isPush(op)
+	}
+
+	if strings.HasPrefix(operand, "@") {
+		it.label = operand[1:]
+		if it.label == "" {
+			return fmt.Errorf("empty label")
+		}
+		if size == 0 {
+			size = labelPushSize
+		}
+		it.op = evm.PUSH1 + evm.OpCode(size-1)
+		return nil
+	}
+
+	value, err := helper.ParseUint256orHex(&operand)
+	if err != nil || value.Sign() < 0 {
+		return fmt.Errorf("invalid value '%s'", operand)
+	}
+	data := value.Bytes()
+
+	// the hex values of a PUSH without size keep their size
+	if size == 0 && strings.HasPrefix(operand, "0x") {
+		if n := (len(operand) - 1) / 2; n > len(data) {
+			data = leftPad(data, n)
+		}
+	}
+	if len(data) == 0 {
+		data = []byte{0}
+	}
+	if size == 0 {
+		size = len(data)
+	}
+	if len(data) > size || size > 32 {
+		return fmt.Errorf("value '%s' does not fit in %d bytes", operand, size)
+	}
+
+	it.op = evm.PUSH1 + evm.OpCode(size-1)
+	it.data = leftPad(data, size)
+	return nil
+}
+
+func leftPad(b []byte, size int) []byte {
+	if len(b) >= size {
+		return b
+	}
+	res := make([]byte, size)
+	copy(res[size-len(b):], b)
+	return res
+}
+
+type token struct {
+	text string
+	line int
+}
+
+func tokenize(src string) []token {
+	tokens := []token{}
+	for i, line := range strings.Split(src, "\n") {
+		if j := strings.Index(line, ";"); j >= 0 {
+			line = line[:j]
+		}
+		for _, text := range strings.Fields(line) {
+			tokens = append(tokens, token{text: text, line: i + 1})
+		}
+	}
+	return tokens
+}
@@ -0,0 +1,124 @@
+package asm
+
+import (
+	"strings"
+
+	"github.com/0xPolygon/eth-state-transition/helper"
+	"github.com/0xPolygon/eth-state-transition/runtime/evm"
+)
+
+// Instruction is an opcode of a code with its immediate value
+// or a section of data of the code
+type Instruction struct {
+	// PC is the position of the instruction in the code
+	PC uint64
+
+	Op evm.OpCode
+
+	// Immediate is the value of a PUSH
+	Immediate []byte
+
+	// Data are the bytes of a data section. A data section are the bytes that
+	// cannot be executed (i.e. after a halting opcode and before the next JUMPDEST),
+	// the unknown opcodes and the PUSH opcodes truncated by the end of the code.
+	Data []byte
+}
+
+// IsData returns true if the instruction is a data section
+func (i *Instruction) IsData() bool {
+	return i.Data != nil
+}
+
+// Size returns the number of bytes of the instruction
+func (i *Instruction) Size() int {
+	if i.IsData() {
+		return len(i.Data)
+	}
+	return 1 + len(i.Immediate)
+}
+
+// String returns the instruction in the format of the assembler
+func (i *Instruction) String() string {
+	if i.IsData() {
+		return dataDirective + " " + helper.EncodeToHex(i.Data)
+	}
+	if len(i.Immediate) != 0 {
+		return i.Op.String() + " " + helper.EncodeToHex(i.Immediate)
+	}
+	return i.Op.String()
+}
+
+// isPush returns the size of the immediate of the opcode if it is a push
+func isPush(op evm.OpCode) (int, bool) {
+	if op >= evm.PUSH1 && op <= evm.PUSH32 {
+		return int(op-evm.PUSH1) + 1, true
+	}
+	return 0, false
+}
+
+// halts returns true if the next opcode is not executed after the opcode
+func halts(op evm.OpCode) bool {
+	switch op {
+	case evm.STOP, evm.JUMP, evm.RETURN, evm.REVERT, evm.INVALID, evm.SELFDESTRUCT:
+		return true
+	}
+	return false
+}
+
+// Disassemble decodes a code into its instructions. The code after a halting
+// opcode is a data section until the next JUMPDEST, since it can only be
+// reached by a jump.
+func Disassemble(code []byte) []*Instruction {
+	instructions := []*Instruction{}
+
+	data := false
+	for pc := 0; pc < len(code); {
+		op := evm.OpCode(code[pc])
+
+		var ins *Instruction
+		if size, ok := isPush(op); ok && pc+1+size > len(code) {
+			// the immediate is truncated by the end of the code
+			ins = &Instruction{Data: code[pc:]}
+		} else if ok {
+			ins = &Instruction{Op: op, Immediate: code[pc+1 : pc+1+size]}
+		} else if op.String() == "" {
+			ins = &Instruction{Data: code[pc : pc+1]}
+		} else {
+			ins = &Instruction{Op: op}
+		}
+		ins.PC = uint64(pc)
+
+		if op == evm.JUMPDEST {
+			data = false
+		}
+
+		// merge the bytes of a data section. The bytes are still decoded to find
+		// the JUMPDEST that ends it, skipping the immediates like the EVM does.
+		if data || ins.IsData() {
+			bytes := code[pc : pc+ins.Size()]
+			if last := len(instructions) - 1; last >= 0 && instructions[last].IsData() {
+				prev := instructions[last]
+				prev.Data = code[prev.PC : pc+len(bytes)]
+			} else {
+				instructions = append(instructions, &Instruction{PC: uint64(pc), Data: bytes})
+			}
+			data = true
+		} else {
+			instructions = append(instructions, ins)
+			data = halts(op)
+		}
+		pc += ins.Size()
+	}
+	return instructions
+}
+
+// DisassembleString decodes a code into its text in the format of
+// the assembler, one instruction per line
+func DisassembleString(code []byte) string {
+	var str strings.Builder
+	for _, ins := range Disassemble(code) {
+		str.WriteString(ins.String())
+		str.WriteString("\n")
+	}
+	return str.String()
+}
@@ -0,0 +1,133 @@
+package evm
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xPolygon/eth-state-transition/helper"
+	"github.com/0xPolygon/eth-state-transition/runtime"
+	"github.com/stretchr/testify/assert"
+)
+
+// push32 is a PUSH32 of the largest 256 bits prime (2^256 - 189)
+var push32 = append([]byte{PUSH32}, helper.MustDecodeHex("0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff43")...)
+
+// computeLoop returns a code that runs the body n times. The body runs with
+// the accumulator at the top of the stack and the counter below it.
+func computeLoop(n uint16, body ...[]byte) []byte {
+	code := []byte{
+		PUSH1 + 1, byte(n >> 8), byte(n),
+		PUSH1, 0x01,
+		// loop
+		JUMPDEST,
+		DUP1 + 1, ISZERO, PUSH1 + 1, 0x00, 0x00, JUMPI,
+	}
+	for _, b := range body {
+		code = append(code, b...)
+	}
+	code = append(code,
+		// decrease the counter
+		SWAP1, PUSH1, 0x01, SWAP1, SUB, SWAP1,
+		PUSH1, 0x05, JUMP,
+	)
+
+	// the end of the loop
+	end := len(code)
+	code[9], code[10] = byte(end>>8), byte(end)
+
+	// return the accumulator
+	return append(code, JUMPDEST, PUSH1, 0x00, MSTORE, PUSH1, 0x20, PUSH1, 0x00, RETURN)
+}
+
+var (
+	arithmeticCode = computeLoop(10000,
+		// acc = acc * i + c
+		[]byte{DUP1 + 1, MUL},
+		push32, []byte{ADD},
+		// acc = acc + acc / i - acc % (i + 7)
+		[]byte{DUP1 + 1, DUP1 + 1, DIV, ADD},
+		[]byte{PUSH1, 0x07, DUP1 + 2, ADD, DUP1 + 1, MOD, SWAP1, SUB},
+		// signed division
+		[]byte{DUP1 + 1, DUP1 + 1, SDIV, SWAP1, POP},
+	)
+
+	modExpCode = computeLoop(10000,
+		// acc = acc * acc mod p
+		push32, []byte{DUP1 + 1, DUP1, MULMOD, SWAP1, POP},
+		// acc = acc + acc mod p
+		push32, []byte{DUP1 + 1, DUP1, ADDMOD, SWAP1, POP},
+		// acc = (acc | 1) ^ i, the base is odd to not reach zero
+		[]byte{PUSH1, 0x01, OR, DUP1 + 1, SWAP1, EXP},
+	)
+
+	bitwiseCode = computeLoop(10000,
+		push32, []byte{XOR},
+		[]byte{DUP1, PUSH1, 0x03, SHL, OR},
+		[]byte{DUP1, PUSH1, 0x11, SAR, AND},
+		[]byte{DUP1, PUSH1, 0x05, SHR, XOR, NOT},
+		[]byte{PUSH1, 0x0f, SIGNEXTEND},
+		[]byte{DUP1, PUSH1, 0x03, BYTE, ADD},
+		[]byte{DUP1, DUP1 + 2, LT, ADD, DUP1, PUSH1, 0x00, SGT, ADD},
+	)
+)
+
+var computeConfig = &runtime.ForksInTime{
+	Homestead:      true,
+	Byzantium:      true,
+	Constantinople: true,
+	Petersburg:     true,
+	Istanbul:       true,
+	EIP150:         true,
+	EIP158:         true,
+	EIP155:         true,
+}
+
+func benchmarkCode(b *testing.B, code []byte) {
+	for _, mode := range []ExecutionMode{Interpreter, BasicBlocks} {
+		b.Run(mode.String(), func(b *testing.B) {
+			evm := NewEVM()
+			evm.SetExecutionMode(mode)
+			host := &mockHost{}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				res := evm.Run(newMockContract(big.NewInt(0), 100000000, code), host, computeConfig)
+				if res.Err != nil {
+					b.Fatal(res.Err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkArithmetic(b *testing.B) {
+	benchmarkCode(b, arithmeticCode)
+}
+
+func BenchmarkModExp(b *testing.B) {
+	benchmarkCode(b, modExpCode)
+}
+
+func BenchmarkBitwise(b *testing.B) {
+	benchmarkCode(b, bitwiseCode)
+}
+
+func TestComputeCodes(t *testing.T) {
+	cases := []struct {
+		code    []byte
+		result  string
+		gasLeft uint64
+	}{
+		{arithmeticCode, "0x00068de3aefe55f930916c2bbaab1388e59a900a3db3c16d66555be335eb37b0", 98869958},
+		{modExpCode, "0x9594924eb23a2ca0a015ac51aff7506b4d5c2f441a571eeab3951aee04a789d7", 97892708},
+		{bitwiseCode, "0x00000000000000000000000000000000000000000000000000000000000000fd", 98649958},
+	}
+
+	for _, c := range cases {
+		res := NewEVM().Run(newMockContract(big.NewInt(0), 100000000, c.code), &mockHost{}, computeConfig)
+		assert.NoError(t, res.Err)
+		assert.Equal(t, c.result, helper.EncodeToHex(res.ReturnValue))
+		assert.Equal(t, c.gasLeft, res.GasLeft)
+	}
+}
@@ -45,3 +45,22 @@ func isPushOp(i byte) bool {
 	// From PUSH1 (0x60) to PUSH32(0x7F)
 	return i>>5 == 3
 }
+
+// JumpDests are the valid jump destinations of a code
+type JumpDests struct {
+	bitmap bitmap
+	size   uint64
+}
+
+// NewJumpDests finds the valid jump destinations of a code,
+// the JUMPDEST opcodes that are not part of a PUSH immediate
+func NewJumpDests(code []byte) *JumpDests {
+	j := &JumpDests{size: uint64(len(code))}
+	j.bitmap.setCode(code)
+	return j
+}
+
+// IsJumpDest returns true if the position is a valid jump destination
+func (j *JumpDests) IsJumpDest(pc uint64) bool {
+	return pc < j.size && j.bitmap.isSet(uint(pc))
+}
@@ -19,3 +19,18 @@ func TestIsPush(t *testing.T) {
 		t.Fatal("bad")
 	}
 }
+
+func TestJumpDests(t *testing.T) {
+	// PUSH1 0x5b JUMPDEST PUSH2 0x5b5b
+	j := NewJumpDests([]byte{0x60, 0x5b, 0x5b, 0x61, 0x5b, 0x5b})
+
+	dests := []uint64{}
+	for pc := uint64(0); pc < 8; pc++ {
+		if j.IsJumpDest(pc) {
+			dests = append(dests, pc)
+		}
+	}
+	if len(dests) != 1 || dests[0] != 2 {
+		t.Fatalf("bad jump destinations %v", dests)
+	}
+}
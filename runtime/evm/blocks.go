@@ -0,0 +1,247 @@
+package evm
+
+import (
+	"github.com/0xPolygon/eth-state-transition/runtime/evm/uint256"
+)
+
+// ExecutionMode is how the EVM executes the code
+type ExecutionMode int
+
+const (
+	// Interpreter executes the code one opcode at a time
+	Interpreter ExecutionMode = iota
+
+	// BasicBlocks compiles the code in basic blocks and charges the constant
+	// gas and checks the stack of each block once when the block is entered
+	BasicBlocks
+)
+
+func (m ExecutionMode) String() string {
+	switch m {
+	case Interpreter:
+		return "interpreter"
+	case BasicBlocks:
+		return "basic-blocks"
+	default:
+		return "unknown"
+	}
+}
+
+// blockOp is an operation of a basic block
+type blockOp struct {
+	pc int
+	op *operation
+
+	// exec is the instruction of the operation, the value of the
+	// push operations is decoded when the code is compiled
+	exec instruction
+
+	// dynamic is true if the operation expands the memory or has dynamic gas
+	dynamic bool
+
+	// prepaid is the constant gas of the next operations of the block
+	prepaid uint64
+}
+
+// basicBlock is a sequence of operations that only jumps or consumes
+// the gas left in its last operation
+type basicBlock struct {
+	ops []blockOp
+
+	// constantGas is the constant gas of all the operations
+	constantGas uint64
+
+	// minStack and maxStack are the bounds of the stack to
+	// execute all the operations without checking the stack
+	minStack int
+	maxStack int
+}
+
+// program is a code compiled in basic blocks
+type program struct {
+	// blocks are the blocks indexed by the position of their first operation
+	blocks []*basicBlock
+}
+
+// endsBlock returns true if the opcode is the last of a basic block. The
+// block ends after the jumps and halts, and after the opcodes that read or
+// consume the gas left, since the gas left is lower while the constant gas
+// of the next operations is prepaid.
+func endsBlock(op OpCode) bool {
+	switch op {
+	case STOP, JUMP, JUMPI, RETURN, REVERT, SELFDESTRUCT,
+		GAS, SSTORE, CREATE, CREATE2, CALL, CALLCODE, DELEGATECALL, STATICCALL:
+		return true
+	}
+	return false
+}
+
+// compile splits a code in basic blocks, a block starts at the beginning of
+// the code, at each JUMPDEST and after the last operation of a block
+func compile(code []byte, table *jumpTable) *program {
+	p := &program{
+		blocks: make([]*basicBlock, len(code)),
+	}
+
+	for pc := 0; pc < len(code); {
+		b := &basicBlock{
+			maxStack: stackSize,
+		}
+		p.blocks[pc] = b
+
+		height := 0
+		for pc < len(code) {
+			opcode := OpCode(code[pc])
+			op := table[opcode]
+
+			next := pc + 1
+			if op == nil {
+				// the opcode fails when it is executed
+				b.ops = append(b.ops, blockOp{pc: pc})
+				pc = next
+				break
+			}
+
+			bop := blockOp{
+				pc:      pc,
+				op:      op,
+				exec:    op.execute,
+				dynamic: op.memorySize != nil || op.dynamicGas != nil,
+			}
+			if isPushOp(byte(opcode)) {
+				n := int(opcode-PUSH1) + 1
+				bop.exec = compilePush(code, pc, n)
+				next += n
+			}
+			b.ops = append(b.ops, bop)
+			b.constantGas += op.constantGas
+
+			// the stack before the operation is the stack at the
+			// start of the block plus the height
+			if op.minStack-height > b.minStack {
+				b.minStack = op.minStack - height
+			}
+			if op.maxStack-height < b.maxStack {
+				b.maxStack = op.maxStack - height
+			}
+			height += stackSize - op.maxStack
+
+			pc = next
+			if endsBlock(opcode) || (pc < len(code) && code[pc] == JUMPDEST) {
+				break
+			}
+		}
+
+		prepaid := uint64(0)
+		for i := len(b.ops) - 1; i >= 0; i-- {
+			b.ops[i].prepaid = prepaid
+			if b.ops[i].op != nil {
+				prepaid += b.ops[i].op.constantGas
+			}
+		}
+	}
+	return p
+}
+
+// compilePush returns a push instruction with the value decoded from the code
+func compilePush(code []byte, pc, n int) instruction {
+	var value uint256.Int
+	pushValue(&value, code, pc, n)
+
+	return func(c *state) {
+		c.push1().Set(&value)
+		c.ip += n
+	}
+}
+
+// runProgram executes a code compiled in basic blocks
+func (c *state) runProgram(p *program) ([]byte, error) {
+	var vmerr error
+
+	codeSize := len(c.code)
+	for !c.stop {
+		if c.ip >= codeSize {
+			c.halt()
+			break
+		}
+		c.runBlock(p.blocks[c.ip])
+	}
+
+	if err := c.err; err != nil {
+		vmerr = err
+	}
+	return c.ret, vmerr
+}
+
+// runBlock executes a basic block. If the stack or the gas left are not enough
+// to execute all the operations, the block is interpreted to fail at the same
+// operation as the interpreter.
+func (c *state) runBlock(b *basicBlock) {
+	i := 0
+	if c.sp >= b.minStack && c.sp <= b.maxStack && c.gas >= b.constantGas {
+		c.gas -= b.constantGas
+		i = c.runPrepaid(b)
+	}
+	c.interpret(b.ops[i:])
+}
+
+// runPrepaid executes the operations of a block with their constant gas
+// consumed, it returns the number of operations executed
+func (c *state) runPrepaid(b *basicBlock) int {
+	for i := range b.ops {
+		bop := &b.ops[i]
+		c.ip = bop.pc
+
+		if bop.op == nil {
+			c.exit(errOpCodeNotFound)
+			return i
+		}
+
+		if bop.dynamic {
+			// the dynamic gas is charged with the gas left of the
+			// interpreter, without the gas prepaid for the next operations
+			c.gas += bop.prepaid
+			if !c.prepareDynamic(bop.op) {
+				return i
+			}
+			if c.gas < bop.prepaid {
+				// the interpreter does not fail here, so the next
+				// operations are interpreted
+				bop.exec(c)
+				c.ip++
+				return i + 1
+			}
+			c.gas -= bop.prepaid
+		}
+
+		bop.exec(c)
+		c.ip++
+
+		if c.stop {
+			return i + 1
+		}
+	}
+	return len(b.ops)
+}
+
+// interpret executes the operations one at a time like the interpreter
+func (c *state) interpret(ops []blockOp) {
+	for i := range ops {
+		if c.stop {
+			return
+		}
+		bop := &ops[i]
+		c.ip = bop.pc
+
+		if bop.op == nil {
+			c.exit(errOpCodeNotFound)
+			return
+		}
+
+		if !c.prepare(bop.op) {
+			return
+		}
+		bop.exec(c)
+		c.ip++
+	}
+}
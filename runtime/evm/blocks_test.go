@@ -0,0 +1,105 @@
+package evm
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xPolygon/eth-state-transition/runtime"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompileBlocks(t *testing.T) {
+	code := []byte{
+		PUSH1, 0x01, PUSH1, 0x02, ADD, POP, // block 0
+		JUMPDEST, ADD, PUSH1, 0x00, JUMPI, // block 6
+		PUSH1, // block 11, truncated push
+	}
+
+	p := compile(code, frontierInstructionSet)
+	assert.Len(t, p.blocks, len(code))
+
+	for pc, b := range p.blocks {
+		switch pc {
+		case 0:
+			assert.Len(t, b.ops, 4)
+			assert.Equal(t, uint64(3+3+3+2), b.constantGas)
+			assert.Equal(t, 0, b.minStack)
+			assert.Equal(t, stackSize-2, b.maxStack)
+			assert.Equal(t, uint64(3+3+2), b.ops[0].prepaid)
+			assert.Equal(t, uint64(0), b.ops[3].prepaid)
+
+		case 6:
+			assert.Len(t, b.ops, 4)
+			assert.Equal(t, uint64(1+3+3+10), b.constantGas)
+			// ADD needs two items and JUMPI one more
+			assert.Equal(t, 2, b.minStack)
+
+		case 11:
+			assert.Len(t, b.ops, 1)
+
+		default:
+			assert.Nil(t, b, "pc %d", pc)
+		}
+	}
+}
+
+func TestBasicBlocksEquivalence(t *testing.T) {
+	codes := map[string][]byte{
+		"memory": {
+			PUSH1, 0x20, PUSH1 + 1, 0x01, 0x00, MSTORE, PUSH1, 0x01, PUSH1, 0x00, MSTORE8,
+			MSIZE, PUSH1, 0x00, MSTORE, PUSH1, 0x01, PUSH1, 0x02, ADD, POP,
+			PUSH1, 0x20, PUSH1, 0x00, RETURN,
+		},
+		"underflow": {
+			PUSH1, 0x01, PUSH1, 0x02, ADD, ADD, PUSH1, 0x00, MSTORE,
+		},
+		"overflow": {
+			JUMPDEST, PUSH1, 0x00, PUSH1, 0x00, JUMP,
+		},
+		"invalid opcode": {
+			PUSH1, 0x01, PUSH1, 0x02, INVALID, PUSH1, 0x00,
+		},
+		"invalid jump": {
+			PUSH1, 0x01, PUSH1, 0x04, JUMP, PUSH1, 0x00,
+		},
+		"revert": {
+			PUSH1, 0x2a, PUSH1, 0x00, MSTORE, PUSH1, 0x20, PUSH1, 0x00, REVERT,
+		},
+		"gas and pc": {
+			PC, GAS, ADD, PC, PUSH1, 0x00, MSTORE, PUSH1, 0x00, MSTORE, PUSH1, 0x40, PUSH1, 0x00, RETURN,
+		},
+		"truncated push": {
+			PUSH1, 0x01, PUSH1 + 3, 0x01, 0x02,
+		},
+		"loop": computeLoop(3,
+			[]byte{DUP1 + 1, ADD, DUP1, PUSH1 + 1, 0x01, 0x00, MSTORE},
+		),
+	}
+
+	config := &runtime.ForksInTime{}
+	for name, code := range codes {
+		for gas := uint64(0); gas < 1000; gas++ {
+			interpreter := NewEVM()
+			blocks := NewEVM()
+			blocks.SetExecutionMode(BasicBlocks)
+
+			expected := interpreter.Run(newMockContract(big.NewInt(0), gas, code), &mockHost{}, config)
+			found := blocks.Run(newMockContract(big.NewInt(0), gas, code), &mockHost{}, config)
+
+			if !assert.Equal(t, expected, found, "%s with %d gas", name, gas) {
+				break
+			}
+		}
+	}
+}
+
+func TestBasicBlocksComputeCodes(t *testing.T) {
+	evm := NewEVM()
+	evm.SetExecutionMode(BasicBlocks)
+
+	for _, code := range [][]byte{arithmeticCode, modExpCode, bitwiseCode} {
+		expected := NewEVM().Run(newMockContract(big.NewInt(0), 100000000, code), &mockHost{}, computeConfig)
+		found := evm.Run(newMockContract(big.NewInt(0), 100000000, code), &mockHost{}, computeConfig)
+		assert.Equal(t, expected, found)
+	}
+}
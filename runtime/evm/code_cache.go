@@ -0,0 +1,116 @@
+package evm
+
+import (
+	"sync"
+	"sync/atomic"
+
+	lru "github.com/hashicorp/golang-lru"
+
+	"github.com/0xPolygon/eth-state-transition/helper"
+	"github.com/0xPolygon/eth-state-transition/runtime"
+	"github.com/0xPolygon/eth-state-transition/types"
+)
+
+// defaultCodeCacheSize is the number of contracts in the default code cache
+const defaultCodeCacheSize = 4096
+
+// DefaultCodeCache is the code cache of the EVMs created with NewEVM,
+// it is shared by all the transitions
+var DefaultCodeCache = NewCodeCache(defaultCodeCacheSize)
+
+var emptyCodeHash = types.BytesToHash(helper.Keccak256(nil))
+
+// analysedCode is a code with its valid jump destinations
+type analysedCode struct {
+	code      []byte
+	jumpDests bitmap
+
+	// programs are the code compiled with each jump table
+	programs sync.Map
+}
+
+// program returns the code compiled with a jump table
+func (a *analysedCode) program(table *jumpTable) *program {
+	if p, ok := a.programs.Load(table); ok {
+		return p.(*program)
+	}
+	p, _ := a.programs.LoadOrStore(table, compile(a.code, table))
+	return p.(*program)
+}
+
+// CodeCache is a bounded cache of analysed code keyed by the hash of the code.
+// It is safe for concurrent use, so it can be shared by many EVMs.
+type CodeCache struct {
+	cache *lru.Cache
+
+	hits   uint64
+	misses uint64
+}
+
+// CodeCacheStats are the statistics of a code cache
+type CodeCacheStats struct {
+	// Hits is the number of call frames that found their code analysed in the cache
+	Hits uint64
+
+	// Misses is the number of call frames that analysed their code
+	Misses uint64
+
+	// Entries is the number of contracts in the cache
+	Entries int
+}
+
+// NewCodeCache creates a code cache of at most size contracts
+func NewCodeCache(size int) *CodeCache {
+	cache, err := lru.New(size)
+	if err != nil {
+		panic(err)
+	}
+	return &CodeCache{cache: cache}
+}
+
+// Resize changes the number of contracts of the cache
+func (c *CodeCache) Resize(size int) {
+	c.cache.Resize(size)
+}
+
+// Purge removes all the contracts of the cache
+func (c *CodeCache) Purge() {
+	c.cache.Purge()
+}
+
+// Stats returns the statistics of the cache
+func (c *CodeCache) Stats() CodeCacheStats {
+	return CodeCacheStats{
+		Hits:    atomic.LoadUint64(&c.hits),
+		Misses:  atomic.LoadUint64(&c.misses),
+		Entries: c.cache.Len(),
+	}
+}
+
+// GetCode returns the code of an address and its hash. The code is only
+// fetched from the host if it is not in the cache.
+func (c *CodeCache) GetCode(host runtime.Host, addr types.Address) (types.Hash, []byte) {
+	hash := host.GetCodeHash(addr)
+	if hash == (types.Hash{}) || hash == emptyCodeHash {
+		return types.Hash{}, host.GetCode(addr)
+	}
+	if obj, ok := c.cache.Peek(hash); ok {
+		return hash, obj.(*analysedCode).code
+	}
+	return hash, host.GetCode(addr)
+}
+
+// analyse returns the analysis of a code, the code is only analysed if
+// its hash is not in the cache
+func (c *CodeCache) analyse(hash types.Hash, code []byte) *analysedCode {
+	if obj, ok := c.cache.Get(hash); ok {
+		atomic.AddUint64(&c.hits, 1)
+		return obj.(*analysedCode)
+	}
+	atomic.AddUint64(&c.misses, 1)
+
+	a := &analysedCode{code: code}
+	a.jumpDests.setCode(code)
+	c.cache.Add(hash, a)
+	return a
+}
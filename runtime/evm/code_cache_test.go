@@ -0,0 +1,67 @@
+package evm
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xPolygon/eth-state-transition/helper"
+	"github.com/0xPolygon/eth-state-transition/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// codeHost is a host with the code of a single contract
+type codeHost struct {
+	mockHost
+
+	code     []byte
+	getCodes int
+}
+
+func (c *codeHost) GetCodeHash(addr types.Address) types.Hash {
+	return types.BytesToHash(helper.Keccak256(c.code))
+}
+
+func (c *codeHost) GetCode(addr types.Address) []byte {
+	c.getCodes++
+	return c.code
+}
+
+func TestCodeCache(t *testing.T) {
+	// jump over an invalid opcode and return
+	code := []byte{
+		PUSH1, 0x04, JUMP, 0xfe, JUMPDEST, byte(STOP),
+	}
+	host := &codeHost{code: code}
+
+	cache := NewCodeCache(2)
+	evm := NewEVMWithCodeCache(cache)
+
+	for i := 0; i < 3; i++ {
+		hash, res := cache.GetCode(host, types.Address{})
+		assert.Equal(t, code, res)
+
+		contract := newMockContract(big.NewInt(0), 1000, res)
+		contract.CodeHash = hash
+
+		assert.NoError(t, evm.Run(contract, host, computeConfig).Err)
+	}
+
+	// the code is only fetched and analysed once
+	assert.Equal(t, 1, host.getCodes)
+	assert.Equal(t, CodeCacheStats{Hits: 2, Misses: 1, Entries: 1}, cache.Stats())
+
+	// the init code is not cached
+	assert.NoError(t, evm.Run(newMockContract(big.NewInt(0), 1000, code), host, computeConfig).Err)
+	assert.Equal(t, CodeCacheStats{Hits: 2, Misses: 1, Entries: 1}, cache.Stats())
+
+	cache.Purge()
+	assert.Equal(t, 0, cache.Stats().Entries)
+}
+
+func TestCodeCacheEmptyCode(t *testing.T) {
+	host := &codeHost{}
+
+	hash, code := NewCodeCache(1).GetCode(host, types.Address{})
+	assert.Equal(t, types.Hash{}, hash)
+	assert.Empty(t, code)
+}
@@ -2,17 +2,45 @@ package evm
 
 import (
 	"github.com/0xPolygon/eth-state-transition/runtime"
+	"github.com/0xPolygon/eth-state-transition/types"
 )
 
 var _ runtime.Runtime = &EVM{}
 
 // EVM is the ethereum virtual machine
 type EVM struct {
+	codeCache *CodeCache
+	mode      ExecutionMode
 }
 
-// NewEVM creates a new EVM
+// NewEVM creates a new EVM that uses the default code cache
 func NewEVM() *EVM {
-	return &EVM{}
+	return NewEVMWithCodeCache(DefaultCodeCache)
+}
+
+// NewEVMWithCodeCache creates a new EVM that uses a code cache
+func NewEVMWithCodeCache(codeCache *CodeCache) *EVM {
+	return &EVM{codeCache: codeCache}
+}
+
+// CodeCache returns the code cache of the EVM
+func (e *EVM) CodeCache() *CodeCache {
+	return e.codeCache
+}
+
+// SetCodeCache sets the code cache of the EVM
+func (e *EVM) SetCodeCache(codeCache *CodeCache) {
+	e.codeCache = codeCache
+}
+
+// ExecutionMode returns how the EVM executes the code
+func (e *EVM) ExecutionMode() ExecutionMode {
+	return e.mode
+}
+
+// SetExecutionMode sets how the EVM executes the code
+func (e *EVM) SetExecutionMode(mode ExecutionMode) {
+	e.mode = mode
 }
 
 // CanRun implements the runtime interface
@@ -38,7 +66,25 @@ func (e *EVM) Run(c *runtime.Contract, host runtime.Host, config *runtime.ForksI
 	contract.host = host
 	contract.config = config
 
-	contract.bitmap.setCode(c.Code)
+	var analysed *analysedCode
+	if c.CodeHash != (types.Hash{}) {
+		analysed = e.codeCache.analyse(c.CodeHash, c.Code)
+		contract.jumpDests = &analysed.jumpDests
+	} else {
+		// the init code of a contract is not cached
+		contract.bitmap.setCode(c.Code)
+		contract.jumpDests = &contract.bitmap
+	}
+
+	// the traced code is always interpreted
+	if e.mode == BasicBlocks && c.Tracer == nil {
+		table := jumpTableFor(config)
+		if analysed != nil {
+			contract.program = analysed.program(table)
+		} else {
+			contract.program = compile(c.Code, table)
+		}
+	}
 
 	ret, err := contract.Run()
 
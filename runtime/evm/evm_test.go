@@ -81,6 +81,14 @@ func (m *mockHost) GetNonce(addr types.Address) uint64 {
 	panic("Not implemented in tests")
 }
 
+func (m *mockHost) AccessAddress(addr types.Address) bool {
+	panic("Not implemented in tests")
+}
+
+func (m *mockHost) AccessSlot(addr types.Address, key types.Hash) bool {
+	panic("Not implemented in tests")
+}
+
 func TestRun(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -158,3 +166,66 @@ func TestRun(t *testing.T) {
 		})
 	}
 }
+
+// mockHostForAccessList keeps the access list of the transaction
+type mockHostForAccessList struct {
+	mockHost
+	addrs map[types.Address]struct{}
+	slots map[types.Hash]struct{}
+}
+
+func (m *mockHostForAccessList) AccessAddress(addr types.Address) bool {
+	_, ok := m.addrs[addr]
+	m.addrs[addr] = struct{}{}
+	return ok
+}
+
+func (m *mockHostForAccessList) AccessSlot(addr types.Address, key types.Hash) bool {
+	_, ok := m.slots[key]
+	m.slots[key] = struct{}{}
+	return ok
+}
+
+func (m *mockHostForAccessList) GetStorage(types.Address, types.Hash) types.Hash {
+	return types.Hash{}
+}
+
+func (m *mockHostForAccessList) GetBalance(types.Address) *big.Int {
+	return big.NewInt(0)
+}
+
+func TestRunAccessListGas(t *testing.T) {
+	account := types.StringToAddress("0x1000")
+
+	// POP(SLOAD(0)) POP(BALANCE(account))
+	code := []byte{PUSH1, 0x00, SLOAD, POP, PUSH1 + 19}
+	code = append(code, account.Bytes()...)
+	code = append(code, BALANCE, POP)
+
+	run := func(config *runtime.ForksInTime, warm bool) uint64 {
+		host := &mockHostForAccessList{
+			addrs: map[types.Address]struct{}{},
+			slots: map[types.Hash]struct{}{},
+		}
+		if warm {
+			host.addrs[account] = struct{}{}
+			host.slots[types.Hash{}] = struct{}{}
+		}
+
+		res := NewEVM().Run(newMockContract(big.NewInt(0), 100000, code), host, config)
+		assert.NoError(t, res.Err)
+		return 100000 - res.GasLeft
+	}
+
+	// PUSH1, PUSH20 and the POPs
+	base := uint64(3 + 3 + 2 + 2)
+
+	istanbul := &runtime.ForksInTime{Homestead: true, EIP150: true, EIP158: true, Byzantium: true, Constantinople: true, Petersburg: true, Istanbul: true}
+	assert.Equal(t, base+800+700, run(istanbul, false))
+	assert.Equal(t, base+800+700, run(istanbul, true))
+
+	berlin := *istanbul
+	berlin.Berlin = true
+	assert.Equal(t, base+2100+2600, run(&berlin, false))
+	assert.Equal(t, base+100+100, run(&berlin, true))
+}
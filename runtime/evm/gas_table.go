@@ -0,0 +1,305 @@
+package evm
+
+import (
+	"github.com/0xPolygon/eth-state-transition/runtime"
+	"github.com/0xPolygon/eth-state-transition/runtime/evm/uint256"
+)
+
+// memoryRange returns the size of the memory to access size bytes at offset
+func memoryRange(offset, size *uint256.Int) (uint64, bool) {
+	if size.IsZero() {
+		return 0, true
+	}
+	if !offset.IsUint64() || !size.IsUint64() {
+		return 0, false
+	}
+
+	o := offset.Uint64()
+	s := size.Uint64()
+
+	if o > 0xffffffffe0 || s > 0xffffffffe0 {
+		return 0, false
+	}
+	return o + s, true
+}
+
+// the stack items are counted from the top of the stack, starting at 1
+
+func memoryMLoad(c *state) (uint64, bool) {
+	return memoryRange(c.peekAt(1), wordSize)
+}
+
+func memoryMStore(c *state) (uint64, bool) {
+	return memoryRange(c.peekAt(1), wordSize)
+}
+
+func memoryMStore8(c *state) (uint64, bool) {
+	return memoryRange(c.peekAt(1), one)
+}
+
+func memorySha3(c *state) (uint64, bool) {
+	return memoryRange(c.peekAt(1), c.peekAt(2))
+}
+
+func memoryCopy(c *state) (uint64, bool) {
+	return memoryRange(c.peekAt(1), c.peekAt(3))
+}
+
+func memoryExtCodeCopy(c *state) (uint64, bool) {
+	return memoryRange(c.peekAt(2), c.peekAt(4))
+}
+
+func memoryLog(c *state) (uint64, bool) {
+	return memoryRange(c.peekAt(1), c.peekAt(2))
+}
+
+func memoryCreate(c *state) (uint64, bool) {
+	return memoryRange(c.peekAt(2), c.peekAt(3))
+}
+
+func memoryHalt(c *state) (uint64, bool) {
+	return memoryRange(c.peekAt(1), c.peekAt(2))
+}
+
+// memoryCall returns the memory of the input and of the output of a call
+func memoryCall(op OpCode) memorySizeFunc {
+	// the value is only in CALL and CALLCODE
+	n := 3
+	if op == CALL || op == CALLCODE {
+		n = 4
+	}
+	return func(c *state) (uint64, bool) {
+		in, ok := memoryRange(c.peekAt(n), c.peekAt(n+1))
+		if !ok {
+			return 0, false
+		}
+		ret, ok := memoryRange(c.peekAt(n+2), c.peekAt(n+3))
+		if !ok {
+			return 0, false
+		}
+		if in > ret {
+			return in, true
+		}
+		return ret, true
+	}
+}
+
+const (
+	sha3WordGas uint64 = 6
+	copyGas     uint64 = 3
+)
+
+// words returns the number of words of a size, the memory is already
+// expanded so the size fits in an uint64 if it is not zero
+func words(size *uint256.Int) uint64 {
+	return (size.Uint64() + 31) / 32
+}
+
+// gasExp charges each byte of the exponent
+func gasExp(byteGas uint64) dynamicGasFunc {
+	return func(c *state) (uint64, error) {
+		return uint64((c.peekAt(2).BitLen()+7)/8) * byteGas, nil
+	}
+}
+
+func gasSha3(c *state) (uint64, error) {
+	return words(c.peekAt(2)) * sha3WordGas, nil
+}
+
+// gasCopy charges each word copied, n is the position of the length in the stack
+func gasCopy(n int) dynamicGasFunc {
+	return func(c *state) (uint64, error) {
+		return words(c.peekAt(n)) * copyGas, nil
+	}
+}
+
+func gasLog(topics int) dynamicGasFunc {
+	return func(c *state) (uint64, error) {
+		return uint64(topics)*375 + c.peekAt(2).Uint64()*8, nil
+	}
+}
+
+func gasCreate2(c *state) (uint64, error) {
+	return words(c.peekAt(3)) * sha3WordGas, nil
+}
+
+// gasSStoreSentry fails SSTORE if the gas left is not more than
+// the stipend of a call (eip-2200)
+func gasSStoreSentry(c *state) (uint64, error) {
+	if c.gas <= 2300 {
+		return 0, errOutOfGas
+	}
+	return 0, nil
+}
+
+// sstoreCosts is the cost of SSTORE for each status of the storage. The cost
+// depends on the status the host returns when the value is written, so it is
+// charged by the instruction.
+type sstoreCosts [runtime.StorageDeleted + 1]uint64
+
+// gasSelfDestruct charges the creation of the beneficiary. Before eip-158 it is
+// charged if the account does not exist, after if it is empty and gets a balance.
+func gasSelfDestruct(eip158 bool) dynamicGasFunc {
+	return func(c *state) (uint64, error) {
+		address := wordToAddress(c.peekAt(1))
+
+		if eip158 {
+			if c.host.Empty(address) && c.host.GetBalance(c.msg.Address).Sign() != 0 {
+				return 25000, nil
+			}
+		} else if !c.host.AccountExists(address) {
+			return 25000, nil
+		}
+		return 0, nil
+	}
+}
+
+// gasCall charges the value transfer, the creation of the account and the gas sent
+// to the new frame, which is stored in callGas. After eip-150 the new frame gets at
+// most all but one 64th of the gas left and after eip-158 the account is only
+// created if the call transfers value to an empty account.
+func gasCall(op OpCode, eip150, eip158 bool) dynamicGasFunc {
+	return func(c *state) (uint64, error) {
+		initialGas := c.peekAt(1)
+		addr := wordToAddress(c.peekAt(2))
+
+		transfersValue := false
+		if op == CALL || op == CALLCODE {
+			transfersValue = !c.peekAt(3).IsZero()
+		}
+
+		var gasCost uint64
+		if op == CALL {
+			if eip158 {
+				if transfersValue && c.host.Empty(addr) {
+					gasCost += 25000
+				}
+			} else if !c.host.AccountExists(addr) {
+				gasCost += 25000
+			}
+		}
+		if transfersValue {
+			gasCost += 9000
+		}
+
+		if eip150 {
+			if c.gas < gasCost {
+				return 0, errOutOfGas
+			}
+			availableGas := c.gas - gasCost
+			availableGas = availableGas - availableGas/64
+
+			if !initialGas.IsUint64() || availableGas < initialGas.Uint64() {
+				c.callGas = availableGas
+			} else {
+				c.callGas = initialGas.Uint64()
+			}
+		} else {
+			if !initialGas.IsUint64() {
+				return 0, errOutOfGas
+			}
+			c.callGas = initialGas.Uint64()
+		}
+
+		if gasCost+c.callGas < gasCost {
+			return 0, errGasUintOverflow
+		}
+		return gasCost + c.callGas, nil
+	}
+}
+
+// costs of the access to the state after eip-2929
+const (
+	coldAccountAccessCost uint64 = 2600
+	coldSloadCost         uint64 = 2100
+	warmStorageReadCost   uint64 = 100
+)
+
+// gasSLoadEIP2929 charges the access to a slot, which is cheaper
+// if the slot was already accessed by the transaction
+func gasSLoadEIP2929(c *state) (uint64, error) {
+	if c.host.AccessSlot(c.msg.Address, wordToHash(c.peekAt(1))) {
+		return warmStorageReadCost, nil
+	}
+	return coldSloadCost, nil
+}
+
+// gasSStoreEIP2929 charges the first access to a slot, the cost of
+// the write is charged by the instruction
+func gasSStoreEIP2929(c *state) (uint64, error) {
+	if _, err := gasSStoreSentry(c); err != nil {
+		return 0, err
+	}
+	if c.host.AccessSlot(c.msg.Address, wordToHash(c.peekAt(1))) {
+		return 0, nil
+	}
+	return coldSloadCost, nil
+}
+
+// gasAccountEIP2929 charges the first access to the account at the
+// position n of the stack, the warm access is the constant gas
+func gasAccountEIP2929(n int) dynamicGasFunc {
+	return func(c *state) (uint64, error) {
+		if c.host.AccessAddress(wordToAddress(c.peekAt(n))) {
+			return 0, nil
+		}
+		return coldAccountAccessCost - warmStorageReadCost, nil
+	}
+}
+
+// gasExtCodeCopyEIP2929 charges the words copied and the first access to the account
+func gasExtCodeCopyEIP2929(c *state) (uint64, error) {
+	gas, err := gasCopy(4)(c)
+	if err != nil {
+		return 0, err
+	}
+	cold, err := gasAccountEIP2929(1)(c)
+	if err != nil {
+		return 0, err
+	}
+	if gas+cold < gas {
+		return 0, errGasUintOverflow
+	}
+	return gas + cold, nil
+}
+
+// gasSelfDestructEIP2929 charges the first access to the beneficiary
+func gasSelfDestructEIP2929(c *state) (uint64, error) {
+	gas, err := gasSelfDestruct(true)(c)
+	if err != nil {
+		return 0, err
+	}
+	if !c.host.AccessAddress(wordToAddress(c.peekAt(1))) {
+		gas += coldAccountAccessCost
+	}
+	return gas, nil
+}
+
+// gasCallEIP2929 charges the first access to the account called. The cost
+// is charged before the gas sent to the new frame is computed.
+func gasCallEIP2929(op OpCode) dynamicGasFunc {
+	gasFn := gasCall(op, true, true)
+
+	return func(c *state) (uint64, error) {
+		if c.host.AccessAddress(wordToAddress(c.peekAt(2))) {
+			return gasFn(c)
+		}
+
+		coldCost := coldAccountAccessCost - warmStorageReadCost
+		if c.gas < coldCost {
+			return 0, errOutOfGas
+		}
+
+		c.gas -= coldCost
+		gas, err := gasFn(c)
+		c.gas += coldCost
+
+		if err != nil {
+			return 0, err
+		}
+		if gas+coldCost < gas {
+			return 0, errGasUintOverflow
+		}
+		return gas + coldCost, nil
+	}
+}
@@ -2,21 +2,22 @@ package evm
 
 import (
 	"fmt"
+	"math"
 	"math/big"
 	"math/bits"
 	"sync"
 
 	"github.com/0xPolygon/eth-state-transition/helper"
 	"github.com/0xPolygon/eth-state-transition/runtime"
+	"github.com/0xPolygon/eth-state-transition/runtime/evm/uint256"
 	"github.com/0xPolygon/eth-state-transition/types"
 )
 
 type instruction func(c *state)
 
 var (
-	zero     = big.NewInt(0)
-	one      = big.NewInt(1)
-	wordSize = big.NewInt(32)
+	one      = uint256.NewInt(1)
+	wordSize = uint256.NewInt(32)
 )
 
 func opAdd(c *state) {
@@ -24,7 +25,6 @@ func opAdd(c *state) {
 	b := c.top()
 
 	b.Add(a, b)
-	toU256(b)
 }
 
 func opMul(c *state) {
@@ -32,7 +32,6 @@ func opMul(c *state) {
 	b := c.top()
 
 	b.Mul(a, b)
-	toU256(b)
 }
 
 func opSub(c *state) {
@@ -40,112 +39,41 @@ func opSub(c *state) {
 	b := c.top()
 
 	b.Sub(a, b)
-	toU256(b)
 }
 
 func opDiv(c *state) {
 	a := c.pop()
 	b := c.top()
 
-	if b.Sign() == 0 {
-		// division by zero
-		b.Set(zero)
-	} else {
-		b.Div(a, b)
-		toU256(b)
-	}
+	b.Div(a, b)
 }
 
 func opSDiv(c *state) {
-	a := to256(c.pop())
-	b := to256(c.top())
+	a := c.pop()
+	b := c.top()
 
-	if b.Sign() == 0 {
-		// division by zero
-		b.Set(zero)
-	} else {
-		neg := a.Sign() != b.Sign()
-		b.Div(a.Abs(a), b.Abs(b))
-		if neg {
-			b.Neg(b)
-		}
-		toU256(b)
-	}
+	b.SDiv(a, b)
 }
 
 func opMod(c *state) {
 	a := c.pop()
 	b := c.top()
 
-	if b.Sign() == 0 {
-		// division by zero
-		b.Set(zero)
-	} else {
-		b.Mod(a, b)
-		toU256(b)
-	}
+	b.Mod(a, b)
 }
 
 func opSMod(c *state) {
-	a := to256(c.pop())
-	b := to256(c.top())
-
-	if b.Sign() == 0 {
-		// division by zero
-		b.Set(zero)
-	} else {
-		neg := a.Sign() < 0
-		b.Mod(a.Abs(a), b.Abs(b))
-		if neg {
-			b.Neg(b)
-		}
-		toU256(b)
-	}
-}
-
-var bigPool = sync.Pool{
-	New: func() interface{} {
-		return new(big.Int)
-	},
-}
-
-func acquireBig() *big.Int {
-	return bigPool.Get().(*big.Int)
-}
+	a := c.pop()
+	b := c.top()
 
-func releaseBig(b *big.Int) {
-	bigPool.Put(b)
+	b.SMod(a, b)
 }
 
 func opExp(c *state) {
 	x := c.pop()
 	y := c.top()
 
-	var gas uint64
-	if c.config.EIP158 {
-		gas = 50
-	} else {
-		gas = 10
-	}
-	gasCost := uint64((y.BitLen()+7)/8) * gas
-	if !c.consumeGas(gasCost) {
-		return
-	}
-
-	z := acquireBig().Set(one)
-
-	// https://www.programminglogic.com/fast-exponentiation-algorithms/
-	for _, d := range y.Bits() {
-		for i := 0; i < _W; i++ {
-			if d&1 == 1 {
-				toU256(z.Mul(z, x))
-			}
-			d >>= 1
-			toU256(x.Mul(x, x))
-		}
-	}
-	y.Set(z)
-	releaseBig(z)
+	y.Exp(x, y)
 }
 
 func opAddMod(c *state) {
@@ -153,14 +81,7 @@ func opAddMod(c *state) {
 	b := c.pop()
 	z := c.top()
 
-	if z.Sign() == 0 {
-		// divison by zero
-		z.Set(zero)
-	} else {
-		a = a.Add(a, b)
-		z = z.Mod(a, z)
-		toU256(z)
-	}
+	z.AddMod(a, b, z)
 }
 
 func opMulMod(c *state) {
@@ -168,14 +89,7 @@ func opMulMod(c *state) {
 	b := c.pop()
 	z := c.top()
 
-	if z.Sign() == 0 {
-		// divison by zero
-		z.Set(zero)
-	} else {
-		a = a.Mul(a, b)
-		z = z.Mod(a, z)
-		toU256(z)
-	}
+	z.MulMod(a, b, z)
 }
 
 func opAnd(c *state) {
@@ -199,36 +113,26 @@ func opXor(c *state) {
 	b.Xor(a, b)
 }
 
-var opByteMask = big.NewInt(255)
-
 func opByte(c *state) {
 	x := c.pop()
 	y := c.top()
 
-	indx := x.Int64()
-	if indx > 31 {
-		y.Set(zero)
-	} else {
-		sh := (31 - indx) * 8
-		y.Rsh(y, uint(sh))
-		y.And(y, opByteMask)
-	}
+	y.Byte(y, x)
 }
 
 func opNot(c *state) {
 	a := c.top()
 
 	a.Not(a)
-	toU256(a)
 }
 
 func opIsZero(c *state) {
 	a := c.top()
 
-	if a.Sign() == 0 {
-		a.Set(one)
+	if a.IsZero() {
+		a.SetOne()
 	} else {
-		a.Set(zero)
+		a.Clear()
 	}
 }
 
@@ -236,10 +140,10 @@ func opEq(c *state) {
 	a := c.pop()
 	b := c.top()
 
-	if a.Cmp(b) == 0 {
-		b.Set(one)
+	if a.Eq(b) {
+		b.SetOne()
 	} else {
-		b.Set(zero)
+		b.Clear()
 	}
 }
 
@@ -247,10 +151,10 @@ func opLt(c *state) {
 	a := c.pop()
 	b := c.top()
 
-	if a.Cmp(b) < 0 {
-		b.Set(one)
+	if a.Lt(b) {
+		b.SetOne()
 	} else {
-		b.Set(zero)
+		b.Clear()
 	}
 }
 
@@ -258,32 +162,32 @@ func opGt(c *state) {
 	a := c.pop()
 	b := c.top()
 
-	if a.Cmp(b) > 0 {
-		b.Set(one)
+	if a.Gt(b) {
+		b.SetOne()
 	} else {
-		b.Set(zero)
+		b.Clear()
 	}
 }
 
 func opSlt(c *state) {
-	a := to256(c.pop())
-	b := to256(c.top())
+	a := c.pop()
+	b := c.top()
 
-	if a.Cmp(b) < 0 {
-		b.Set(one)
+	if a.Slt(b) {
+		b.SetOne()
 	} else {
-		b.Set(zero)
+		b.Clear()
 	}
 }
 
 func opSgt(c *state) {
-	a := to256(c.pop())
-	b := to256(c.top())
+	a := c.pop()
+	b := c.top()
 
-	if a.Cmp(b) > 0 {
-		b.Set(one)
+	if a.Sgt(b) {
+		b.SetOne()
 	} else {
-		b.Set(zero)
+		b.Clear()
 	}
 }
 
@@ -291,87 +195,37 @@ func opSignExtension(c *state) {
 	ext := c.pop()
 	x := c.top()
 
-	if ext.Cmp(wordSize) > 0 {
-		return
-	}
-	if x == nil {
-		return
-	}
-
-	bit := uint(ext.Uint64()*8 + 7)
-
-	mask := acquireBig().Set(one)
-	mask.Lsh(mask, bit)
-	mask.Sub(mask, one)
-
-	if x.Bit(int(bit)) > 0 {
-		mask.Not(mask)
-		x.Or(x, mask)
-	} else {
-		x.And(x, mask)
-	}
-
-	toU256(x)
-	releaseBig(mask)
+	x.ExtendSign(x, ext)
 }
 
-func equalOrOverflowsUint256(b *big.Int) bool {
-	return b.BitLen() > 8
+// shiftAmount returns the shift of SHL, SHR and SAR,
+// any value over 255 shifts all the bits
+func shiftAmount(shift *uint256.Int) uint {
+	if !shift.IsUint64() || shift.Uint64() > 256 {
+		return 256
+	}
+	return uint(shift.Uint64())
 }
 
 func opShl(c *state) {
-	if !c.config.Constantinople {
-		c.exit(errOpCodeNotFound)
-		return
-	}
-
 	shift := c.pop()
 	value := c.top()
 
-	if equalOrOverflowsUint256(shift) {
-		value.Set(zero)
-	} else {
-		value.Lsh(value, uint(shift.Uint64()))
-		toU256(value)
-	}
+	value.Lsh(value, shiftAmount(shift))
 }
 
 func opShr(c *state) {
-	if !c.config.Constantinople {
-		c.exit(errOpCodeNotFound)
-		return
-	}
-
 	shift := c.pop()
 	value := c.top()
 
-	if equalOrOverflowsUint256(shift) {
-		value.Set(zero)
-	} else {
-		value.Rsh(value, uint(shift.Uint64()))
-		toU256(value)
-	}
+	value.Rsh(value, shiftAmount(shift))
 }
 
 func opSar(c *state) {
-	if !c.config.Constantinople {
-		c.exit(errOpCodeNotFound)
-		return
-	}
-
 	shift := c.pop()
-	value := to256(c.top())
+	value := c.top()
 
-	if equalOrOverflowsUint256(shift) {
-		if value.Sign() >= 0 {
-			value.Set(zero)
-		} else {
-			value.Set(tt256m1)
-		}
-	} else {
-		value.Rsh(value, uint(shift.Uint64()))
-		toU256(value)
-	}
+	value.SRsh(value, shiftAmount(shift))
 }
 
 // memory operations
@@ -388,56 +242,23 @@ var bufPool = sync.Pool{
 func opMload(c *state) {
 	offset := c.pop()
 
-	var ok bool
-	c.tmp, ok = c.get2(c.tmp[:0], offset, wordSize)
-	if !ok {
-		return
-	}
+	c.tmp = c.get2(c.tmp[:0], offset, wordSize)
 	c.push1().SetBytes(c.tmp)
 }
 
-var (
-	_W = bits.UintSize
-	_S = _W / 8
-)
-
 func opMStore(c *state) {
 	offset := c.pop()
 	val := c.pop()
 
-	if !c.checkMemory(offset, wordSize) {
-		return
-	}
-
 	o := offset.Uint64()
-	buf := c.memory[o : o+32]
-
-	i := 32
-
-	// convert big.int to bytes
-	// https://golang.org/src/math/big/nat.go#L1284
-	for _, d := range val.Bits() {
-		for j := 0; j < _S; j++ {
-			i--
-			buf[i] = byte(d)
-			d >>= 8
-		}
-	}
-
-	// fill the rest of the slot with zeros
-	for i > 0 {
-		i--
-		buf[i] = 0
-	}
+	b := val.Bytes32()
+	copy(c.memory[o:o+32], b[:])
 }
 
 func opMStore8(c *state) {
 	offset := c.pop()
 	val := c.pop()
 
-	if !c.checkMemory(offset, one) {
-		return
-	}
 	c.memory[offset.Uint64()] = byte(val.Uint64() & 0xff)
 }
 
@@ -446,93 +267,33 @@ func opMStore8(c *state) {
 func opSload(c *state) {
 	loc := c.top()
 
-	var gas uint64
-	if c.config.Istanbul {
-		// eip-1884
-		gas = 800
-	} else if c.config.EIP150 {
-		gas = 200
-	} else {
-		gas = 50
-	}
-	if !c.consumeGas(gas) {
-		return
-	}
-
-	val := c.host.GetStorage(c.msg.Address, bigToHash(loc))
+	val := c.host.GetStorage(c.msg.Address, wordToHash(loc))
 	loc.SetBytes(val.Bytes())
 }
 
-func opSStore(c *state) {
-	if c.inStaticCall() {
-		c.exit(errWriteProtection)
-		return
-	}
-
-	if c.config.Istanbul && c.gas <= 2300 {
-		c.exit(errOutOfGas)
-		return
-	}
-
-	key := c.popHash()
-	val := c.popHash()
-
-	legacyGasMetering := !c.config.Istanbul && (c.config.Petersburg || !c.config.Constantinople)
-
-	status := c.host.SetStorage(c.msg.Address, key, val, c.config)
-	cost := uint64(0)
-
-	switch status {
-	case runtime.StorageUnchanged:
-		if c.config.Istanbul {
-			// eip-2200
-			cost = 800
-		} else if legacyGasMetering {
-			cost = 5000
-		} else {
-			cost = 200
+// opSStore returns the SSTORE instruction with the costs of a fork
+func opSStore(costs sstoreCosts) instruction {
+	return func(c *state) {
+		if c.inStaticCall() {
+			c.exit(errWriteProtection)
+			return
 		}
 
-	case runtime.StorageModified:
-		cost = 5000
+		key := c.popHash()
+		val := c.popHash()
 
-	case runtime.StorageModifiedAgain:
-		if c.config.Istanbul {
-			// eip-2200
-			cost = 800
-		} else if legacyGasMetering {
-			cost = 5000
-		} else {
-			cost = 200
+		status := c.host.SetStorage(c.msg.Address, key, val, c.config)
+		if !c.consumeGas(costs[status]) {
+			return
 		}
-
-	case runtime.StorageAdded:
-		cost = 20000
-
-	case runtime.StorageDeleted:
-		cost = 5000
-	}
-	if !c.consumeGas(cost) {
-		return
 	}
 }
 
-const sha3WordGas uint64 = 6
-
 func opSha3(c *state) {
 	offset := c.pop()
 	length := c.pop()
 
-	var ok bool
-	if c.tmp, ok = c.get2(c.tmp[:0], offset, length); !ok {
-		return
-	}
-
-	size := length.Uint64()
-	if !c.consumeGas(((size + 31) / 32) * sha3WordGas) {
-		return
-	}
-
+	c.tmp = c.get2(c.tmp[:0], offset, length)
 	c.tmp = helper.Keccak256To(c.tmp[:0], c.tmp)
 
 	v := c.push1()
@@ -552,38 +313,14 @@ func opAddress(c *state) {
 func opBalance(c *state) {
 	addr, _ := c.popAddr()
 
-	var gas uint64
-	if c.config.Istanbul {
-		// eip-1884
-		gas = 700
-	} else if c.config.EIP150 {
-		gas = 400
-	} else {
-		gas = 20
-	}
-
-	if !c.consumeGas(gas) {
-		return
-	}
-
-	c.push1().Set(c.host.GetBalance(addr))
+	c.push1().SetFromBig(c.host.GetBalance(addr))
 }
 
 func opSelfBalance(c *state) {
-	if !c.config.Istanbul {
-		c.exit(errOpCodeNotFound)
-		return
-	}
-
-	c.push1().Set(c.host.GetBalance(c.msg.Address))
+	c.push1().SetFromBig(c.host.GetBalance(c.msg.Address))
 }
 
 func opChainID(c *state) {
-	if !c.config.Istanbul {
-		c.exit(errOpCodeNotFound)
-		return
-	}
-
 	c.push1().SetUint64(uint64(c.host.GetTxContext().ChainID))
 }
 
@@ -598,9 +335,9 @@ func opCaller(c *state) {
 func opCallValue(c *state) {
 	v := c.push1()
 	if value := c.msg.Value; value != nil {
-		v.Set(value)
+		v.SetFromBig(value)
 	} else {
-		v.Set(zero)
+		v.Clear()
 	}
 }
 
@@ -632,16 +369,6 @@ func opCodeSize(c *state) {
 func opExtCodeSize(c *state) {
 	addr, _ := c.popAddr()
 
-	var gas uint64
-	if c.config.EIP150 {
-		gas = 700
-	} else {
-		gas = 20
-	}
-	if !c.consumeGas(gas) {
-		return
-	}
-
 	c.push1().SetUint64(uint64(c.host.GetCodeSize(addr)))
 }
 
@@ -650,34 +377,15 @@ func opGasPrice(c *state) {
 }
 
 func opReturnDataSize(c *state) {
-	if !c.config.Byzantium {
-		c.exit(errOpCodeNotFound)
-	} else {
-		c.push1().SetUint64(uint64(len(c.returnData)))
-	}
+	c.push1().SetUint64(uint64(len(c.returnData)))
 }
 
 func opExtCodeHash(c *state) {
-	if !c.config.Constantinople {
-		c.exit(errOpCodeNotFound)
-		return
-	}
-
 	address, _ := c.popAddr()
 
-	var gas uint64
-	if c.config.Istanbul {
-		gas = 700
-	} else {
-		gas = 400
-	}
-	if !c.consumeGas(gas) {
-		return
-	}
-
 	v := c.push1()
 	if c.host.Empty(address) {
-		v.Set(zero)
+		v.Clear()
 	} else {
 		v.SetBytes(c.host.GetCodeHash(address).Bytes())
 	}
@@ -695,7 +403,7 @@ func opGas(c *state) {
 	c.push1().SetUint64(c.gas)
 }
 
-func (c *state) setBytes(dst, input []byte, size uint64, dataOffset *big.Int) {
+func (c *state) setBytes(dst, input []byte, size uint64, dataOffset *uint256.Int) {
 	if !dataOffset.IsUint64() {
 		// overflow, copy 'size' 0 bytes to dst
 		for i := uint64(0); i < size; i++ {
@@ -719,33 +427,13 @@ func (c *state) setBytes(dst, input []byte, size uint64, dataOffset *big.Int) {
 	}
 }
 
-const copyGas uint64 = 3
-
 func opExtCodeCopy(c *state) {
 	address, _ := c.popAddr()
 	memOffset := c.pop()
 	codeOffset := c.pop()
 	length := c.pop()
 
-	if !c.checkMemory(memOffset, length) {
-		return
-	}
-
 	size := length.Uint64()
-	if !c.consumeGas(((size + 31) / 32) * copyGas) {
-		return
-	}
-
-	var gas uint64
-	if c.config.EIP150 {
-		gas = 700
-	} else {
-		gas = 20
-	}
-	if !c.consumeGas(gas) {
-		return
-	}
-
 	code := c.host.GetCode(address)
 	if size != 0 {
 		c.setBytes(c.memory[memOffset.Uint64():], code, size, codeOffset)
@@ -757,52 +445,31 @@ func opCallDataCopy(c *state) {
 	dataOffset := c.pop()
 	length := c.pop()
 
-	if !c.checkMemory(memOffset, length) {
-		return
-	}
-
 	size := length.Uint64()
-	if !c.consumeGas(((size + 31) / 32) * copyGas) {
-		return
-	}
-
 	if size != 0 {
 		c.setBytes(c.memory[memOffset.Uint64():], c.msg.Input, size, dataOffset)
 	}
 }
 
 func opReturnDataCopy(c *state) {
-	if !c.config.Byzantium {
-		c.exit(errOpCodeNotFound)
-		return
-	}
-
 	memOffset := c.pop()
 	dataOffset := c.pop()
 	length := c.pop()
 
-	if !c.checkMemory(memOffset, length) {
-		return
-	}
-
-	size := length.Uint64()
-	if !c.consumeGas(((size + 31) / 32) * copyGas) {
-		return
-	}
-
-	end := length.Add(dataOffset, length)
-	if !end.IsUint64() {
+	if !dataOffset.IsUint64() || !length.IsUint64() {
 		c.exit(errReturnDataOutOfBounds)
 		return
 	}
-	size = end.Uint64()
-	if uint64(len(c.returnData)) < size {
+	// the end of the data must not wrap around
+	end, carry := bits.Add64(dataOffset.Uint64(), length.Uint64(), 0)
+	if carry != 0 || uint64(len(c.returnData)) < end {
 		c.exit(errReturnDataOutOfBounds)
 		return
 	}
 
-	data := c.returnData[dataOffset.Uint64():size]
-	copy(c.memory[memOffset.Uint64():], data)
+	if end != dataOffset.Uint64() {
+		copy(c.memory[memOffset.Uint64():], c.returnData[dataOffset.Uint64():end])
+	}
 }
 
 func opCodeCopy(c *state) {
@@ -810,14 +477,7 @@ func opCodeCopy(c *state) {
 	dataOffset := c.pop()
 	length := c.pop()
 
-	if !c.checkMemory(memOffset, length) {
-		return
-	}
-
 	size := length.Uint64()
-	if !c.consumeGas(((size + 31) / 32) * copyGas) {
-		return
-	}
 	if size != 0 {
 		c.setBytes(c.memory[memOffset.Uint64():], c.code, size, dataOffset)
 	}
@@ -828,18 +488,18 @@ func opCodeCopy(c *state) {
 func opBlockHash(c *state) {
 	num := c.top()
 
-	if !num.IsInt64() {
-		num.Set(zero)
+	if !num.IsUint64() || num.Uint64() > math.MaxInt64 {
+		num.Clear()
 		return
 	}
 
-	n := num.Int64()
+	n := int64(num.Uint64())
 	lastBlock := c.host.GetTxContext().Number
 
 	if lastBlock-257 < n && n < lastBlock {
 		num.SetBytes(c.host.GetBlockHash(n).Bytes())
 	} else {
-		num.Set(zero)
+		num.Clear()
 	}
 }
 
@@ -848,11 +508,11 @@ func opCoinbase(c *state) {
 }
 
 func opTimestamp(c *state) {
-	c.push1().SetInt64(c.host.GetTxContext().Timestamp)
+	c.push1().SetUint64(uint64(c.host.GetTxContext().Timestamp))
 }
 
 func opNumber(c *state) {
-	c.push1().SetInt64(c.host.GetTxContext().Number)
+	c.push1().SetUint64(uint64(c.host.GetTxContext().Number))
 }
 
 func opDifficulty(c *state) {
@@ -860,7 +520,7 @@ func opDifficulty(c *state) {
 }
 
 func opGasLimit(c *state) {
-	c.push1().SetInt64(c.host.GetTxContext().GasLimit)
+	c.push1().SetUint64(uint64(c.host.GetTxContext().GasLimit))
 }
 
 func opSelfDestruct(c *state) {
@@ -871,26 +531,6 @@ func opSelfDestruct(c *state) {
 
 	address, _ := c.popAddr()
 
-	// try to remove the gas first
-	var gas uint64
-
-	// EIP150 reprice fork
-	if c.config.EIP150 {
-		gas = 5000
-		if c.config.EIP158 {
-			// if empty and transfers value
-			if c.host.Empty(address) && c.host.GetBalance(c.msg.Address).Sign() != 0 {
-				gas += 25000
-			}
-		} else if !c.host.AccountExists(address) {
-			gas += 25000
-		}
-	}
-
-	if !c.consumeGas(gas) {
-		return
-	}
-
 	c.host.Selfdestruct(c.msg.Address, address)
 	c.halt()
 }
@@ -909,7 +549,7 @@ func opJumpi(c *state) {
 	dest := c.pop()
 	cond := c.pop()
 
-	if cond.Sign() != 0 {
+	if !cond.IsZero() {
 		if c.validJumpdest(dest) {
 			c.ip = int(dest.Uint64() - 1)
 		} else {
@@ -923,76 +563,53 @@ func opJumpDest(c *state) {
 
 func opPush(n int) instruction {
 	return func(c *state) {
-		ins := c.code
-		ip := c.ip
-
-		v := c.push1()
-		if ip+1+n > len(ins) {
-			v.SetBytes(append(ins[ip+1:], make([]byte, n)...))
-		} else {
-			v.SetBytes(ins[ip+1 : ip+1+n])
-		}
-
+		pushValue(c.push1(), c.code, c.ip, n)
 		c.ip += n
 	}
 }
 
+// pushValue sets v to the value of the push of n bytes at pc, the
+// value is padded with zeros if the code ends before its last byte
+func pushValue(v *uint256.Int, code []byte, pc, n int) {
+	if pc+1+n > len(code) {
+		buf := make([]byte, n)
+		copy(buf, code[pc+1:])
+		v.SetBytes(buf)
+	} else {
+		v.SetBytes(code[pc+1 : pc+1+n])
+	}
+}
+
 func opDup(n int) instruction {
 	return func(c *state) {
-		if !c.stackAtLeast(n) {
-			c.exit(errStackUnderflow)
-		} else {
-			val := c.peekAt(n)
-			c.push1().Set(val)
-		}
+		val := c.peekAt(n)
+		c.push1().Set(val)
 	}
 }
 
 func opSwap(n int) instruction {
 	return func(c *state) {
-		if !c.stackAtLeast(n + 1) {
-			c.exit(errStackUnderflow)
-		} else {
-			c.swap(n)
-		}
+		c.swap(n)
 	}
 }
 
 func opLog(size int) instruction {
-	size = size - 1
 	return func(c *state) {
 		if c.inStaticCall() {
 			c.exit(errWriteProtection)
 			return
 		}
 
-		if !c.stackAtLeast(2 + size) {
-			c.exit(errStackUnderflow)
-			return
-		}
-
 		mStart := c.pop()
 		mSize := c.pop()
 
 		topics := make([]types.Hash, size)
 		for i := 0; i < size; i++ {
-			topics[i] = bigToHash(c.pop())
-		}
-
-		var ok bool
-		c.tmp, ok = c.get2(c.tmp[:0], mStart, mSize)
-		if !ok {
-			return
+			topics[i] = c.popHash()
 		}
 
+		c.tmp = c.get2(c.tmp[:0], mStart, mSize)
 		c.host.EmitLog(c.msg.Address, topics, c.tmp)
-
-		if !c.consumeGas(uint64(size) * 375) {
-			return
-		}
-		if !c.consumeGas(mSize.Uint64() * 8) {
-			return
-		}
 	}
 }
 
@@ -1000,28 +617,25 @@ func opStop(c *state) {
 	c.halt()
 }
 
-func opCreate(op OpCode) instruction {
+// opCreate returns the CREATE or CREATE2 instruction. If codeStoreFails is set
+// the creation fails when there is no gas to store the code (homestead), and if
+// allButOne64th is set the new frame gets all but one 64th of the gas (eip-150).
+func opCreate(op OpCode, codeStoreFails, allButOne64th bool) instruction {
 	return func(c *state) {
 		if c.inStaticCall() {
 			c.exit(errWriteProtection)
 			return
 		}
 
-		if op == CREATE2 {
-			if !c.config.Constantinople {
-				c.exit(errOpCodeNotFound)
-				return
-			}
-		}
-
 		// reset the return data
 		c.resetReturnData()
 
-		contract, err := c.buildCreateContract(op)
+		contract, err := c.buildCreateContract(op, allButOne64th)
 		if err != nil {
-			c.push1().Set(zero)
+			c.push1().Clear()
 			if contract != nil {
 				c.gas += contract.Gas
+				c.callGasLeft = contract.Gas
 			}
 			return
 		}
@@ -1029,21 +643,26 @@ func opCreate(op OpCode) instruction {
 			return
 		}
 
-		contract.Type = runtime.Create
+		if op == CREATE2 {
+			contract.Type = runtime.Create2
+		} else {
+			contract.Type = runtime.Create
+		}
 
 		// Correct call
 		result := c.host.Callx(contract, c.host)
 
 		v := c.push1()
-		if op == CREATE && c.config.Homestead && result.Err == runtime.ErrCodeStoreOutOfGas {
-			v.Set(zero)
+		if op == CREATE && codeStoreFails && result.Err == runtime.ErrCodeStoreOutOfGas {
+			v.Clear()
 		} else if result.Failed() && result.Err != runtime.ErrCodeStoreOutOfGas {
-			v.Set(zero)
+			v.Clear()
 		} else {
 			v.SetBytes(contract.Address.Bytes())
 		}
 
 		c.gas += result.GasLeft
+		c.callGasLeft = result.GasLeft
 
 		if result.Reverted() {
 			c.returnData = append(c.returnData[:0], result.ReturnValue...)
@@ -1056,21 +675,12 @@ func opCall(op OpCode) instruction {
 		c.resetReturnData()
 
 		if op == CALL && c.inStaticCall() {
-			if val := c.peekAt(3); val != nil && val.BitLen() > 0 {
+			if !c.peekAt(3).IsZero() {
 				c.exit(errWriteProtection)
 				return
 			}
 		}
 
-		if op == DELEGATECALL && !c.config.Homestead {
-			c.exit(errOpCodeNotFound)
-			return
-		}
-		if op == STATICCALL && !c.config.Byzantium {
-			c.exit(errOpCodeNotFound)
-			return
-		}
-
 		var callType runtime.CallType
 		switch op {
 		case CALL:
@@ -1091,9 +701,10 @@ func opCall(op OpCode) instruction {
 
 		contract, offset, size, err := c.buildCallContract(op)
 		if err != nil {
-			c.push1().Set(zero)
+			c.push1().Clear()
 			if contract != nil {
 				c.gas += contract.Gas
+				c.callGasLeft = contract.Gas
 			}
 			return
 		}
@@ -1107,9 +718,9 @@ func opCall(op OpCode) instruction {
 
 		v := c.push1()
 		if result.Succeeded() {
-			v.Set(one)
+			v.SetOne()
 		} else {
-			v.Set(zero)
+			v.Clear()
 		}
 
 		if result.Succeeded() || result.Reverted() {
@@ -1119,18 +730,19 @@ func opCall(op OpCode) instruction {
 		}
 
 		c.gas += result.GasLeft
+		c.callGasLeft = result.GasLeft
 		c.returnData = append(c.returnData[:0], result.ReturnValue...)
 	}
 }
 
 func (c *state) buildCallContract(op OpCode) (*runtime.Contract, uint64, uint64, error) {
 	// Pop input arguments
-	initialGas := c.pop()
+	c.pop()
 	addr, _ := c.popAddr()
 
 	var value *big.Int
 	if op == CALL || op == CALLCODE {
-		value = c.pop()
+		value = c.pop().ToBig()
 	}
 
 	// input range
@@ -1142,71 +754,23 @@ func (c *state) buildCallContract(op OpCode) (*runtime.Contract, uint64, uint64,
 	retSize := c.pop()
 
 	// Get the input arguments
-	args, ok := c.get2(nil, inOffset, inSize)
-	if !ok {
-		return nil, 0, 0, nil
-	}
-	// Check if the memory return offsets are out of bounds
-	if !c.checkMemory(retOffset, retSize) {
-		return nil, 0, 0, nil
-	}
-
-	var gasCost uint64
-	if c.config.EIP150 {
-		gasCost = 700
-	} else {
-		gasCost = 40
-	}
+	args := c.get2(nil, inOffset, inSize)
 
-	eip158 := c.config.EIP158
 	transfersValue := (op == CALL || op == CALLCODE) && value != nil && value.Sign() != 0
 
-	if op == CALL {
-		if eip158 {
-			if transfersValue && c.host.Empty(addr) {
-				gasCost += 25000
-			}
-		} else if !c.host.AccountExists(addr) {
-			gasCost += 25000
-		}
-	}
+	// the gas of the new frame is consumed by the dynamic gas of the call
+	gas := c.callGas
 	if transfersValue {
-		gasCost += 9000
-	}
-
-	var gas uint64
-
-	ok = initialGas.IsUint64()
-	if c.config.EIP150 {
-		availableGas := c.gas - gasCost
-		availableGas = availableGas - availableGas/64
-
-		if !ok || availableGas < initialGas.Uint64() {
-			gas = availableGas
-		} else {
-			gas = initialGas.Uint64()
-		}
-	} else {
-		if !ok {
-			c.exit(errOutOfGas)
-			return nil, 0, 0, nil
-		}
-		gas = initialGas.Uint64()
-	}
-
-	gasCost = gasCost + gas
-
-	// Consume gas cost
-	if !c.consumeGas(gasCost) {
-		return nil, 0, 0, nil
-	}
-	if transfersValue {
-		gas += 2300
+		gas += runtime.CallStipend
 	}
 
 	parent := c
 
-	contract := runtime.NewContractCall(c.msg.Depth+1, parent.msg.Origin, parent.msg.Address, addr, value, gas, c.host.GetCode(addr), args)
+	codeHash, code := c.evm.codeCache.GetCode(c.host, addr)
+
+	contract := runtime.NewContractCall(c.msg.Depth+1, parent.msg.Origin, parent.msg.Address, addr, value, gas, code, args)
+	contract.CodeHash = codeHash
+	contract.Tracer = parent.msg.Tracer
 
 	if op == STATICCALL || parent.msg.Static {
 		contract.Static = true
@@ -1227,38 +791,21 @@ func (c *state) buildCallContract(op OpCode) (*runtime.Contract, uint64, uint64,
 	return contract, retOffset.Uint64(), retSize.Uint64(), nil
 }
 
-func (c *state) buildCreateContract(op OpCode) (*runtime.Contract, error) {
+func (c *state) buildCreateContract(op OpCode, allButOne64th bool) (*runtime.Contract, error) {
 	// Pop input arguments
-	value := c.pop()
+	value := c.pop().ToBig()
 	offset := c.pop()
 	length := c.pop()
 
-	var salt *big.Int
+	var salt types.Hash
 	if op == CREATE2 {
-		salt = c.pop()
+		salt = c.popHash()
 	}
 
 	// check if the value can be transfered
-	hasTransfer := value != nil && value.Sign() != 0
-
-	// Calculate and consume gas cost
+	hasTransfer := value.Sign() != 0
 
-	// var overflow bool
-	var gasCost uint64
-
-	// Both CREATE and CREATE2 use memory
-	var input []byte
-	var ok bool
-
-	input, ok = c.get2(input[:0], offset, length) // Does the memory check
-	if !ok {
-		return nil, nil
-	}
-
-	// Consume memory resize gas (TODO, change with get2)
-	if !c.consumeGas(gasCost) {
-		return nil, nil
-	}
+	input := c.get2(nil, offset, length)
 
 	if hasTransfer {
 		if c.host.GetBalance(c.msg.Address).Cmp(value) < 0 {
@@ -1266,19 +813,9 @@ func (c *state) buildCreateContract(op OpCode) (*runtime.Contract, error) {
 		}
 	}
 
-	if op == CREATE2 {
-		// Consume sha3 gas cost
-		size := length.Uint64()
-		if !c.consumeGas(((size + 31) / 32) * sha3WordGas) {
-			return nil, nil
-		}
-	}
-
 	// Calculate and consume gas for the call
 	gas := c.gas
-
-	// CREATE2 uses by default EIP150
-	if c.config.EIP150 || op == CREATE2 {
+	if allButOne64th {
 		gas -= gas / 64
 	}
 
@@ -1291,27 +828,19 @@ func (c *state) buildCreateContract(op OpCode) (*runtime.Contract, error) {
 	if op == CREATE {
 		address = helper.CreateAddress(c.msg.Address, c.host.GetNonce(c.msg.Address))
 	} else {
-		address = helper.CreateAddress2(c.msg.Address, bigToHash(salt), input)
+		address = helper.CreateAddress2(c.msg.Address, salt, input)
 	}
 	contract := runtime.NewContractCreation(c.msg.Depth+1, c.msg.Origin, c.msg.Address, address, value, gas, input)
+	contract.Tracer = c.msg.Tracer
 	return contract, nil
 }
 
 func opHalt(op OpCode) instruction {
 	return func(c *state) {
-		if op == REVERT && !c.config.Byzantium {
-			c.exit(errOpCodeNotFound)
-			return
-		}
-
 		offset := c.pop()
 		size := c.pop()
 
-		var ok bool
-		c.ret, ok = c.get2(c.ret[:0], offset, size)
-		if !ok {
-			return
-		}
+		c.ret = c.get2(c.ret[:0], offset, size)
 
 		if op == REVERT {
 			c.exit(errRevert)
@@ -1320,22 +849,3 @@ func opHalt(op OpCode) instruction {
 		}
 	}
 }
-
-var (
-	tt256   = new(big.Int).Lsh(big.NewInt(1), 256)   // 2 ** 256
-	tt256m1 = new(big.Int).Sub(tt256, big.NewInt(1)) // 2 ** 256 - 1
-)
-
-func toU256(x *big.Int) *big.Int {
-	if x.Sign() < 0 || x.BitLen() > 256 {
-		x.And(x, tt256m1)
-	}
-	return x
-}
-
-func to256(x *big.Int) *big.Int {
-	if x.BitLen() > 255 {
-		x.Sub(x, tt256)
-	}
-	return x
-}
@@ -1,23 +1,24 @@
 package evm
 
 import (
-	"math/big"
 	"testing"
 
 	"github.com/0xPolygon/eth-state-transition/helper"
 	"github.com/0xPolygon/eth-state-transition/runtime"
+	"github.com/0xPolygon/eth-state-transition/runtime/evm/uint256"
 	"github.com/0xPolygon/eth-state-transition/types"
 	"github.com/stretchr/testify/assert"
 )
 
 var (
-	two = big.NewInt(2)
+	zero = uint256.NewInt(0)
+	two  = uint256.NewInt(2)
 )
 
 type cases2To1 []struct {
-	a *big.Int
-	b *big.Int
-	c *big.Int
+	a *uint256.Int
+	b *uint256.Int
+	c *uint256.Int
 }
 
 func test2to1(t *testing.T, f instruction, tests cases2To1) {
@@ -35,8 +36,8 @@ func test2to1(t *testing.T, f instruction, tests cases2To1) {
 }
 
 type cases2ToBool []struct {
-	a *big.Int
-	b *big.Int
+	a *uint256.Int
+	b *uint256.Int
 	c bool
 }
 
@@ -85,11 +86,15 @@ func TestMStore(t *testing.T) {
 	s, close := getState()
 	defer close()
 
-	s.push(big.NewInt(10))   // value
-	s.push(big.NewInt(1024)) // offset
+	s.push(uint256.NewInt(10))   // value
+	s.push(uint256.NewInt(1024)) // offset
 
 	s.gas = 1000
-	opMStore(s)
+
+	// the memory is expanded before the instruction runs
+	op := frontierInstructionSet[MSTORE]
+	assert.True(t, s.prepare(op))
+	op.execute(s)
 
 	assert.Len(t, s.memory, 1024+32)
 }
@@ -116,14 +121,14 @@ func TestCreate(t *testing.T) {
 	type state struct {
 		gas    uint64
 		sp     int
-		stack  []*big.Int
+		stack  []uint256.Int
 		memory []byte
 		stop   bool
 		err    error
 	}
 
-	addressToBigInt := func(addr types.Address) *big.Int {
-		return new(big.Int).SetBytes(addr[:])
+	addressToWord := func(addr types.Address) uint256.Int {
+		return *new(uint256.Int).SetBytes(addr[:])
 	}
 
 	tests := []struct {
@@ -146,10 +151,10 @@ func TestCreate(t *testing.T) {
 			initState: &state{
 				gas: 1000,
 				sp:  3,
-				stack: []*big.Int{
-					big.NewInt(0x01), // length
-					big.NewInt(0x00), // offset
-					big.NewInt(0x00), // value
+				stack: []uint256.Int{
+					*uint256.NewInt(0x01), // length
+					*uint256.NewInt(0x00), // offset
+					*uint256.NewInt(0x00), // value
 				},
 				memory: []byte{
 					byte(REVERT),
@@ -158,10 +163,10 @@ func TestCreate(t *testing.T) {
 			resultState: &state{
 				gas: 500,
 				sp:  1,
-				stack: []*big.Int{
-					addressToBigInt(helper.CreateAddress(addr1, 0)), // contract address
-					big.NewInt(0x00),
-					big.NewInt(0x00),
+				stack: []uint256.Int{
+					addressToWord(helper.CreateAddress(addr1, 0)), // contract address
+					*uint256.NewInt(0x00),
+					*uint256.NewInt(0x00),
 				},
 				memory: []byte{
 					byte(REVERT),
@@ -185,10 +190,10 @@ func TestCreate(t *testing.T) {
 			initState: &state{
 				gas: 1000,
 				sp:  3,
-				stack: []*big.Int{
-					big.NewInt(0x01), // length
-					big.NewInt(0x00), // offset
-					big.NewInt(0x00), // value
+				stack: []uint256.Int{
+					*uint256.NewInt(0x01), // length
+					*uint256.NewInt(0x00), // offset
+					*uint256.NewInt(0x00), // value
 				},
 				memory: []byte{
 					byte(REVERT),
@@ -200,10 +205,10 @@ func TestCreate(t *testing.T) {
 			resultState: &state{
 				gas: 1000,
 				sp:  3,
-				stack: []*big.Int{
-					big.NewInt(0x01), // length
-					big.NewInt(0x00), // offset
-					big.NewInt(0x00), // value
+				stack: []uint256.Int{
+					*uint256.NewInt(0x01), // length
+					*uint256.NewInt(0x00), // offset
+					*uint256.NewInt(0x00), // value
 				},
 				memory: []byte{
 					byte(REVERT),
@@ -213,44 +218,6 @@ func TestCreate(t *testing.T) {
 			},
 			mockHost: &mockHostForCreate{},
 		},
-		{
-			name:     "should throw errOpCodeNotFound when op is CREATE2 and config.Constantinople is disabled",
-			op:       CREATE2,
-			contract: &runtime.Contract{},
-			config: &runtime.ForksInTime{
-				Constantinople: false,
-			},
-			initState: &state{
-				gas: 1000,
-				sp:  3,
-				stack: []*big.Int{
-					big.NewInt(0x01), // length
-					big.NewInt(0x00), // offset
-					big.NewInt(0x00), // value
-				},
-				memory: []byte{
-					byte(REVERT),
-				},
-				stop: false,
-				err:  nil,
-			},
-			// shouldn't change any states except for stop and err
-			resultState: &state{
-				gas: 1000,
-				sp:  3,
-				stack: []*big.Int{
-					big.NewInt(0x01), // length
-					big.NewInt(0x00), // offset
-					big.NewInt(0x00), // value
-				},
-				memory: []byte{
-					byte(REVERT),
-				},
-				stop: true,
-				err:  errOpCodeNotFound,
-			},
-			mockHost: &mockHostForCreate{},
-		},
 		{
 			name: "should set zero address if op is CREATE and contract call throws ErrCodeStoreOutOfGas",
 			op:   CREATE,
@@ -264,10 +231,10 @@ func TestCreate(t *testing.T) {
 			initState: &state{
 				gas: 1000,
 				sp:  3,
-				stack: []*big.Int{
-					big.NewInt(0x01), // length
-					big.NewInt(0x00), // offset
-					big.NewInt(0x00), // value
+				stack: []uint256.Int{
+					*uint256.NewInt(0x01), // length
+					*uint256.NewInt(0x00), // offset
+					*uint256.NewInt(0x00), // value
 				},
 				memory: []byte{
 					byte(REVERT),
@@ -279,11 +246,10 @@ func TestCreate(t *testing.T) {
 			resultState: &state{
 				gas: 1000,
 				sp:  1,
-				stack: []*big.Int{
-					// need to init with 0x01 to add abs field in big.Int
-					big.NewInt(0x01).SetInt64(0x00),
-					big.NewInt(0x00),
-					big.NewInt(0x00),
+				stack: []uint256.Int{
+					*uint256.NewInt(0x00),
+					*uint256.NewInt(0x00),
+					*uint256.NewInt(0x00),
 				},
 				memory: []byte{
 					byte(REVERT),
@@ -312,10 +278,10 @@ func TestCreate(t *testing.T) {
 			initState: &state{
 				gas: 1000,
 				sp:  3,
-				stack: []*big.Int{
-					big.NewInt(0x01), // length
-					big.NewInt(0x00), // offset
-					big.NewInt(0x00), // value
+				stack: []uint256.Int{
+					*uint256.NewInt(0x01), // length
+					*uint256.NewInt(0x00), // offset
+					*uint256.NewInt(0x00), // value
 				},
 				memory: []byte{
 					byte(REVERT),
@@ -327,11 +293,10 @@ func TestCreate(t *testing.T) {
 			resultState: &state{
 				gas: 1000,
 				sp:  1,
-				stack: []*big.Int{
-					// need to init with 0x01 to add abs field in big.Int
-					big.NewInt(0x01).SetInt64(0x00),
-					big.NewInt(0x00),
-					big.NewInt(0x00),
+				stack: []uint256.Int{
+					*uint256.NewInt(0x00),
+					*uint256.NewInt(0x00),
+					*uint256.NewInt(0x00),
 				},
 				memory: []byte{
 					byte(REVERT),
@@ -362,7 +327,7 @@ func TestCreate(t *testing.T) {
 			s.config = tt.config
 			s.host = tt.mockHost
 
-			opCreate(tt.op)(s)
+			jumpTableFor(tt.config)[tt.op].execute(s)
 
 			assert.Equal(t, tt.resultState.gas, s.gas, "gas in state after execution is not correct")
 			assert.Equal(t, tt.resultState.sp, s.sp, "sp in state after execution is not correct")
@@ -373,3 +338,41 @@ func TestCreate(t *testing.T) {
 		})
 	}
 }
+
+func TestReturnDataCopy(t *testing.T) {
+	maxUint := new(uint256.Int).SetAllOne()
+
+	cases := []struct {
+		offset *uint256.Int
+		length *uint256.Int
+		err    error
+	}{
+		{uint256.NewInt(0), uint256.NewInt(4), nil},
+		{uint256.NewInt(2), uint256.NewInt(2), nil},
+		{uint256.NewInt(4), uint256.NewInt(0), nil},
+		{uint256.NewInt(3), uint256.NewInt(2), errReturnDataOutOfBounds},
+		// the end of the data wraps around
+		{maxUint, uint256.NewInt(1), errReturnDataOutOfBounds},
+		{uint256.NewInt(1), maxUint, errReturnDataOutOfBounds},
+		{new(uint256.Int).Lsh(uint256.NewInt(1), 64), uint256.NewInt(0), errReturnDataOutOfBounds},
+	}
+
+	for _, c := range cases {
+		s, close := getState()
+		s.returnData = []byte{1, 2, 3, 4}
+		s.memory = make([]byte, 32)
+
+		s.push(c.length)
+		s.push(c.offset)
+		s.push(uint256.NewInt(0)) // memory offset
+
+		opReturnDataCopy(s)
+		assert.Equal(t, c.err, s.err, "offset %s length %s", c.offset, c.length)
+
+		if c.err == nil {
+			offset, length := c.offset.Uint64(), c.length.Uint64()
+			assert.Equal(t, s.returnData[offset:offset+length], s.memory[:length])
+		}
+		close()
+	}
+}
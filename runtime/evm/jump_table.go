@@ -0,0 +1,391 @@
+package evm
+
+import (
+	"github.com/0xPolygon/eth-state-transition/runtime"
+)
+
+// dynamicGasFunc returns the gas of an operation that depends on its arguments
+// or on the state. It is called with the memory already expanded.
+type dynamicGasFunc func(c *state) (uint64, error)
+
+// memorySizeFunc returns the size of the memory used by an operation,
+// false if the size overflows
+type memorySizeFunc func(c *state) (uint64, bool)
+
+// operation is an entry of the jump table
+type operation struct {
+	execute instruction
+
+	constantGas uint64
+	dynamicGas  dynamicGasFunc
+
+	// minStack is the items required in the stack and maxStack the
+	// items allowed in the stack to not overflow it
+	minStack int
+	maxStack int
+
+	memorySize memorySizeFunc
+}
+
+// jumpTable are the operations of a fork, nil if the opcode is not valid
+type jumpTable [256]*operation
+
+// newOperation creates an operation that pops and pushes a number of items of the stack
+func newOperation(inst instruction, gas uint64, pops, pushes int) *operation {
+	return &operation{
+		execute:     inst,
+		constantGas: gas,
+		minStack:    pops,
+		maxStack:    stackSize + pops - pushes,
+	}
+}
+
+// copy returns a copy of the table to derive the table of the next fork
+func (t *jumpTable) copy() *jumpTable {
+	res := &jumpTable{}
+	for i, op := range t {
+		if op != nil {
+			o := *op
+			res[i] = &o
+		}
+	}
+	return res
+}
+
+// jump tables of each fork, built from the one of the previous fork
+var (
+	frontierInstructionSet       = newFrontierInstructionSet()
+	homesteadInstructionSet      = newHomesteadInstructionSet()
+	eip150InstructionSet         = newEIP150InstructionSet()
+	eip158InstructionSet         = newEIP158InstructionSet()
+	byzantiumInstructionSet      = newByzantiumInstructionSet()
+	constantinopleInstructionSet = newConstantinopleInstructionSet()
+	petersburgInstructionSet     = newPetersburgInstructionSet()
+	istanbulInstructionSet       = newIstanbulInstructionSet()
+	berlinInstructionSet         = newBerlinInstructionSet()
+)
+
+// jumpTableFor returns the jump table of the latest fork enabled
+func jumpTableFor(config *runtime.ForksInTime) *jumpTable {
+	switch {
+	case config.Berlin:
+		return berlinInstructionSet
+	case config.Istanbul:
+		return istanbulInstructionSet
+	case config.Petersburg:
+		return petersburgInstructionSet
+	case config.Constantinople:
+		return constantinopleInstructionSet
+	case config.Byzantium:
+		return byzantiumInstructionSet
+	case config.EIP158:
+		return eip158InstructionSet
+	case config.EIP150:
+		return eip150InstructionSet
+	case config.Homestead:
+		return homesteadInstructionSet
+	default:
+		return frontierInstructionSet
+	}
+}
+
+// legacySStoreCosts are the costs of SSTORE before eip-1283
+var legacySStoreCosts = sstoreCosts{
+	runtime.StorageUnchanged:     5000,
+	runtime.StorageModified:      5000,
+	runtime.StorageModifiedAgain: 5000,
+	runtime.StorageAdded:         20000,
+	runtime.StorageDeleted:       5000,
+}
+
+func newFrontierInstructionSet() *jumpTable {
+	t := &jumpTable{}
+
+	// unsigned arithmetic operations
+	t[STOP] = newOperation(opStop, 0, 0, 0)
+	t[ADD] = newOperation(opAdd, 3, 2, 1)
+	t[SUB] = newOperation(opSub, 3, 2, 1)
+	t[MUL] = newOperation(opMul, 5, 2, 1)
+	t[DIV] = newOperation(opDiv, 5, 2, 1)
+	t[SDIV] = newOperation(opSDiv, 5, 2, 1)
+	t[MOD] = newOperation(opMod, 5, 2, 1)
+	t[SMOD] = newOperation(opSMod, 5, 2, 1)
+	t[EXP] = newOperation(opExp, 10, 2, 1)
+	t[EXP].dynamicGas = gasExp(10)
+
+	for i := 1; i <= 32; i++ {
+		t[PUSH1+OpCode(i-1)] = newOperation(opPush(i), 3, 0, 1)
+	}
+	for i := 1; i <= 16; i++ {
+		t[DUP1+OpCode(i-1)] = newOperation(opDup(i), 3, i, i+1)
+		t[SWAP1+OpCode(i-1)] = newOperation(opSwap(i), 3, i+1, i+1)
+	}
+	for i := 0; i <= 4; i++ {
+		op := newOperation(opLog(i), 375, i+2, 0)
+		op.dynamicGas = gasLog(i)
+		op.memorySize = memoryLog
+		t[LOG0+OpCode(i)] = op
+	}
+
+	t[ADDMOD] = newOperation(opAddMod, 8, 3, 1)
+	t[MULMOD] = newOperation(opMulMod, 8, 3, 1)
+
+	t[AND] = newOperation(opAnd, 3, 2, 1)
+	t[OR] = newOperation(opOr, 3, 2, 1)
+	t[XOR] = newOperation(opXor, 3, 2, 1)
+	t[BYTE] = newOperation(opByte, 3, 2, 1)
+
+	t[NOT] = newOperation(opNot, 3, 1, 1)
+	t[ISZERO] = newOperation(opIsZero, 3, 1, 1)
+
+	t[EQ] = newOperation(opEq, 3, 2, 1)
+	t[LT] = newOperation(opLt, 3, 2, 1)
+	t[GT] = newOperation(opGt, 3, 2, 1)
+	t[SLT] = newOperation(opSlt, 3, 2, 1)
+	t[SGT] = newOperation(opSgt, 3, 2, 1)
+
+	t[SIGNEXTEND] = newOperation(opSignExtension, 5, 2, 1)
+
+	t[CREATE] = newOperation(opCreate(CREATE, false, false), 32000, 3, 1)
+	t[CREATE].memorySize = memoryCreate
+
+	t[CALL] = newOperation(opCall(CALL), 40, 7, 1)
+	t[CALL].dynamicGas = gasCall(CALL, false, false)
+	t[CALL].memorySize = memoryCall(CALL)
+
+	t[CALLCODE] = newOperation(opCall(CALLCODE), 40, 7, 1)
+	t[CALLCODE].dynamicGas = gasCall(CALLCODE, false, false)
+	t[CALLCODE].memorySize = memoryCall(CALLCODE)
+
+	t[RETURN] = newOperation(opHalt(RETURN), 0, 2, 0)
+	t[RETURN].memorySize = memoryHalt
+
+	// memory
+	t[MLOAD] = newOperation(opMload, 3, 1, 1)
+	t[MLOAD].memorySize = memoryMLoad
+	t[MSTORE] = newOperation(opMStore, 3, 2, 0)
+	t[MSTORE].memorySize = memoryMStore
+	t[MSTORE8] = newOperation(opMStore8, 3, 2, 0)
+	t[MSTORE8].memorySize = memoryMStore8
+
+	// store
+	t[SLOAD] = newOperation(opSload, 50, 1, 1)
+	t[SSTORE] = newOperation(opSStore(legacySStoreCosts), 0, 2, 0)
+
+	t[SHA3] = newOperation(opSha3, 30, 2, 1)
+	t[SHA3].dynamicGas = gasSha3
+	t[SHA3].memorySize = memorySha3
+
+	t[POP] = newOperation(opPop, 2, 1, 0)
+
+	// context operations
+	t[ADDRESS] = newOperation(opAddress, 2, 0, 1)
+	t[BALANCE] = newOperation(opBalance, 20, 1, 1)
+	t[ORIGIN] = newOperation(opOrigin, 2, 0, 1)
+	t[CALLER] = newOperation(opCaller, 2, 0, 1)
+	t[CALLVALUE] = newOperation(opCallValue, 2, 0, 1)
+	t[CALLDATALOAD] = newOperation(opCallDataLoad, 3, 1, 1)
+	t[CALLDATASIZE] = newOperation(opCallDataSize, 2, 0, 1)
+	t[CODESIZE] = newOperation(opCodeSize, 2, 0, 1)
+	t[EXTCODESIZE] = newOperation(opExtCodeSize, 20, 1, 1)
+	t[GASPRICE] = newOperation(opGasPrice, 2, 0, 1)
+	t[PC] = newOperation(opPC, 2, 0, 1)
+	t[MSIZE] = newOperation(opMSize, 2, 0, 1)
+	t[GAS] = newOperation(opGas, 2, 0, 1)
+
+	t[EXTCODECOPY] = newOperation(opExtCodeCopy, 20, 4, 0)
+	t[EXTCODECOPY].dynamicGas = gasCopy(4)
+	t[EXTCODECOPY].memorySize = memoryExtCodeCopy
+
+	t[CALLDATACOPY] = newOperation(opCallDataCopy, 3, 3, 0)
+	t[CALLDATACOPY].dynamicGas = gasCopy(3)
+	t[CALLDATACOPY].memorySize = memoryCopy
+
+	t[CODECOPY] = newOperation(opCodeCopy, 3, 3, 0)
+	t[CODECOPY].dynamicGas = gasCopy(3)
+	t[CODECOPY].memorySize = memoryCopy
+
+	// block information
+	t[BLOCKHASH] = newOperation(opBlockHash, 20, 1, 1)
+	t[COINBASE] = newOperation(opCoinbase, 2, 0, 1)
+	t[TIMESTAMP] = newOperation(opTimestamp, 2, 0, 1)
+	t[NUMBER] = newOperation(opNumber, 2, 0, 1)
+	t[DIFFICULTY] = newOperation(opDifficulty, 2, 0, 1)
+	t[GASLIMIT] = newOperation(opGasLimit, 2, 0, 1)
+
+	t[SELFDESTRUCT] = newOperation(opSelfDestruct, 0, 1, 0)
+
+	// jumps
+	t[JUMP] = newOperation(opJump, 8, 1, 0)
+	t[JUMPI] = newOperation(opJumpi, 10, 2, 0)
+	t[JUMPDEST] = newOperation(opJumpDest, 1, 0, 0)
+
+	return t
+}
+
+func newHomesteadInstructionSet() *jumpTable {
+	t := frontierInstructionSet.copy()
+
+	t[DELEGATECALL] = newOperation(opCall(DELEGATECALL), 40, 6, 1)
+	t[DELEGATECALL].dynamicGas = gasCall(DELEGATECALL, false, false)
+	t[DELEGATECALL].memorySize = memoryCall(DELEGATECALL)
+
+	// the creation fails if there is no gas to store the code
+	t[CREATE].execute = opCreate(CREATE, true, false)
+
+	return t
+}
+
+// newEIP150InstructionSet reprices the opcodes that access the state (eip-150)
+func newEIP150InstructionSet() *jumpTable {
+	t := homesteadInstructionSet.copy()
+
+	t[BALANCE].constantGas = 400
+	t[EXTCODESIZE].constantGas = 700
+	t[EXTCODECOPY].constantGas = 700
+	t[SLOAD].constantGas = 200
+
+	t[SELFDESTRUCT].constantGas = 5000
+	t[SELFDESTRUCT].dynamicGas = gasSelfDestruct(false)
+
+	// the calls get all but one 64th of the gas left
+	for _, op := range []OpCode{CALL, CALLCODE, DELEGATECALL} {
+		t[op].constantGas = 700
+		t[op].dynamicGas = gasCall(op, true, false)
+	}
+	t[CREATE].execute = opCreate(CREATE, true, true)
+
+	return t
+}
+
+// newEIP158InstructionSet reprices EXP and the access to empty accounts (eip-158, eip-160)
+func newEIP158InstructionSet() *jumpTable {
+	t := eip150InstructionSet.copy()
+
+	t[EXP].dynamicGas = gasExp(50)
+	t[SELFDESTRUCT].dynamicGas = gasSelfDestruct(true)
+
+	for _, op := range []OpCode{CALL, CALLCODE, DELEGATECALL} {
+		t[op].dynamicGas = gasCall(op, true, true)
+	}
+
+	return t
+}
+
+func newByzantiumInstructionSet() *jumpTable {
+	t := eip158InstructionSet.copy()
+
+	t[STATICCALL] = newOperation(opCall(STATICCALL), 700, 6, 1)
+	t[STATICCALL].dynamicGas = gasCall(STATICCALL, true, true)
+	t[STATICCALL].memorySize = memoryCall(STATICCALL)
+
+	t[REVERT] = newOperation(opHalt(REVERT), 0, 2, 0)
+	t[REVERT].memorySize = memoryHalt
+
+	t[RETURNDATASIZE] = newOperation(opReturnDataSize, 2, 0, 1)
+
+	t[RETURNDATACOPY] = newOperation(opReturnDataCopy, 3, 3, 0)
+	t[RETURNDATACOPY].dynamicGas = gasCopy(3)
+	t[RETURNDATACOPY].memorySize = memoryCopy
+
+	return t
+}
+
+// constantinopleSStoreCosts are the costs of SSTORE with the net gas metering (eip-1283)
+var constantinopleSStoreCosts = sstoreCosts{
+	runtime.StorageUnchanged:     200,
+	runtime.StorageModified:      5000,
+	runtime.StorageModifiedAgain: 200,
+	runtime.StorageAdded:         20000,
+	runtime.StorageDeleted:       5000,
+}
+
+func newConstantinopleInstructionSet() *jumpTable {
+	t := byzantiumInstructionSet.copy()
+
+	t[SHL] = newOperation(opShl, 3, 2, 1)
+	t[SHR] = newOperation(opShr, 3, 2, 1)
+	t[SAR] = newOperation(opSar, 3, 2, 1)
+
+	t[EXTCODEHASH] = newOperation(opExtCodeHash, 400, 1, 1)
+
+	t[CREATE2] = newOperation(opCreate(CREATE2, true, true), 32000, 4, 1)
+	t[CREATE2].dynamicGas = gasCreate2
+	t[CREATE2].memorySize = memoryCreate
+
+	t[SSTORE].execute = opSStore(constantinopleSStoreCosts)
+
+	return t
+}
+
+// newPetersburgInstructionSet removes the net gas metering of SSTORE (eip-1283)
+func newPetersburgInstructionSet() *jumpTable {
+	t := constantinopleInstructionSet.copy()
+
+	t[SSTORE].execute = opSStore(legacySStoreCosts)
+
+	return t
+}
+
+// istanbulSStoreCosts are the costs of SSTORE with the net gas metering (eip-2200)
+var istanbulSStoreCosts = sstoreCosts{
+	runtime.StorageUnchanged:     800,
+	runtime.StorageModified:      5000,
+	runtime.StorageModifiedAgain: 800,
+	runtime.StorageAdded:         20000,
+	runtime.StorageDeleted:       5000,
+}
+
+func newIstanbulInstructionSet() *jumpTable {
+	t := petersburgInstructionSet.copy()
+
+	t[CHAINID] = newOperation(opChainID, 2, 0, 1)
+	t[SELFBALANCE] = newOperation(opSelfBalance, 5, 0, 1)
+
+	// eip-1884
+	t[SLOAD].constantGas = 800
+	t[BALANCE].constantGas = 700
+	t[EXTCODEHASH].constantGas = 700
+
+	t[SSTORE].execute = opSStore(istanbulSStoreCosts)
+	t[SSTORE].dynamicGas = gasSStoreSentry
+
+	return t
+}
+
+// berlinSStoreCosts are the costs of SSTORE with the access lists (eip-2929)
+var berlinSStoreCosts = sstoreCosts{
+	runtime.StorageUnchanged:     warmStorageReadCost,
+	runtime.StorageModified:      5000 - coldSloadCost,
+	runtime.StorageModifiedAgain: warmStorageReadCost,
+	runtime.StorageAdded:         20000,
+	runtime.StorageDeleted:       5000 - coldSloadCost,
+}
+
+// newBerlinInstructionSet charges more the first access to an account
+// or a storage slot in the transaction (eip-2929)
+func newBerlinInstructionSet() *jumpTable {
+	t := istanbulInstructionSet.copy()
+
+	t[SLOAD].constantGas = 0
+	t[SLOAD].dynamicGas = gasSLoadEIP2929
+
+	t[SSTORE].execute = opSStore(berlinSStoreCosts)
+	t[SSTORE].dynamicGas = gasSStoreEIP2929
+
+	for _, op := range []OpCode{BALANCE, EXTCODESIZE, EXTCODEHASH} {
+		t[op].constantGas = warmStorageReadCost
+		t[op].dynamicGas = gasAccountEIP2929(1)
+	}
+	t[EXTCODECOPY].constantGas = warmStorageReadCost
+	t[EXTCODECOPY].dynamicGas = gasExtCodeCopyEIP2929
+
+	for _, op := range []OpCode{CALL, CALLCODE, DELEGATECALL, STATICCALL} {
+		t[op].constantGas = warmStorageReadCost
+		t[op].dynamicGas = gasCallEIP2929(op)
+	}
+
+	t[SELFDESTRUCT].dynamicGas = gasSelfDestructEIP2929
+
+	return t
+}
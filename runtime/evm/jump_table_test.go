@@ -0,0 +1,118 @@
+package evm
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/0xPolygon/eth-state-transition/runtime"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPushOpcodes(t *testing.T) {
+	code := make([]byte, 33)
+	for i := 0; i < 33; i++ {
+		code[i] = byte(i + 1)
+	}
+
+	c := 1
+	for i := PUSH1; i <= PUSH32; i++ {
+		s := &state{
+			code: code,
+		}
+
+		op := frontierInstructionSet[i]
+		op.execute(s)
+
+		assert.False(t, s.stop)
+
+		res := s.pop().Bytes()
+		assert.Len(t, res, c)
+
+		assert.True(t, bytes.HasPrefix(code[1:], res))
+		c++
+	}
+}
+
+func TestJumpTableForks(t *testing.T) {
+	cases := []struct {
+		config  runtime.ForksInTime
+		table   *jumpTable
+		enabled []OpCode
+		missing []OpCode
+	}{
+		{
+			config:  runtime.ForksInTime{},
+			table:   frontierInstructionSet,
+			missing: []OpCode{DELEGATECALL, REVERT, STATICCALL, SHL, CREATE2, CHAINID},
+		},
+		{
+			config:  runtime.ForksInTime{Homestead: true},
+			table:   homesteadInstructionSet,
+			enabled: []OpCode{DELEGATECALL},
+			missing: []OpCode{REVERT, RETURNDATASIZE, STATICCALL},
+		},
+		{
+			config:  runtime.ForksInTime{Homestead: true, EIP150: true, EIP158: true, Byzantium: true},
+			table:   byzantiumInstructionSet,
+			enabled: []OpCode{REVERT, RETURNDATASIZE, RETURNDATACOPY, STATICCALL},
+			missing: []OpCode{SHL, SHR, SAR, EXTCODEHASH, CREATE2},
+		},
+		{
+			config:  runtime.ForksInTime{Byzantium: true, Constantinople: true, Petersburg: true},
+			table:   petersburgInstructionSet,
+			enabled: []OpCode{SHL, SHR, SAR, EXTCODEHASH, CREATE2},
+			missing: []OpCode{CHAINID, SELFBALANCE},
+		},
+		{
+			config:  runtime.ForksInTime{Petersburg: true, Istanbul: true},
+			table:   istanbulInstructionSet,
+			enabled: []OpCode{CHAINID, SELFBALANCE},
+		},
+		{
+			config:  runtime.ForksInTime{Istanbul: true, Berlin: true},
+			table:   berlinInstructionSet,
+			enabled: []OpCode{CHAINID, SELFBALANCE},
+		},
+	}
+
+	for _, c := range cases {
+		config := c.config
+		table := jumpTableFor(&config)
+		assert.Equal(t, c.table, table)
+
+		for _, op := range c.enabled {
+			assert.NotNil(t, table[op], op.String())
+		}
+		for _, op := range c.missing {
+			assert.Nil(t, table[op], op.String())
+		}
+	}
+
+	// the tables derived from a fork do not change it
+	assert.Equal(t, uint64(50), frontierInstructionSet[SLOAD].constantGas)
+	assert.Equal(t, uint64(200), byzantiumInstructionSet[SLOAD].constantGas)
+	assert.Equal(t, uint64(800), istanbulInstructionSet[SLOAD].constantGas)
+	assert.Equal(t, uint64(0), berlinInstructionSet[SLOAD].constantGas)
+}
+
+func TestJumpTableStack(t *testing.T) {
+	// DUP16 requires 16 items and pushes one
+	op := frontierInstructionSet[DUP16]
+	assert.Equal(t, 16, op.minStack)
+	assert.Equal(t, stackSize-1, op.maxStack)
+
+	// the checks fail before the instruction runs
+	s, close := getState()
+	defer close()
+
+	s.gas = 1000
+	s.push(one)
+	assert.False(t, s.prepare(op))
+	assert.Equal(t, errStackUnderflow, s.err)
+	assert.Equal(t, uint64(1000), s.gas)
+
+	s.reset()
+	s.gas = 1
+	assert.False(t, s.prepare(frontierInstructionSet[ADDRESS]))
+	assert.Equal(t, errOutOfGas, s.err)
+}
@@ -2,6 +2,7 @@ package evm
 
 import (
 	"fmt"
+	"strings"
 )
 
 // OpCode is the EVM operation code
@@ -254,6 +255,9 @@ const (
 	// REVERT reverts with return data
 	REVERT = 0xFD
 
+	// INVALID is the designated invalid opcode, it aborts the execution
+	INVALID = 0xFE
+
 	// SELFDESTRUCT destroys the contract and sends all funds to addr
 	SELFDESTRUCT = 0xFF
 )
@@ -328,6 +332,7 @@ var opCodeToString = map[OpCode]string{
 	CREATE2:        "CREATE2",
 	STATICCALL:     "STATICCALL",
 	REVERT:         "REVERT",
+	INVALID:        "INVALID",
 	SELFDESTRUCT:   "SELFDESTRUCT",
 	CHAINID:        "CHAINID",
 	SELFBALANCE:    "SELFBALANCE",
@@ -353,8 +358,20 @@ func init() {
 	opCodesToString(LOG0, LOG4, "LOG")
 	// write swap
 	opCodesToString(SWAP1, SWAP16, "SWAP")
+
+	for op, str := range opCodeToString {
+		stringToOpCode[str] = op
+	}
 }
 
 func (op OpCode) String() string {
 	return opCodeToString[op]
 }
+
+var stringToOpCode = map[string]OpCode{}
+
+// StringToOpCode returns the opcode with the given name
+func StringToOpCode(str string) (OpCode, bool) {
+	op, ok := stringToOpCode[strings.ToUpper(str)]
+	return op, ok
+}
@@ -25,3 +25,16 @@ func TestOpcodesString(t *testing.T) {
 
 	assert(OpCode(0xA5), "")
 }
+
+func TestStringToOpCode(t *testing.T) {
+	op, ok := StringToOpCode("SSTORE")
+	assert.True(t, ok)
+	assert.Equal(t, OpCode(SSTORE), op)
+
+	op, ok = StringToOpCode("push32")
+	assert.True(t, ok)
+	assert.Equal(t, OpCode(PUSH32), op)
+
+	_, ok = StringToOpCode("PUSH33")
+	assert.False(t, ok)
+}
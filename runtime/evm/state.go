@@ -2,19 +2,21 @@ package evm
 
 import (
 	"errors"
-	"math/big"
 	"strings"
 
 	"sync"
 
 	"github.com/0xPolygon/eth-state-transition/helper"
 	"github.com/0xPolygon/eth-state-transition/runtime"
+	"github.com/0xPolygon/eth-state-transition/runtime/evm/uint256"
 	"github.com/0xPolygon/eth-state-transition/types"
 )
 
 var statePool = sync.Pool{
 	New: func() interface{} {
-		return new(state)
+		return &state{
+			stack: make([]uint256.Int, 0, stackSize),
+		}
 	},
 }
 
@@ -57,7 +59,7 @@ type state struct {
 	lastGasCost uint64
 
 	// stack
-	stack []*big.Int
+	stack []uint256.Int
 	sp    int
 
 	// remove later
@@ -68,11 +70,23 @@ type state struct {
 
 	gas uint64
 
-	// bitvec bitvec
-	bitmap bitmap
+	// jumpDests are the valid jump destinations of the code, either
+	// from the code cache or from bitmap
+	jumpDests *bitmap
+	bitmap    bitmap
+
+	// program is the code compiled in basic blocks, it is nil
+	// if the code is interpreted
+	program *program
 
 	returnData []byte
 	ret        []byte
+
+	// callGasLeft is the gas returned by the last call frame
+	callGasLeft uint64
+
+	// callGas is the gas of the new frame computed by the dynamic gas of a call
+	callGas uint64
 }
 
 func (c *state) reset() {
@@ -80,10 +94,14 @@ func (c *state) reset() {
 	c.ip = 0
 	c.gas = 0
 	c.lastGasCost = 0
+	c.callGasLeft = 0
+	c.callGas = 0
 	c.stop = false
 	c.err = nil
 
 	// reset bitmap
+	c.jumpDests = nil
+	c.program = nil
 	c.bitmap.reset()
 
 	// reset memory
@@ -98,12 +116,12 @@ func (c *state) reset() {
 	c.memory = c.memory[:0]
 }
 
-func (c *state) validJumpdest(dest *big.Int) bool {
+func (c *state) validJumpdest(dest *uint256.Int) bool {
 	udest := dest.Uint64()
-	if dest.BitLen() >= 63 || udest >= uint64(len(c.code)) {
+	if !dest.IsUint64() || udest >= uint64(len(c.code)) {
 		return false
 	}
-	return c.bitmap.isSet(uint(udest))
+	return c.jumpDests.isSet(uint(udest))
 }
 
 func (c *state) halt() {
@@ -118,27 +136,22 @@ func (c *state) exit(err error) {
 	c.err = err
 }
 
-func (c *state) push(val *big.Int) {
+func (c *state) push(val *uint256.Int) {
 	c.push1().Set(val)
 }
 
-func (c *state) push1() *big.Int {
-	if len(c.stack) > c.sp {
-		c.sp++
-		return c.stack[c.sp-1]
+// push1 returns the new item at the top of the stack, the
+// previous value of the item is not cleared
+func (c *state) push1() *uint256.Int {
+	if len(c.stack) == c.sp {
+		c.stack = append(c.stack, uint256.Int{})
 	}
-	v := big.NewInt(0)
-	c.stack = append(c.stack, v)
 	c.sp++
-	return v
-}
-
-func (c *state) stackAtLeast(n int) bool {
-	return c.sp >= n
+	return &c.stack[c.sp-1]
 }
 
 func (c *state) popHash() types.Hash {
-	return types.BytesToHash(c.pop().Bytes())
+	return wordToHash(c.pop())
 }
 
 func (c *state) popAddr() (types.Address, bool) {
@@ -147,31 +160,32 @@ func (c *state) popAddr() (types.Address, bool) {
 		return types.Address{}, false
 	}
 
-	return types.BytesToAddress(b.Bytes()), true
+	return wordToAddress(b), true
 }
 
 func (c *state) stackSize() int {
 	return c.sp
 }
 
-func (c *state) top() *big.Int {
+func (c *state) top() *uint256.Int {
 	if c.sp == 0 {
 		return nil
 	}
-	return c.stack[c.sp-1]
+	return &c.stack[c.sp-1]
 }
 
-func (c *state) pop() *big.Int {
+// pop removes the item at the top of the stack and returns it,
+// the item is valid until the next push
+func (c *state) pop() *uint256.Int {
 	if c.sp == 0 {
 		return nil
 	}
-	o := c.stack[c.sp-1]
 	c.sp--
-	return o
+	return &c.stack[c.sp]
 }
 
-func (c *state) peekAt(n int) *big.Int {
-	return c.stack[c.sp-n]
+func (c *state) peekAt(n int) *uint256.Int {
+	return &c.stack[c.sp-n]
 }
 
 func (c *state) swap(n int) {
@@ -192,8 +206,59 @@ func (c *state) resetReturnData() {
 	c.returnData = c.returnData[:0]
 }
 
+// prepare checks the stack of an operation and consumes its gas, expanding
+// the memory it uses, before the operation is executed
+func (c *state) prepare(op *operation) bool {
+	if c.sp < op.minStack {
+		c.exit(errStackUnderflow)
+		return false
+	}
+	if c.sp > op.maxStack {
+		c.exit(errStackOverflow)
+		return false
+	}
+	if !c.consumeGas(op.constantGas) {
+		return false
+	}
+	return c.prepareDynamic(op)
+}
+
+// prepareDynamic expands the memory of an operation and consumes its dynamic gas
+func (c *state) prepareDynamic(op *operation) bool {
+	if op.memorySize != nil {
+		size, ok := op.memorySize(c)
+		if !ok {
+			c.exit(errGasUintOverflow)
+			return false
+		}
+		if !c.extendMemory(size) {
+			return false
+		}
+	}
+	if op.dynamicGas != nil {
+		gas, err := op.dynamicGas(c)
+		if err != nil {
+			c.exit(err)
+			return false
+		}
+		if !c.consumeGas(gas) {
+			return false
+		}
+	}
+	return true
+}
+
 // Run executes the virtual machine
 func (c *state) Run() ([]byte, error) {
+	table := jumpTableFor(c.config)
+
+	if c.msg.Tracer != nil {
+		return c.runWithTracer(table, c.msg.Tracer)
+	}
+	if c.program != nil {
+		return c.runProgram(c.program)
+	}
+
 	var vmerr error
 
 	codeSize := len(c.code)
@@ -203,32 +268,18 @@ func (c *state) Run() ([]byte, error) {
 			break
 		}
 
-		op := OpCode(c.code[c.ip])
-
-		inst := dispatchTable[op]
-		if inst.inst == nil {
+		op := table[c.code[c.ip]]
+		if op == nil {
 			c.exit(errOpCodeNotFound)
 			break
 		}
-		// check if the depth of the stack is enough for the instruction
-		if c.sp < inst.stack {
-			c.exit(errStackUnderflow)
-			break
-		}
-		// consume the gas of the instruction
-		if !c.consumeGas(inst.gas) {
-			c.exit(errOutOfGas)
+		if !c.prepare(op) {
 			break
 		}
 
 		// execute the instruction
-		inst.inst(c)
+		op.execute(c)
 
-		// check if stack size exceeds the max size
-		if c.sp > stackSize {
-			c.exit(errStackOverflow)
-			break
-		}
 		c.ip++
 	}
 
@@ -242,49 +293,36 @@ func (c *state) inStaticCall() bool {
 	return c.msg.Static
 }
 
-func bigToHash(b *big.Int) types.Hash {
-	return types.BytesToHash(b.Bytes())
+func wordToHash(w *uint256.Int) types.Hash {
+	return types.Hash(w.Bytes32())
+}
+
+func wordToAddress(w *uint256.Int) types.Address {
+	return types.Address(w.Bytes20())
 }
 
 func (c *state) Len() int {
 	return len(c.memory)
 }
 
-func (c *state) checkMemory(offset, size *big.Int) bool {
-	if size.Sign() == 0 {
+// extendMemory consumes the gas to expand the memory to size bytes and resizes it
+func (c *state) extendMemory(size uint64) bool {
+	m := uint64(len(c.memory))
+	if m >= size {
 		return true
 	}
 
-	if !offset.IsUint64() || !size.IsUint64() {
-		c.exit(errGasUintOverflow)
-		return false
-	}
-
-	o := offset.Uint64()
-	s := size.Uint64()
+	w := (size + 31) / 32
+	newCost := uint64(3*w + w*w/512)
+	cost := newCost - c.lastGasCost
+	c.lastGasCost = newCost
 
-	if o > 0xffffffffe0 || s > 0xffffffffe0 {
-		c.exit(errGasUintOverflow)
+	if !c.consumeGas(cost) {
 		return false
 	}
 
-	m := uint64(len(c.memory))
-	newSize := o + s
-
-	if m < newSize {
-		w := (newSize + 31) / 32
-		newCost := uint64(3*w + w*w/512)
-		cost := newCost - c.lastGasCost
-		c.lastGasCost = newCost
-
-		if !c.consumeGas(cost) {
-			c.exit(errOutOfGas)
-			return false
-		}
-
-		// resize the memory
-		c.memory = extendByteSlice(c.memory, int(w*32))
-	}
+	// resize the memory
+	c.memory = extendByteSlice(c.memory, int(w*32))
 	return true
 }
 
@@ -296,20 +334,17 @@ func extendByteSlice(b []byte, needLen int) []byte {
 	return b[:needLen]
 }
 
-func (c *state) get2(dst []byte, offset, length *big.Int) ([]byte, bool) {
-	if length.Sign() == 0 {
-		return nil, true
-	}
-
-	if !c.checkMemory(offset, length) {
-		return nil, false
+// get2 appends a range of the memory to dst. The memory is already
+// expanded to include the range before the instruction runs.
+func (c *state) get2(dst []byte, offset, length *uint256.Int) []byte {
+	if length.IsZero() {
+		return nil
 	}
 
 	o := offset.Uint64()
 	l := length.Uint64()
 
-	dst = append(dst, c.memory[o:o+l]...)
-	return dst, true
+	return append(dst, c.memory[o:o+l]...)
 }
 
 func (c *state) Show() string {
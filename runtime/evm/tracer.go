@@ -0,0 +1,112 @@
+package evm
+
+import (
+	"math/big"
+
+	"github.com/0xPolygon/eth-state-transition/runtime"
+)
+
+var _ runtime.ScopeContext = &state{}
+
+// runWithTracer executes the virtual machine like Run and sends the
+// events of each opcode to the tracer
+func (c *state) runWithTracer(table *jumpTable, tracer runtime.Tracer) ([]byte, error) {
+	var vmerr error
+
+	codeSize := len(c.code)
+	for !c.stop {
+		if c.ip >= codeSize {
+			c.halt()
+			break
+		}
+
+		pc := uint64(c.ip)
+		op := OpCode(c.code[c.ip])
+		gas := c.gas
+
+		tracer.CaptureState(c, c.host)
+		c.callGasLeft = 0
+
+		operation := table[op]
+		if operation == nil {
+			c.exit(errOpCodeNotFound)
+			tracer.CaptureFault(pc, byte(op), 0, c.err)
+			break
+		}
+		if !c.prepare(operation) {
+			tracer.CaptureFault(pc, byte(op), operation.constantGas, c.err)
+			break
+		}
+
+		// execute the instruction
+		operation.execute(c)
+
+		// the cost includes the gas sent to the new frame but not the gas it returned
+		cost := gas - c.gas + c.callGasLeft
+
+		if c.err != nil && c.err != errRevert {
+			tracer.CaptureFault(pc, byte(op), cost, c.err)
+			break
+		}
+		tracer.ExecuteState(pc, byte(op), cost)
+
+		if c.stop {
+			break
+		}
+		c.ip++
+	}
+
+	if err := c.err; err != nil {
+		vmerr = err
+	}
+	return c.ret, vmerr
+}
+
+// PC implements the ScopeContext interface
+func (c *state) PC() uint64 {
+	return uint64(c.ip)
+}
+
+// Op implements the ScopeContext interface
+func (c *state) Op() byte {
+	return c.code[c.ip]
+}
+
+// Gas implements the ScopeContext interface
+func (c *state) Gas() uint64 {
+	return c.gas
+}
+
+// Depth implements the ScopeContext interface
+func (c *state) Depth() int {
+	return c.msg.Depth
+}
+
+// Stack implements the ScopeContext interface
+func (c *state) Stack() []*big.Int {
+	stack := make([]*big.Int, c.sp)
+	for i := range stack {
+		stack[i] = c.stack[i].ToBig()
+	}
+	return stack
+}
+
+// SetStack sets the item i of the stack, counted from the bottom
+func (c *state) SetStack(i int, value *big.Int) {
+	c.stack[i].SetFromBig(value)
+}
+
+// Memory implements the ScopeContext interface
+func (c *state) Memory() []byte {
+	return c.memory
+}
+
+// ReturnData implements the ScopeContext interface
+func (c *state) ReturnData() []byte {
+	return c.returnData
+}
+
+// Contract implements the ScopeContext interface
+func (c *state) Contract() *runtime.Contract {
+	return c.msg
+}
@@ -0,0 +1,97 @@
+package evm
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xPolygon/eth-state-transition/runtime"
+	"github.com/0xPolygon/eth-state-transition/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type step struct {
+	pc    uint64
+	op    OpCode
+	gas   uint64
+	cost  uint64
+	stack int
+	err   error
+}
+
+// mockTracer records the steps of the execution
+type mockTracer struct {
+	steps []*step
+}
+
+func (m *mockTracer) TxStart(gasLimit uint64) {}
+
+func (m *mockTracer) TxEnd(gasLeft uint64) {}
+
+func (m *mockTracer) CallStart(depth int, from, to types.Address, callType runtime.CallType, gas uint64, value *big.Int, input []byte) {
+}
+
+func (m *mockTracer) CallEnd(depth int, output []byte, gasUsed uint64, err error) {}
+
+func (m *mockTracer) CaptureState(scope runtime.ScopeContext, host runtime.Host) {
+	m.steps = append(m.steps, &step{
+		pc:    scope.PC(),
+		op:    OpCode(scope.Op()),
+		gas:   scope.Gas(),
+		stack: len(scope.Stack()),
+	})
+}
+
+func (m *mockTracer) ExecuteState(pc uint64, op byte, cost uint64) {
+	m.steps[len(m.steps)-1].cost = cost
+}
+
+func (m *mockTracer) CaptureFault(pc uint64, op byte, cost uint64, err error) {
+	m.steps[len(m.steps)-1].cost = cost
+	m.steps[len(m.steps)-1].err = err
+}
+
+func TestRunWithTracer(t *testing.T) {
+	code := []byte{
+		PUSH1, 0x01, PUSH1, 0x02, ADD,
+		PUSH1, 0x00, MSTORE8,
+		PUSH1, 0x01, PUSH1, 0x00, RETURN,
+	}
+
+	tracer := &mockTracer{}
+
+	contract := newMockContract(big.NewInt(0), 5000, code)
+	contract.Tracer = tracer
+
+	res := NewEVM().Run(contract, &mockHost{}, &runtime.ForksInTime{})
+	assert.NoError(t, res.Err)
+
+	// the result does not change with the tracer
+	expected := NewEVM().Run(newMockContract(big.NewInt(0), 5000, code), &mockHost{}, &runtime.ForksInTime{})
+	assert.Equal(t, expected, res)
+
+	assert.Equal(t, []*step{
+		{pc: 0, op: PUSH1, gas: 5000, cost: 3, stack: 0},
+		{pc: 2, op: PUSH1, gas: 4997, cost: 3, stack: 1},
+		{pc: 4, op: ADD, gas: 4994, cost: 3, stack: 2},
+		{pc: 5, op: PUSH1, gas: 4991, cost: 3, stack: 1},
+		// includes the memory expansion
+		{pc: 7, op: MSTORE8, gas: 4988, cost: 6, stack: 2},
+		{pc: 8, op: PUSH1, gas: 4982, cost: 3, stack: 0},
+		{pc: 10, op: PUSH1, gas: 4979, cost: 3, stack: 1},
+		{pc: 12, op: RETURN, gas: 4976, cost: 0, stack: 2},
+	}, tracer.steps)
+}
+
+func TestRunWithTracerFault(t *testing.T) {
+	tracer := &mockTracer{}
+
+	contract := newMockContract(big.NewInt(0), 5000, []byte{PUSH1, 0x01, ADD})
+	contract.Tracer = tracer
+
+	res := NewEVM().Run(contract, &mockHost{}, &runtime.ForksInTime{})
+	assert.Equal(t, errStackUnderflow, res.Err)
+
+	assert.Len(t, tracer.steps, 2)
+	assert.Equal(t, OpCode(ADD), tracer.steps[1].op)
+	assert.Equal(t, errStackUnderflow, tracer.steps[1].err)
+}
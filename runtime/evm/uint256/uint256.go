@@ -0,0 +1,608 @@
+// Package uint256 implements the 256 bits integers of the EVM with a fixed
+// size array, so the arithmetic does not allocate memory. The operations
+// wrap around modulo 2^256, and the signed ones use the two's complement.
+package uint256
+
+import (
+	"math/big"
+	"math/bits"
+)
+
+// Int is a 256 bits unsigned integer, the first word is the least significant one
+type Int [4]uint64
+
+// NewInt creates an integer from an uint64
+func NewInt(v uint64) *Int {
+	return &Int{v}
+}
+
+// Set sets z to x
+func (z *Int) Set(x *Int) *Int {
+	*z = *x
+	return z
+}
+
+// SetUint64 sets z to v
+func (z *Int) SetUint64(v uint64) *Int {
+	*z = Int{v}
+	return z
+}
+
+// SetOne sets z to 1
+func (z *Int) SetOne() *Int {
+	return z.SetUint64(1)
+}
+
+// Clear sets z to 0
+func (z *Int) Clear() *Int {
+	*z = Int{}
+	return z
+}
+
+// SetAllOne sets z to 2^256 - 1
+func (z *Int) SetAllOne() *Int {
+	*z = Int{^uint64(0), ^uint64(0), ^uint64(0), ^uint64(0)}
+	return z
+}
+
+// SetBytes sets z to the big endian value of b. Only the last
+// 32 bytes are used if b is longer.
+func (z *Int) SetBytes(b []byte) *Int {
+	if len(b) > 32 {
+		b = b[len(b)-32:]
+	}
+	*z = Int{}
+	for i, j := len(b)-1, 0; i >= 0; i, j = i-1, j+1 {
+		z[j/8] |= uint64(b[i]) << (8 * uint(j%8))
+	}
+	return z
+}
+
+// SetFromBig sets z to the last 256 bits of the absolute value of b
+func (z *Int) SetFromBig(b *big.Int) *Int {
+	if b.BitLen() > 256 {
+		b = new(big.Int).And(b, maxBig)
+	}
+	var buf [32]byte
+	return z.SetBytes(b.FillBytes(buf[:]))
+}
+
+var maxBig = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+
+// Bytes32 returns the big endian value of z
+func (z *Int) Bytes32() [32]byte {
+	var b [32]byte
+	for i := 0; i < 32; i++ {
+		b[31-i] = byte(z[i/8] >> (8 * uint(i%8)))
+	}
+	return b
+}
+
+// Bytes20 returns the last 20 bytes of the big endian value of z
+func (z *Int) Bytes20() [20]byte {
+	var b [20]byte
+	b32 := z.Bytes32()
+	copy(b[:], b32[12:])
+	return b
+}
+
+// Bytes returns the big endian value of z without the leading zeros
+func (z *Int) Bytes() []byte {
+	b := z.Bytes32()
+	return b[32-(z.BitLen()+7)/8:]
+}
+
+// ToBig returns z as a big integer
+func (z *Int) ToBig() *big.Int {
+	b := z.Bytes32()
+	return new(big.Int).SetBytes(b[:])
+}
+
+// String returns the decimal value of z
+func (z *Int) String() string {
+	return z.ToBig().String()
+}
+
+// IsZero returns true if z is 0
+func (z *Int) IsZero() bool {
+	return z[0]|z[1]|z[2]|z[3] == 0
+}
+
+// IsUint64 returns true if z fits in an uint64
+func (z *Int) IsUint64() bool {
+	return z[1]|z[2]|z[3] == 0
+}
+
+// Uint64 returns the lower 64 bits of z
+func (z *Int) Uint64() uint64 {
+	return z[0]
+}
+
+// BitLen returns the number of bits required to represent z
+func (z *Int) BitLen() int {
+	for i := 3; i >= 0; i-- {
+		if z[i] != 0 {
+			return i*64 + bits.Len64(z[i])
+		}
+	}
+	return 0
+}
+
+// IsNeg returns true if z is negative in two's complement
+func (z *Int) IsNeg() bool {
+	return z[3]>>63 == 1
+}
+
+// Eq returns true if z == x
+func (z *Int) Eq(x *Int) bool {
+	return *z == *x
+}
+
+// Cmp compares z and x as unsigned integers and returns -1, 0 or 1
+func (z *Int) Cmp(x *Int) int {
+	for i := 3; i >= 0; i-- {
+		if z[i] < x[i] {
+			return -1
+		}
+		if z[i] > x[i] {
+			return 1
+		}
+	}
+	return 0
+}
+
+// Lt returns true if z < x as unsigned integers
+func (z *Int) Lt(x *Int) bool {
+	return z.Cmp(x) < 0
+}
+
+// Gt returns true if z > x as unsigned integers
+func (z *Int) Gt(x *Int) bool {
+	return z.Cmp(x) > 0
+}
+
+// Slt returns true if z < x as signed integers
+func (z *Int) Slt(x *Int) bool {
+	zNeg, xNeg := z.IsNeg(), x.IsNeg()
+	if zNeg != xNeg {
+		return zNeg
+	}
+	return z.Lt(x)
+}
+
+// Sgt returns true if z > x as signed integers
+func (z *Int) Sgt(x *Int) bool {
+	return x.Slt(z)
+}
+
+// Add sets z to x + y
+func (z *Int) Add(x, y *Int) *Int {
+	var carry uint64
+	z[0], carry = bits.Add64(x[0], y[0], 0)
+	z[1], carry = bits.Add64(x[1], y[1], carry)
+	z[2], carry = bits.Add64(x[2], y[2], carry)
+	z[3], _ = bits.Add64(x[3], y[3], carry)
+	return z
+}
+
+// addOverflow sets z to x + y and returns the carry
+func (z *Int) addOverflow(x, y *Int) uint64 {
+	var carry uint64
+	z[0], carry = bits.Add64(x[0], y[0], 0)
+	z[1], carry = bits.Add64(x[1], y[1], carry)
+	z[2], carry = bits.Add64(x[2], y[2], carry)
+	z[3], carry = bits.Add64(x[3], y[3], carry)
+	return carry
+}
+
+// Sub sets z to x - y
+func (z *Int) Sub(x, y *Int) *Int {
+	var borrow uint64
+	z[0], borrow = bits.Sub64(x[0], y[0], 0)
+	z[1], borrow = bits.Sub64(x[1], y[1], borrow)
+	z[2], borrow = bits.Sub64(x[2], y[2], borrow)
+	z[3], _ = bits.Sub64(x[3], y[3], borrow)
+	return z
+}
+
+// Neg sets z to -x
+func (z *Int) Neg(x *Int) *Int {
+	return z.Sub(&Int{}, x)
+}
+
+// Abs sets z to the absolute value of x as a signed integer
+func (z *Int) Abs(x *Int) *Int {
+	if x.IsNeg() {
+		return z.Neg(x)
+	}
+	return z.Set(x)
+}
+
+// Mul sets z to x * y
+func (z *Int) Mul(x, y *Int) *Int {
+	var res Int
+	for i := 0; i < 4; i++ {
+		var carry uint64
+		for j := 0; i+j < 4; j++ {
+			hi, lo := bits.Mul64(x[i], y[j])
+			var c uint64
+			lo, c = bits.Add64(lo, res[i+j], 0)
+			hi += c
+			lo, c = bits.Add64(lo, carry, 0)
+			hi += c
+			res[i+j] = lo
+			carry = hi
+		}
+	}
+	*z = res
+	return z
+}
+
+// umul returns the 512 bits product of x and y
+func umul(x, y *Int) [8]uint64 {
+	var res [8]uint64
+	for i := 0; i < 4; i++ {
+		var carry uint64
+		for j := 0; j < 4; j++ {
+			hi, lo := bits.Mul64(x[i], y[j])
+			var c uint64
+			lo, c = bits.Add64(lo, res[i+j], 0)
+			hi += c
+			lo, c = bits.Add64(lo, carry, 0)
+			hi += c
+			res[i+j] = lo
+			carry = hi
+		}
+		res[i+4] = carry
+	}
+	return res
+}
+
+// Div sets z to x / y, or to 0 if y is 0
+func (z *Int) Div(x, y *Int) *Int {
+	if y.IsZero() || y.Gt(x) {
+		return z.Clear()
+	}
+	if x.IsUint64() {
+		return z.SetUint64(x.Uint64() / y.Uint64())
+	}
+	var quot [8]uint64
+	udivrem(quot[:], x[:], y)
+	copy(z[:], quot[:4])
+	return z
+}
+
+// Mod sets z to x % y, or to 0 if y is 0
+func (z *Int) Mod(x, y *Int) *Int {
+	if y.IsZero() {
+		return z.Clear()
+	}
+	if x.Lt(y) {
+		return z.Set(x)
+	}
+	if x.IsUint64() {
+		return z.SetUint64(x.Uint64() % y.Uint64())
+	}
+	var quot [8]uint64
+	*z = udivrem(quot[:], x[:], y)
+	return z
+}
+
+// SDiv sets z to x / y as signed integers rounded to zero, or to 0 if y is 0
+func (z *Int) SDiv(x, y *Int) *Int {
+	neg := x.IsNeg() != y.IsNeg()
+
+	var a, b Int
+	a.Abs(x)
+	b.Abs(y)
+	z.Div(&a, &b)
+	if neg {
+		z.Neg(z)
+	}
+	return z
+}
+
+// SMod sets z to x % y as signed integers with the sign of x, or to 0 if y is 0
+func (z *Int) SMod(x, y *Int) *Int {
+	neg := x.IsNeg()
+
+	var a, b Int
+	a.Abs(x)
+	b.Abs(y)
+	z.Mod(&a, &b)
+	if neg {
+		z.Neg(z)
+	}
+	return z
+}
+
+// AddMod sets z to (x + y) % m without overflow, or to 0 if m is 0
+func (z *Int) AddMod(x, y, m *Int) *Int {
+	if m.IsZero() {
+		return z.Clear()
+	}
+	var sum Int
+	carry := sum.addOverflow(x, y)
+	if carry == 0 {
+		return z.Mod(&sum, m)
+	}
+
+	// the sum has 257 bits
+	u := [5]uint64{sum[0], sum[1], sum[2], sum[3], carry}
+	var quot [8]uint64
+	*z = udivrem(quot[:], u[:], m)
+	return z
+}
+
+// MulMod sets z to (x * y) % m without overflow, or to 0 if m is 0
+func (z *Int) MulMod(x, y, m *Int) *Int {
+	if m.IsZero() {
+		return z.Clear()
+	}
+	p := umul(x, y)
+	var quot [8]uint64
+	*z = udivrem(quot[:], p[:], m)
+	return z
+}
+
+// Exp sets z to base ^ exponent
+func (z *Int) Exp(base, exponent *Int) *Int {
+	res := Int{1}
+	b := *base
+
+	n := exponent.BitLen()
+	for i := 0; i < n; i++ {
+		if exponent[i/64]>>(uint(i)%64)&1 == 1 {
+			res.Mul(&res, &b)
+		}
+		if i+1 < n {
+			b.Mul(&b, &b)
+		}
+	}
+	*z = res
+	return z
+}
+
+// ExtendSign sets z to x with the sign of its byte at position n (from the
+// least significant one) extended to the higher bytes
+func (z *Int) ExtendSign(x, n *Int) *Int {
+	if !n.IsUint64() || n.Uint64() >= 31 {
+		return z.Set(x)
+	}
+	bit := uint(n.Uint64()*8 + 7)
+
+	var mask Int
+	mask.Lsh(&Int{1}, bit)
+	mask.Sub(&mask, &Int{1})
+
+	if x[bit/64]>>(bit%64)&1 == 1 {
+		return z.Or(x, mask.Not(&mask))
+	}
+	return z.And(x, &mask)
+}
+
+// And sets z to x & y
+func (z *Int) And(x, y *Int) *Int {
+	z[0], z[1], z[2], z[3] = x[0]&y[0], x[1]&y[1], x[2]&y[2], x[3]&y[3]
+	return z
+}
+
+// Or sets z to x | y
+func (z *Int) Or(x, y *Int) *Int {
+	z[0], z[1], z[2], z[3] = x[0]|y[0], x[1]|y[1], x[2]|y[2], x[3]|y[3]
+	return z
+}
+
+// Xor sets z to x ^ y
+func (z *Int) Xor(x, y *Int) *Int {
+	z[0], z[1], z[2], z[3] = x[0]^y[0], x[1]^y[1], x[2]^y[2], x[3]^y[3]
+	return z
+}
+
+// Not sets z to ^x
+func (z *Int) Not(x *Int) *Int {
+	z[0], z[1], z[2], z[3] = ^x[0], ^x[1], ^x[2], ^x[3]
+	return z
+}
+
+// Byte sets z to the byte of x at position n (from the most
+// significant one), or to 0 if n is out of range
+func (z *Int) Byte(x, n *Int) *Int {
+	if !n.IsUint64() || n.Uint64() >= 32 {
+		return z.Clear()
+	}
+	i := n.Uint64()
+	v := x[3-i/8] >> (56 - 8*(i%8)) & 0xff
+	return z.SetUint64(v)
+}
+
+// Lsh sets z to x << n
+func (z *Int) Lsh(x *Int, n uint) *Int {
+	if n >= 256 {
+		return z.Clear()
+	}
+	words, shift := n/64, n%64
+
+	var res Int
+	for i := 3; i >= int(words); i-- {
+		res[i] = x[i-int(words)] << shift
+		if shift != 0 && i-int(words)-1 >= 0 {
+			res[i] |= x[i-int(words)-1] >> (64 - shift)
+		}
+	}
+	*z = res
+	return z
+}
+
+// Rsh sets z to x >> n
+func (z *Int) Rsh(x *Int, n uint) *Int {
+	if n >= 256 {
+		return z.Clear()
+	}
+	words, shift := n/64, n%64
+
+	var res Int
+	for i := 0; i+int(words) < 4; i++ {
+		res[i] = x[i+int(words)] >> shift
+		if shift != 0 && i+int(words)+1 < 4 {
+			res[i] |= x[i+int(words)+1] << (64 - shift)
+		}
+	}
+	*z = res
+	return z
+}
+
+// SRsh sets z to x >> n filling the higher bits with the sign of x
+func (z *Int) SRsh(x *Int, n uint) *Int {
+	if !x.IsNeg() {
+		return z.Rsh(x, n)
+	}
+	if n >= 256 {
+		return z.SetAllOne()
+	}
+	z.Rsh(x, n)
+	if n == 0 {
+		return z
+	}
+	var ones Int
+	ones.SetAllOne()
+	return z.Or(z, ones.Lsh(&ones, 256-n))
+}
+
+// udivrem divides u by d, stores the quotient in quot and returns the
+// remainder. It uses the algorithm D of Knuth (TAOCP vol 2, 4.3.1) with
+// words of 64 bits. d cannot be zero.
+func udivrem(quot, u []uint64, d *Int) Int {
+	dLen := 0
+	for i := len(d) - 1; i >= 0; i-- {
+		if d[i] != 0 {
+			dLen = i + 1
+			break
+		}
+	}
+
+	uLen := 0
+	for i := len(u) - 1; i >= 0; i-- {
+		if u[i] != 0 {
+			uLen = i + 1
+			break
+		}
+	}
+
+	var rem Int
+	if uLen < dLen {
+		copy(rem[:], u)
+		return rem
+	}
+
+	// normalize the divisor so its highest bit is set
+	shift := uint(bits.LeadingZeros64(d[dLen-1]))
+
+	var dnStorage Int
+	dn := dnStorage[:dLen]
+	for i := dLen - 1; i > 0; i-- {
+		dn[i] = d[i]<<shift | d[i-1]>>(64-shift)
+	}
+	dn[0] = d[0] << shift
+
+	var unStorage [9]uint64
+	un := unStorage[:uLen+1]
+	un[uLen] = u[uLen-1] >> (64 - shift)
+	for i := uLen - 1; i > 0; i-- {
+		un[i] = u[i]<<shift | u[i-1]>>(64-shift)
+	}
+	un[0] = u[0] << shift
+
+	if dLen == 1 {
+		r := udivremBy1(quot, un, dn[0])
+		rem[0] = r >> shift
+		return rem
+	}
+
+	udivremKnuth(quot, un, dn)
+
+	// the remainder is in the lower words of un
+	for i := 0; i < dLen-1; i++ {
+		rem[i] = un[i]>>shift | un[i+1]<<(64-shift)
+	}
+	rem[dLen-1] = un[dLen-1] >> shift
+	return rem
+}
+
+// udivremBy1 divides u by a normalized word
+func udivremBy1(quot, u []uint64, d uint64) uint64 {
+	rem := u[len(u)-1]
+	for j := len(u) - 2; j >= 0; j-- {
+		quot[j], rem = bits.Div64(rem, u[j], d)
+	}
+	return rem
+}
+
+// udivremKnuth divides u by the normalized d of at least two words,
+// the remainder is left in u
+func udivremKnuth(quot, u, d []uint64) {
+	n := len(d)
+	dh := d[n-1]
+	dl := d[n-2]
+
+	for j := len(u) - n - 1; j >= 0; j-- {
+		u2 := u[j+n]
+		u1 := u[j+n-1]
+		u0 := u[j+n-2]
+
+		// estimate the digit of the quotient with the two higher
+		// words of the divisor, it is at most one more than the digit
+		var qhat, rhat uint64
+		overflow := false
+		if u2 >= dh {
+			qhat = ^uint64(0)
+			var c uint64
+			rhat, c = bits.Add64(u1, dh, 0)
+			overflow = c != 0
+		} else {
+			qhat, rhat = bits.Div64(u2, u1, dh)
+		}
+		for !overflow {
+			ph, pl := bits.Mul64(qhat, dl)
+			if ph < rhat || (ph == rhat && pl <= u0) {
+				break
+			}
+			qhat--
+			var c uint64
+			rhat, c = bits.Add64(rhat, dh, 0)
+			overflow = c != 0
+		}
+
+		// multiply and subtract
+		borrow := subMulTo(u[j:j+n], d, qhat)
+		u[j+n] = u2 - borrow
+		if u2 < borrow {
+			// the digit was one too big, add back
+			qhat--
+			u[j+n] += addTo(u[j:j+n], d)
+		}
+		quot[j] = qhat
+	}
+}
+
+// subMulTo sets x to x - y * m and returns the borrow
+func subMulTo(x, y []uint64, m uint64) uint64 {
+	var borrow uint64
+	for i := 0; i < len(y); i++ {
+		s, c1 := bits.Sub64(x[i], borrow, 0)
+		ph, pl := bits.Mul64(y[i], m)
+		t, c2 := bits.Sub64(s, pl, 0)
+		x[i] = t
+		borrow = ph + c1 + c2
+	}
+	return borrow
+}
+
+// addTo sets x to x + y and returns the carry
+func addTo(x, y []uint64) uint64 {
+	var carry uint64
+	for i := 0; i < len(y); i++ {
+		x[i], carry = bits.Add64(x[i], y[i], carry)
+	}
+	return carry
+}
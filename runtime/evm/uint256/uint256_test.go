@@ -0,0 +1,209 @@
+package uint256
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	tt256   = new(big.Int).Lsh(big.NewInt(1), 256)
+	tt255   = new(big.Int).Lsh(big.NewInt(1), 255)
+	tt256m1 = new(big.Int).Sub(tt256, big.NewInt(1))
+)
+
+func toU256(x *big.Int) *big.Int {
+	return x.And(x, tt256m1)
+}
+
+func toSigned(x *big.Int) *big.Int {
+	if x.Cmp(tt255) >= 0 {
+		return new(big.Int).Sub(x, tt256)
+	}
+	return x
+}
+
+func fromHex(str string) *big.Int {
+	b, _ := new(big.Int).SetString(str, 16)
+	return b
+}
+
+// testValues returns the edge values and random values of different sizes
+func testValues() []*big.Int {
+	values := []*big.Int{
+		big.NewInt(0),
+		big.NewInt(1),
+		big.NewInt(2),
+		big.NewInt(255),
+		new(big.Int).SetUint64(^uint64(0)),
+		new(big.Int).Lsh(big.NewInt(1), 64),
+		new(big.Int).Lsh(big.NewInt(1), 128),
+		new(big.Int).Sub(tt255, big.NewInt(1)),
+		tt255,
+		new(big.Int).Sub(tt256, big.NewInt(2)),
+		tt256m1,
+		fromHex("ffffffffffffffff0000000000000000ffffffffffffffff"),
+		fromHex("8000000000000000000000000000000000000000000000000000000000000001"),
+	}
+
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 40; i++ {
+		b := make([]byte, 1+rnd.Intn(32))
+		rnd.Read(b)
+		values = append(values, new(big.Int).SetBytes(b))
+	}
+	return values
+}
+
+func TestIntConversion(t *testing.T) {
+	for _, v := range testValues() {
+		x := new(Int).SetFromBig(v)
+		assert.Equal(t, v.String(), x.String())
+		assert.Equal(t, v.Bytes(), x.Bytes())
+		assert.Equal(t, v.BitLen(), x.BitLen())
+		assert.Equal(t, x, new(Int).SetBytes(v.Bytes()))
+	}
+
+	// the values are truncated to 256 bits
+	x := new(Int).SetFromBig(new(big.Int).Add(tt256, big.NewInt(5)))
+	assert.Equal(t, NewInt(5), x)
+
+	x.SetBytes(append([]byte{0x1}, make([]byte, 32)...))
+	assert.True(t, x.IsZero())
+}
+
+func TestIntBinaryOps(t *testing.T) {
+	cases := map[string]struct {
+		fn  func(z, x, y *Int) *Int
+		ref func(x, y *big.Int) *big.Int
+	}{
+		"add": {(*Int).Add, func(x, y *big.Int) *big.Int { return toU256(new(big.Int).Add(x, y)) }},
+		"sub": {(*Int).Sub, func(x, y *big.Int) *big.Int { return toU256(new(big.Int).Sub(x, y)) }},
+		"mul": {(*Int).Mul, func(x, y *big.Int) *big.Int { return toU256(new(big.Int).Mul(x, y)) }},
+		"div": {(*Int).Div, func(x, y *big.Int) *big.Int {
+			if y.Sign() == 0 {
+				return new(big.Int)
+			}
+			return new(big.Int).Div(x, y)
+		}},
+		"mod": {(*Int).Mod, func(x, y *big.Int) *big.Int {
+			if y.Sign() == 0 {
+				return new(big.Int)
+			}
+			return new(big.Int).Mod(x, y)
+		}},
+		"sdiv": {(*Int).SDiv, func(x, y *big.Int) *big.Int {
+			if y.Sign() == 0 {
+				return new(big.Int)
+			}
+			return toU256(new(big.Int).Quo(toSigned(x), toSigned(y)))
+		}},
+		"smod": {(*Int).SMod, func(x, y *big.Int) *big.Int {
+			if y.Sign() == 0 {
+				return new(big.Int)
+			}
+			return toU256(new(big.Int).Rem(toSigned(x), toSigned(y)))
+		}},
+		"exp": {(*Int).Exp, func(x, y *big.Int) *big.Int { return new(big.Int).Exp(x, y, tt256) }},
+		"and": {(*Int).And, func(x, y *big.Int) *big.Int { return new(big.Int).And(x, y) }},
+		"or":  {(*Int).Or, func(x, y *big.Int) *big.Int { return new(big.Int).Or(x, y) }},
+		"xor": {(*Int).Xor, func(x, y *big.Int) *big.Int { return new(big.Int).Xor(x, y) }},
+	}
+
+	values := testValues()
+	for name, c := range cases {
+		for _, a := range values {
+			for _, b := range values {
+				x, y := new(Int).SetFromBig(a), new(Int).SetFromBig(b)
+				res := c.fn(new(Int), x, y)
+				assert.Equal(t, c.ref(a, b).String(), res.String(), "%s %s %s", name, a, b)
+
+				// the result can be stored in the arguments
+				res = c.fn(x, x, y)
+				assert.Equal(t, c.ref(a, b).String(), res.String(), "%s %s %s", name, a, b)
+			}
+		}
+	}
+}
+
+func TestIntModOps(t *testing.T) {
+	values := testValues()
+	for _, a := range values {
+		for _, b := range values {
+			for _, m := range values[:20] {
+				x, y, n := new(Int).SetFromBig(a), new(Int).SetFromBig(b), new(Int).SetFromBig(m)
+
+				addMod, mulMod := new(big.Int), new(big.Int)
+				if m.Sign() != 0 {
+					addMod.Mod(new(big.Int).Add(a, b), m)
+					mulMod.Mod(new(big.Int).Mul(a, b), m)
+				}
+				assert.Equal(t, addMod.String(), new(Int).AddMod(x, y, n).String(), "addmod %s %s %s", a, b, m)
+				assert.Equal(t, mulMod.String(), new(Int).MulMod(x, y, n).String(), "mulmod %s %s %s", a, b, m)
+			}
+		}
+	}
+}
+
+func TestIntCompare(t *testing.T) {
+	values := testValues()
+	for _, a := range values {
+		for _, b := range values {
+			x, y := new(Int).SetFromBig(a), new(Int).SetFromBig(b)
+
+			assert.Equal(t, a.Cmp(b), x.Cmp(y))
+			assert.Equal(t, a.Cmp(b) < 0, x.Lt(y))
+			assert.Equal(t, a.Cmp(b) > 0, x.Gt(y))
+			assert.Equal(t, a.Cmp(b) == 0, x.Eq(y))
+			assert.Equal(t, toSigned(a).Cmp(toSigned(b)) < 0, x.Slt(y), "slt %s %s", a, b)
+			assert.Equal(t, toSigned(a).Cmp(toSigned(b)) > 0, x.Sgt(y), "sgt %s %s", a, b)
+		}
+	}
+}
+
+func TestIntShifts(t *testing.T) {
+	for _, a := range testValues() {
+		x := new(Int).SetFromBig(a)
+		for n := uint(0); n <= 260; n++ {
+			assert.Equal(t, toU256(new(big.Int).Lsh(a, n)).String(), new(Int).Lsh(x, n).String(), "lsh %s %d", a, n)
+			assert.Equal(t, new(big.Int).Rsh(a, n).String(), new(Int).Rsh(x, n).String(), "rsh %s %d", a, n)
+
+			// big.Int rounds the arithmetic shift to the negative infinity
+			sar := toU256(new(big.Int).Rsh(toSigned(a), n))
+			assert.Equal(t, sar.String(), new(Int).SRsh(x, n).String(), "sar %s %d", a, n)
+		}
+	}
+}
+
+func TestIntByteAndSign(t *testing.T) {
+	for _, a := range testValues() {
+		x := new(Int).SetFromBig(a)
+		b := x.Bytes32()
+
+		for n := uint64(0); n < 40; n++ {
+			expected := uint64(0)
+			if n < 32 {
+				expected = uint64(b[n])
+			}
+			assert.Equal(t, NewInt(expected), new(Int).Byte(x, NewInt(n)))
+
+			// sign extension of the byte n from the right
+			ext := new(big.Int).Set(a)
+			if n < 31 {
+				bit := uint(n*8 + 7)
+				mask := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), bit), big.NewInt(1))
+				if a.Bit(int(bit)) == 1 {
+					ext.Or(a, new(big.Int).Xor(mask, tt256m1))
+				} else {
+					ext.And(a, mask)
+				}
+			}
+			assert.Equal(t, ext.String(), new(Int).ExtendSign(x, NewInt(n)).String(), "signextend %s %d", a, n)
+		}
+	}
+
+	assert.Equal(t, new(Int).SetAllOne(), new(Int).Not(new(Int)))
+	assert.Equal(t, new(Int).SetAllOne(), new(Int).Neg(NewInt(1)))
+}
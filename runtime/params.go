@@ -8,6 +8,40 @@ import (
 type Params struct {
 	Forks   *Forks `json:"forks"`
 	ChainID int    `json:"chainID"`
+
+	// Rewards is the proof-of-work block reward schedule.
+	// If it is not set, the mainnet schedule is used.
+	Rewards *RewardSchedule `json:"rewards,omitempty"`
+}
+
+// RewardSchedule is the reward in wei for sealing a block from each fork onwards.
+// A fork without a reward keeps the reward of the previous fork.
+type RewardSchedule struct {
+	Frontier       *big.Int `json:"frontier,omitempty"`
+	Byzantium      *big.Int `json:"byzantium,omitempty"`
+	Constantinople *big.Int `json:"constantinople,omitempty"`
+}
+
+// MainnetRewards is the block reward schedule of the mainnet
+var MainnetRewards = &RewardSchedule{
+	Frontier:       new(big.Int).Mul(big.NewInt(5), big.NewInt(1e18)), // 5 ETH
+	Byzantium:      new(big.Int).Mul(big.NewInt(3), big.NewInt(1e18)), // 3 ETH (EIP-649)
+	Constantinople: new(big.Int).Mul(big.NewInt(2), big.NewInt(1e18)), // 2 ETH (EIP-1234)
+}
+
+// BlockReward returns the reward for sealing a block with the given forks enabled
+func (r *RewardSchedule) BlockReward(forks ForksInTime) *big.Int {
+	reward := new(big.Int)
+	if r.Frontier != nil {
+		reward.Set(r.Frontier)
+	}
+	if forks.Byzantium && r.Byzantium != nil {
+		reward.Set(r.Byzantium)
+	}
+	if forks.Constantinople && r.Constantinople != nil {
+		reward.Set(r.Constantinople)
+	}
+	return reward
 }
 
 // Forks specifies when each fork is activated
@@ -17,9 +51,13 @@ type Forks struct {
 	Constantinople *Fork `json:"constantinople,omitempty"`
 	Petersburg     *Fork `json:"petersburg,omitempty"`
 	Istanbul       *Fork `json:"istanbul,omitempty"`
+	Berlin         *Fork `json:"berlin,omitempty"`
 	EIP150         *Fork `json:"EIP150,omitempty"`
 	EIP158         *Fork `json:"EIP158,omitempty"`
 	EIP155         *Fork `json:"EIP155,omitempty"`
+
+	// DAOFork is the block of the DAO hard fork (1920000 on mainnet)
+	DAOFork *Fork `json:"daoFork,omitempty"`
 }
 
 func (f *Forks) active(ff *Fork, block uint64) bool {
@@ -45,6 +83,10 @@ func (f *Forks) IsPetersburg(block uint64) bool {
 	return f.active(f.Petersburg, block)
 }
 
+func (f *Forks) IsBerlin(block uint64) bool {
+	return f.active(f.Berlin, block)
+}
+
 func (f *Forks) IsEIP150(block uint64) bool {
 	return f.active(f.EIP150, block)
 }
@@ -57,6 +99,11 @@ func (f *Forks) IsEIP155(block uint64) bool {
 	return f.active(f.EIP155, block)
 }
 
+// IsDAOForkBlock returns whether the block is the one that applies the DAO hard fork
+func (f *Forks) IsDAOForkBlock(block uint64) bool {
+	return f.DAOFork != nil && uint64(*f.DAOFork) == block
+}
+
 func (f *Forks) At(block uint64) ForksInTime {
 	return ForksInTime{
 		Homestead:      f.active(f.Homestead, block),
@@ -64,6 +111,7 @@ func (f *Forks) At(block uint64) ForksInTime {
 		Constantinople: f.active(f.Constantinople, block),
 		Petersburg:     f.active(f.Petersburg, block),
 		Istanbul:       f.active(f.Istanbul, block),
+		Berlin:         f.active(f.Berlin, block),
 		EIP150:         f.active(f.EIP150, block),
 		EIP158:         f.active(f.EIP158, block),
 		EIP155:         f.active(f.EIP155, block),
@@ -92,6 +140,7 @@ type ForksInTime struct {
 	Constantinople,
 	Petersburg,
 	Istanbul,
+	Berlin,
 	EIP150,
 	EIP158,
 	EIP155 bool
@@ -106,4 +155,5 @@ var AllForksEnabled = &Forks{
 	Constantinople: NewFork(0),
 	Petersburg:     NewFork(0),
 	Istanbul:       NewFork(0),
+	Berlin:         NewFork(0),
 }
@@ -60,12 +60,17 @@ var (
 
 // CanRun implements the runtime interface
 func (p *Precompiled) CanRun(c *runtime.Contract, _ runtime.Host, config *runtime.ForksInTime) bool {
-	if _, ok := p.contracts[c.CodeAddress]; !ok {
+	return p.IsPrecompiled(c.CodeAddress, config)
+}
+
+// IsPrecompiled returns whether there is a precompiled contract enabled at the address
+func (p *Precompiled) IsPrecompiled(addr types.Address, config *runtime.ForksInTime) bool {
+	if _, ok := p.contracts[addr]; !ok {
 		return false
 	}
 
 	// byzantium precompiles
-	switch c.CodeAddress {
+	switch addr {
 	case five:
 		fallthrough
 	case six:
@@ -77,7 +82,7 @@ func (p *Precompiled) CanRun(c *runtime.Contract, _ runtime.Host, config *runtim
 	}
 
 	// istanbul precompiles
-	switch c.CodeAddress {
+	switch addr {
 	case nine:
 		return config.Istanbul
 	}
@@ -85,6 +90,18 @@ func (p *Precompiled) CanRun(c *runtime.Contract, _ runtime.Host, config *runtim
 	return true
 }
 
+// Addresses returns the addresses of the precompiled contracts enabled in a fork
+func (p *Precompiled) Addresses(config *runtime.ForksInTime) []types.Address {
+	addrs := []types.Address{}
+	for i := 1; i <= len(p.contracts); i++ {
+		addr := types.BytesToAddress([]byte{byte(i)})
+		if p.IsPrecompiled(addr, config) {
+			addrs = append(addrs, addr)
+		}
+	}
+	return addrs
+}
+
 // Name implements the runtime interface
 func (p *Precompiled) Name() string {
 	return "precompiled"
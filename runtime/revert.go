@@ -0,0 +1,34 @@
+package runtime
+
+import "encoding/binary"
+
+// revertSelector is the selector of Error(string) used to encode the revert reasons
+var revertSelector = []byte{0x08, 0xc3, 0x79, 0xa0}
+
+// UnpackRevertReason returns the reason of a revert if the data is encoded as Error(string)
+func UnpackRevertReason(data []byte) (string, bool) {
+	// selector, offset, length
+	if len(data) < 4+32+32 || string(data[:4]) != string(revertSelector) {
+		return "", false
+	}
+	data = data[4:]
+
+	offset, ok := abiUint(data[:32])
+	if !ok || offset > uint64(len(data))-32 {
+		return "", false
+	}
+	size, ok := abiUint(data[offset : offset+32])
+	if !ok || size > uint64(len(data))-offset-32 {
+		return "", false
+	}
+	return string(data[offset+32 : offset+32+size]), true
+}
+
+func abiUint(word []byte) (uint64, bool) {
+	for _, b := range word[:24] {
+		if b != 0 {
+			return 0, false
+		}
+	}
+	return binary.BigEndian.Uint64(word[24:]), true
+}
@@ -20,6 +20,31 @@ type TxContext struct {
 	Difficulty types.Hash
 }
 
+// NewTxContext creates the context of the transactions of a block from its header.
+// The transaction specific fields (GasPrice and Origin) are left empty.
+func NewTxContext(header *types.Header, chainID int64) TxContext {
+	ctx := TxContext{
+		Hash:      header.Hash(),
+		Coinbase:  header.Miner,
+		Number:    int64(header.Number),
+		Timestamp: int64(header.Timestamp),
+		GasLimit:  int64(header.GasLimit),
+		ChainID:   chainID,
+	}
+
+	if header.Difficulty == nil || header.Difficulty.Sign() == 0 {
+		// after the merge the difficulty is zero and the mix hash
+		// holds the randomness from the beacon chain (EIP-4399)
+		ctx.Difficulty = header.MixHash
+	} else {
+		ctx.Difficulty = types.BytesToHash(header.Difficulty.Bytes())
+	}
+	return ctx
+}
+
+// CallStipend is the free gas given to the callee of a call with value
+const CallStipend = 2300
+
 // StorageStatus is the status of the storage access
 type StorageStatus int
 
@@ -69,6 +94,11 @@ type Host interface {
 	Callx(*Contract, Host) *ExecutionResult
 	Empty(addr types.Address) bool
 	GetNonce(addr types.Address) uint64
+
+	// AccessAddress and AccessSlot add an account or a storage slot to the access
+	// list of the transaction and return whether it was already in it (eip-2929)
+	AccessAddress(addr types.Address) bool
+	AccessSlot(addr types.Address, key types.Hash) bool
 }
 
 // ExecutionResult includes all output after executing given evm
@@ -115,8 +145,32 @@ const (
 	StaticCall
 	Create
 	Create2
+	// Selfdestruct is only used to trace the transfer of the balance of a
+	// destroyed contract
+	Selfdestruct
 )
 
+func (c CallType) String() string {
+	switch c {
+	case Call:
+		return "CALL"
+	case CallCode:
+		return "CALLCODE"
+	case DelegateCall:
+		return "DELEGATECALL"
+	case StaticCall:
+		return "STATICCALL"
+	case Create:
+		return "CREATE"
+	case Create2:
+		return "CREATE2"
+	case Selfdestruct:
+		return "SELFDESTRUCT"
+	default:
+		panic("BUG: call type not found")
+	}
+}
+
 // Runtime can process contracts
 type Runtime interface {
 	Run(c *Contract, host Host, config *ForksInTime) *ExecutionResult
@@ -137,6 +191,13 @@ type Contract struct {
 	Input       []byte
 	Gas         uint64
 	Static      bool
+
+	// CodeHash is the hash of the code, it is empty for the init code
+	// of a contract creation
+	CodeHash types.Hash
+
+	// Tracer receives the events of the execution if it is set
+	Tracer Tracer
 }
 
 func NewContract(depth int, origin types.Address, from types.Address, to types.Address, value *big.Int, gas uint64, code []byte) *Contract {
@@ -0,0 +1,64 @@
+package runtime
+
+import (
+	"math/big"
+
+	"github.com/0xPolygon/eth-state-transition/types"
+)
+
+// Tracer receives the events of the execution of a transaction.
+// The tracer is set in the contract of each call frame.
+type Tracer interface {
+	// TxStart is called before executing the transaction with its gas limit
+	TxStart(gasLimit uint64)
+
+	// TxEnd is called after executing the transaction with the gas left
+	TxEnd(gasLeft uint64)
+
+	// CallStart is called when a call frame starts. The top-level frame has depth 1.
+	// from is the account making the call and to is the account whose code is run,
+	// which for CALLCODE and DELEGATECALL is not the account of the new frame.
+	CallStart(depth int, from, to types.Address, callType CallType, gas uint64, value *big.Int, input []byte)
+
+	// CallEnd is called when a call frame returns
+	CallEnd(depth int, output []byte, gasUsed uint64, err error)
+
+	// CaptureState is called before executing each opcode
+	CaptureState(scope ScopeContext, host Host)
+
+	// ExecuteState is called after executing an opcode with the gas it consumed.
+	// For calls and creations the cost includes the gas sent to the new frame.
+	ExecuteState(pc uint64, op byte, cost uint64)
+
+	// CaptureFault is called instead of ExecuteState when an opcode fails
+	CaptureFault(pc uint64, op byte, cost uint64, err error)
+}
+
+// ScopeContext is the view of the state of a call frame during the execution
+// of an opcode. The values are only valid during the tracer callback and must
+// be copied to be retained.
+type ScopeContext interface {
+	// PC returns the position of the opcode in the code
+	PC() uint64
+
+	// Op returns the opcode
+	Op() byte
+
+	// Gas returns the gas available before the opcode
+	Gas() uint64
+
+	// Depth returns the depth of the call frame
+	Depth() int
+
+	// Stack returns a copy of the stack, the last item is the top of the stack
+	Stack() []*big.Int
+
+	// Memory returns the memory
+	Memory() []byte
+
+	// ReturnData returns the data returned by the last call
+	ReturnData() []byte
+
+	// Contract returns the contract of the call frame
+	Contract() *Contract
+}
@@ -0,0 +1,184 @@
+package state
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/0xPolygon/eth-state-transition/runtime"
+	"github.com/0xPolygon/eth-state-transition/types"
+)
+
+var (
+	ErrStateAndStateDiff = fmt.Errorf("state and stateDiff cannot be overridden at the same time")
+)
+
+// AccountOverride replaces the values of an account during a call.
+// State replaces the whole storage of the account while StateDiff
+// only replaces the given slots.
+type AccountOverride struct {
+	Nonce     *uint64
+	Code      []byte
+	Balance   *big.Int
+	State     map[types.Hash]types.Hash
+	StateDiff map[types.Hash]types.Hash
+}
+
+// StateOverride is the set of account overrides of a call
+type StateOverride map[types.Address]*AccountOverride
+
+func (s StateOverride) apply(txn *Txn) error {
+	for addr, account := range s {
+		if account.State != nil && account.StateDiff != nil {
+			return fmt.Errorf("%w: account %s", ErrStateAndStateDiff, addr)
+		}
+		if account.Nonce != nil {
+			txn.SetNonce(addr, *account.Nonce)
+		}
+		if account.Code != nil {
+			txn.SetCode(addr, account.Code)
+		}
+		if account.Balance != nil {
+			txn.SetBalance(addr, account.Balance)
+		}
+		if account.State != nil {
+			txn.SetFullState(addr, account.State)
+		}
+		for key, value := range account.StateDiff {
+			txn.SetState(addr, key, value)
+		}
+	}
+	return nil
+}
+
+// CallOptions are the options of a read-only call
+type CallOptions struct {
+	// GasCap is the maximum gas of the call. If it is zero, the gas
+	// limit of the block is used.
+	GasCap uint64
+
+	// Overrides are applied to the state before the call
+	Overrides StateOverride
+
+	// CheckNonce fails the call if the nonce of the transaction is
+	// not the nonce of the sender
+	CheckNonce bool
+
+	// CheckBalance fails the call if the sender cannot pay for the gas and
+	// the value of the transaction. The gas is charged to the sender at the
+	// gas price of the transaction during the call.
+	CheckBalance bool
+}
+
+// Simulate executes the transaction as a read-only call (i.e. eth_call) on top of
+// the current state. Unless the options enable the checks, the nonce and the balance of
+// the sender are not checked and the gas is not charged. If the gas price is not set, a
+// zero gas price is used.
+// The changes of the call are discarded, the state of the transition is not modified.
+func (t *Transition) Simulate(msg *Transaction, opts *CallOptions) (*runtime.ExecutionResult, error) {
+	result, _, err := t.simulate(msg, opts, nil)
+	return result, err
+}
+
+// untraced returns a copy of the transition without tracer
+func (t *Transition) untraced() *Transition {
+	tt := *t
+	tt.tracer = nil
+	return &tt
+}
+
+// simulate runs the read-only call and returns the result and the refunded gas.
+// If wrap is set, the call is executed with the host it returns for the transition.
+func (t *Transition) simulate(msg *Transaction, opts *CallOptions, wrap func(*Transition) runtime.Host) (*runtime.ExecutionResult, uint64, error) {
+	if opts == nil {
+		opts = &CallOptions{}
+	}
+
+	// run the call on a copy so that the changes are discarded
+	sim := *t
+	sim.txn = t.txn.Copy()
+
+	if err := opts.Overrides.apply(sim.txn); err != nil {
+		return nil, 0, err
+	}
+	if sim.forks.Berlin {
+		sim.prepareAccessList(msg)
+	}
+
+	gasCap := opts.GasCap
+	if gasCap == 0 {
+		gasCap = uint64(t.ctx.GasLimit)
+	}
+	gas := msg.Gas
+	if gas == 0 || gas > gasCap {
+		gas = gasCap
+	}
+
+	intrinsicGasCost, err := TransactionGasCost(msg, t.forks.Homestead, t.forks.Istanbul, t.forks.Berlin)
+	if err != nil {
+		return nil, 0, err
+	}
+	if gas < intrinsicGasCost {
+		return nil, 0, ErrNotEnoughIntrinsicGas
+	}
+	gasLeft := gas - intrinsicGasCost
+
+	gasPrice := new(big.Int)
+	if msg.GasPrice != nil {
+		gasPrice.Set(msg.GasPrice)
+	}
+	value := new(big.Int)
+	if msg.Value != nil {
+		value.Set(msg.Value)
+	}
+
+	if opts.CheckNonce {
+		if err := sim.nonceCheck(msg); err != nil {
+			return nil, 0, err
+		}
+	}
+	if opts.CheckBalance {
+		upfrontGasCost := new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(gas))
+		if err := sim.txn.SubBalance(msg.From, upfrontGasCost); err != nil {
+			if err == runtime.ErrNotEnoughFunds {
+				return nil, 0, ErrNotEnoughFundsForGas
+			}
+			return nil, 0, err
+		}
+		if sim.txn.GetBalance(msg.From).Cmp(value) < 0 {
+			return nil, 0, ErrNotEnoughFunds
+		}
+	}
+
+	sim.ctx.GasPrice = types.BytesToHash(gasPrice.Bytes())
+	sim.ctx.Origin = msg.From
+
+	var host runtime.Host = &sim
+	if wrap != nil {
+		host = wrap(&sim)
+	}
+
+	if tracer, ok := sim.tracer.(TxnTracer); ok {
+		tracer.TxnStart(sim.txn)
+	}
+	if sim.tracer != nil {
+		sim.tracer.TxStart(gas)
+	}
+
+	var result *runtime.ExecutionResult
+	if msg.IsContractCreation() {
+		result = sim.create(msg.From, msg.Input, value, gasLeft, host)
+	} else {
+		sim.txn.IncrNonce(msg.From)
+		result = sim.call(msg.From, *msg.To, msg.Input, value, gasLeft, host)
+	}
+
+	refund := sim.refundGas(gas, result)
+	if opts.CheckBalance {
+		sim.txn.AddBalance(msg.From, new(big.Int).Mul(new(big.Int).SetUint64(result.GasLeft), gasPrice))
+	}
+
+	if sim.tracer != nil {
+		sim.tracer.TxEnd(result.GasLeft)
+	}
+	return result, refund, nil
+}
@@ -10,6 +10,11 @@ import (
 	"github.com/0xPolygon/eth-state-transition/types"
 )
 
+// State is the set of snapshots indexed by their state root
+type State interface {
+	NewSnapshotAt(root types.Hash) (SnapshotWriter, error)
+}
+
 type SnapshotWriter interface {
 	Snapshot
 
@@ -32,6 +37,10 @@ type stateObject struct {
 	Deleted   bool
 	DirtyCode bool
 	Txn       *iradix.Txn
+
+	// storage is the frozen storage of an object inserted in a Txn, the
+	// object can be copied concurrently since the copy does not modify it
+	storage *iradix.Tree
 }
 
 func (s *stateObject) Empty() bool {
@@ -50,7 +59,9 @@ func (s *stateObject) Copy() *stateObject {
 	ss.DirtyCode = s.DirtyCode
 	ss.Code = s.Code
 
-	if s.Txn != nil {
+	if s.storage != nil {
+		ss.Txn = s.storage.Txn()
+	} else if s.Txn != nil {
 		ss.Txn = s.Txn.CommitOnly().Txn()
 	}
 	return ss
@@ -80,11 +91,20 @@ type StorageObject struct {
 }
 
 type Result struct {
-	Logs            []*Log
-	Success         bool
-	GasUsed         uint64
-	ContractAddress types.Address
-	ReturnValue     []byte
+	// Type is the type of the transaction (EIP-2718)
+	Type uint8
+
+	// Root is the intermediate state root after the transaction,
+	// only set for receipts before Byzantium
+	Root              types.Hash
+	CumulativeGasUsed uint64
+	LogsBloom         types.Bloom
+	Logs              []*Log
+	Success           bool
+	TxHash            types.Hash
+	GasUsed           uint64
+	ContractAddress   types.Address
+	ReturnValue       []byte
 }
 
 type Log struct {
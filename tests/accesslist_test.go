@@ -0,0 +1,112 @@
+package tests
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	state "github.com/0xPolygon/eth-state-transition"
+	"github.com/0xPolygon/eth-state-transition/runtime/evm/asm"
+	"github.com/0xPolygon/eth-state-transition/types"
+)
+
+func TestCreateAccessList(t *testing.T) {
+	sender := types.StringToAddress("0x1000")
+	contractA := types.StringToAddress("0x2000")
+	contractB := types.StringToAddress("0x3000")
+	account := types.StringToAddress("0x4000")
+	identity := types.StringToAddress("0x4")
+
+	codeA := asm.MustAssemble(fmt.Sprintf(`
+		PUSH1 0x00 SLOAD POP                                    ; POP(SLOAD(0))
+		PUSH20 %s BALANCE POP                                   ; POP(BALANCE(account))
+		PUSH1 0x00 PUSH1 0x00 PUSH1 0x00 PUSH1 0x00 PUSH1 0x00  ; ret, args and value
+		PUSH20 %s GAS CALL POP                                  ; POP(CALL(GAS, contractB, ...))
+		PUSH1 0x00 PUSH1 0x00 PUSH1 0x00 PUSH1 0x00 PUSH1 0x00  ; ret, args and value
+		PUSH20 %s GAS CALL POP                                  ; POP(CALL(GAS, identity, ...))
+	`, account, contractB, identity))
+
+	allocs := map[types.Address]*GenesisAccount{
+		contractA: {Balance: big.NewInt(0), Code: codeA},
+		contractB: {Balance: big.NewInt(0), Code: asm.MustAssemble(`PUSH1 0x01 SLOAD POP`)}, // POP(SLOAD(1))
+	}
+
+	// the receiver is only included because of its storage and the
+	// precompiled contracts are not included
+	expected := state.AccessList{
+		{Address: contractA, StorageKeys: []types.Hash{types.StringToHash("0x0")}},
+		{Address: contractB, StorageKeys: []types.Hash{types.StringToHash("0x1")}},
+		{Address: account, StorageKeys: []types.Hash{}},
+	}
+
+	listCost := 3*state.TxAccessListAddressGas + 2*state.TxAccessListStorageKeyGas
+
+	// after berlin the accounts and slots in the list are warm, the
+	// receiver is warm anyway
+	warmSavings := uint64(2*(2600-100) + 2*(2100-100))
+
+	cases := []struct {
+		fork string
+		cost func(withoutList uint64) uint64
+	}{
+		{
+			// the list is not paid before berlin
+			fork: "Istanbul",
+			cost: func(withoutList uint64) uint64 {
+				return withoutList
+			},
+		},
+		{
+			fork: "Berlin",
+			cost: func(withoutList uint64) uint64 {
+				return withoutList + listCost - warmSavings
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.fork, func(t *testing.T) {
+			transition := newSimulateTransitionAt(t, c.fork, allocs)
+
+			msg := &state.Transaction{
+				From: sender,
+				To:   &contractA,
+			}
+
+			res, err := transition.CreateAccessList(msg, nil)
+			assert.NoError(t, err)
+			assert.NoError(t, res.Err)
+
+			assert.Equal(t, expected, res.AccessList)
+			assert.Equal(t, c.cost(res.GasUsedWithoutList), res.GasUsed)
+
+			// the accounts in the input list are kept
+			msg.AccessList = state.AccessList{
+				{Address: types.StringToAddress("0x5000")},
+			}
+			res, err = transition.CreateAccessList(msg, nil)
+			assert.NoError(t, err)
+			assert.Len(t, res.AccessList, 4)
+		})
+	}
+}
+
+func TestAccessListIntrinsicGas(t *testing.T) {
+	msg := &state.Transaction{
+		To: &types.Address{},
+		AccessList: state.AccessList{
+			{Address: types.StringToAddress("0x1000"), StorageKeys: []types.Hash{{}}},
+		},
+	}
+
+	// the list is only charged after berlin
+	gas, err := state.TransactionGasCost(msg, true, true, false)
+	assert.NoError(t, err)
+	assert.Equal(t, state.TxGas, gas)
+
+	gas, err = state.TransactionGasCost(msg, true, true, true)
+	assert.NoError(t, err)
+	assert.Equal(t, state.TxGas+state.TxAccessListAddressGas+state.TxAccessListStorageKeyGas, gas)
+}
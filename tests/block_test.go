@@ -0,0 +1,415 @@
+package tests
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	state "github.com/0xPolygon/eth-state-transition"
+	itrie "github.com/0xPolygon/eth-state-transition/immutable-trie"
+	"github.com/0xPolygon/eth-state-transition/runtime"
+	"github.com/0xPolygon/eth-state-transition/types"
+)
+
+var (
+	blockSender   = types.StringToAddress("0x1000")
+	blockReceiver = types.StringToAddress("0x2000")
+	blockMiner    = types.StringToAddress("0x3000")
+)
+
+func ether(n int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(n), big.NewInt(1e18))
+}
+
+// buildBlockState creates the state with the accounts and returns the state root
+func buildBlockState(t *testing.T, allocs map[types.Address]*GenesisAccount) (*itrie.State, types.Hash) {
+	return buildStorageState(itrie.NewMemoryStorage(), allocs)
+}
+
+// buildStorageState creates the state with the accounts on a storage
+func buildStorageState(storage itrie.Storage, allocs map[types.Address]*GenesisAccount) (*itrie.State, types.Hash) {
+	s := itrie.NewArchiveState(storage)
+	txn := state.NewTxn(s.NewSnapshot())
+
+	for addr, alloc := range allocs {
+		txn.CreateAccount(addr)
+		txn.SetNonce(addr, alloc.Nonce)
+		txn.SetBalance(addr, alloc.Balance)
+		if len(alloc.Code) != 0 {
+			txn.SetCode(addr, alloc.Code)
+		}
+	}
+
+	_, root := s.NewSnapshot().Commit(txn.Commit())
+	return s, types.BytesToHash(root)
+}
+
+func newTransferBlock(number uint64) *types.Block {
+	return &types.Block{
+		Header: &types.Header{
+			Miner:      blockMiner,
+			Difficulty: big.NewInt(131072),
+			Number:     number,
+			GasLimit:   1000000,
+		},
+		Transactions: []*types.Transaction{
+			{
+				Nonce:    0,
+				GasPrice: big.NewInt(1),
+				Gas:      21000,
+				To:       &blockReceiver,
+				Value:    big.NewInt(100),
+				From:     blockSender,
+			},
+		},
+	}
+}
+
+// newEmptyBlock returns a block without transactions, only the state root
+// of the header is not valid
+func newEmptyBlock(number uint64, difficulty int64) *types.Block {
+	return &types.Block{
+		Header: &types.Header{
+			Miner:        blockMiner,
+			Difficulty:   big.NewInt(difficulty),
+			Number:       number,
+			GasLimit:     1000000,
+			ReceiptsRoot: state.ReceiptsRoot(nil, true),
+		},
+	}
+}
+
+// sealBlock sets in the header the values computed by the executor
+func sealBlock(block *types.Block, res *state.BlockResult, byzantium bool) {
+	block.Header.GasUsed = res.TotalGas
+	block.Header.StateRoot = res.Root
+	block.Header.ReceiptsRoot = state.ReceiptsRoot(res.Receipts, byzantium)
+	block.Header.LogsBloom = state.CreateBloom(res.Receipts)
+}
+
+func TestApplyBlock(t *testing.T) {
+	rewards := map[string]*big.Int{
+		"Frontier":       ether(5),
+		"Byzantium":      ether(3),
+		"Constantinople": ether(2),
+	}
+	for fork, blockReward := range rewards {
+		fork, blockReward := fork, blockReward
+		t.Run(fork, func(t *testing.T) {
+			s, parentRoot := buildBlockState(t, map[types.Address]*GenesisAccount{
+				blockSender: {Balance: ether(1)},
+			})
+
+			params := &runtime.Params{Forks: Forks[fork], ChainID: 1}
+			executor := state.NewExecutor(params, s)
+
+			block := newTransferBlock(1)
+			res, err := executor.ApplyBlock(parentRoot, block)
+			assert.True(t, errors.Is(err, state.ErrGasUsedMismatch))
+
+			assert.Equal(t, uint64(21000), res.TotalGas)
+			assert.Len(t, res.Receipts, 1)
+			assert.Equal(t, uint64(21000), res.Receipts[0].CumulativeGasUsed)
+			assert.Equal(t, block.Transactions[0].Hash(), res.Receipts[0].TxHash)
+
+			byzantium := params.Forks.IsByzantium(1)
+			if byzantium {
+				assert.True(t, res.Receipts[0].Success)
+			} else {
+				// the receipt has the intermediate state root instead of the status
+				assert.NotEqual(t, types.Hash{}, res.Receipts[0].Root)
+			}
+
+			// the same block with the correct header is valid
+			sealBlock(block, res, byzantium)
+			res2, err := executor.ApplyBlock(parentRoot, block)
+			assert.NoError(t, err)
+			assert.Equal(t, res.Root, res2.Root)
+
+			snap, err := s.NewSnapshotAt(res.Root)
+			assert.NoError(t, err)
+
+			txn := state.NewTxn(snap)
+			assert.Equal(t, big.NewInt(100), txn.GetBalance(blockReceiver))
+			assert.Equal(t, uint64(1), txn.GetNonce(blockSender))
+
+			// reward plus the fees of the transaction
+			reward := new(big.Int).Add(blockReward, big.NewInt(21000))
+			assert.Equal(t, reward, txn.GetBalance(blockMiner))
+
+			// a wrong state root is detected
+			block.Header.StateRoot = types.StringToHash("0x1")
+			_, err = executor.ApplyBlock(parentRoot, block)
+			assert.True(t, errors.Is(err, state.ErrStateRootMismatch))
+		})
+	}
+}
+
+func TestApplyBlockWithdrawals(t *testing.T) {
+	s, parentRoot := buildBlockState(t, map[types.Address]*GenesisAccount{
+		blockSender: {Balance: ether(1)},
+	})
+
+	params := &runtime.Params{Forks: Forks["Istanbul"], ChainID: 1}
+	executor := state.NewExecutor(params, s)
+
+	block := newTransferBlock(1)
+	block.Header.Difficulty = big.NewInt(0)
+	block.Header.WithdrawalsRoot = &types.Hash{}
+	block.Withdrawals = []*types.Withdrawal{
+		{Index: 0, ValidatorIndex: 1, Address: blockReceiver, Amount: 10},
+	}
+
+	res, err := executor.ApplyBlock(parentRoot, block)
+	assert.True(t, errors.Is(err, state.ErrGasUsedMismatch))
+
+	snap, err := s.NewSnapshotAt(res.Root)
+	assert.NoError(t, err)
+
+	// no reward is paid, only the fees
+	txn := state.NewTxn(snap)
+	assert.Equal(t, big.NewInt(21000), txn.GetBalance(blockMiner))
+	assert.Equal(t, big.NewInt(10*1e9+100), txn.GetBalance(blockReceiver))
+}
+
+func TestApplyBlockUncleRewards(t *testing.T) {
+	s, parentRoot := buildBlockState(t, map[types.Address]*GenesisAccount{})
+
+	params := &runtime.Params{Forks: Forks["Byzantium"], ChainID: 1}
+	executor := state.NewExecutor(params, s)
+
+	uncleMiner1 := types.StringToAddress("0x4000")
+	uncleMiner2 := types.StringToAddress("0x5000")
+
+	block := newEmptyBlock(10, 131072)
+	block.Uncles = []*types.Header{
+		{Miner: uncleMiner1, Number: 9},
+		{Miner: uncleMiner2, Number: 4},
+	}
+
+	res, err := executor.ApplyBlock(parentRoot, block)
+	assert.True(t, errors.Is(err, state.ErrStateRootMismatch))
+
+	snap, err := s.NewSnapshotAt(res.Root)
+	assert.NoError(t, err)
+
+	txn := state.NewTxn(snap)
+
+	// 3 ETH plus 1/32 of it for each uncle
+	reward := new(big.Int).Add(ether(3), new(big.Int).Div(ether(6), big.NewInt(32)))
+	assert.Equal(t, reward, txn.GetBalance(blockMiner))
+
+	// the uncles get 7/8 and 2/8 of the block reward
+	assert.Equal(t, new(big.Int).Div(ether(21), big.NewInt(8)), txn.GetBalance(uncleMiner1))
+	assert.Equal(t, new(big.Int).Div(ether(6), big.NewInt(8)), txn.GetBalance(uncleMiner2))
+}
+
+func TestApplyBlockInvalidUncle(t *testing.T) {
+	s, parentRoot := buildBlockState(t, map[types.Address]*GenesisAccount{})
+
+	params := &runtime.Params{Forks: Forks["Byzantium"], ChainID: 1}
+	executor := state.NewExecutor(params, s)
+
+	// the uncles must be one of the 6 blocks before the block
+	for _, number := range []uint64{1, 2, 3, 10, 11} {
+		block := newEmptyBlock(10, 131072)
+		block.Uncles = []*types.Header{
+			{Miner: types.StringToAddress("0x4000"), Number: number},
+		}
+
+		_, err := executor.ApplyBlock(parentRoot, block)
+		assert.True(t, errors.Is(err, state.ErrInvalidUncle), number)
+	}
+
+	// the valid uncles only fail the check of the state root
+	for _, c := range []struct{ number, uncle uint64 }{
+		{10, 4},
+		{10, 9},
+		// the uncles of the first blocks are not before the genesis
+		{3, 0},
+	} {
+		block := newEmptyBlock(c.number, 131072)
+		block.Uncles = []*types.Header{
+			{Miner: types.StringToAddress("0x4000"), Number: c.uncle},
+		}
+		_, err := executor.ApplyBlock(parentRoot, block)
+		assert.True(t, errors.Is(err, state.ErrStateRootMismatch), c.uncle)
+	}
+}
+
+func TestApplyBlockRewardSchedule(t *testing.T) {
+	cases := []struct {
+		name       string
+		fork       string
+		schedule   *runtime.RewardSchedule
+		difficulty int64
+		reward     *big.Int
+	}{
+		{
+			// proof-of-stake blocks have no reward
+			name:       "Merge",
+			fork:       "Istanbul",
+			difficulty: 0,
+			reward:     big.NewInt(0),
+		},
+		{
+			// forks without a reward keep the previous one
+			name:       "Inherited",
+			fork:       "Istanbul",
+			schedule:   &runtime.RewardSchedule{Frontier: ether(1)},
+			difficulty: 1,
+			reward:     ether(1),
+		},
+		{
+			name: "Override",
+			fork: "Byzantium",
+			schedule: &runtime.RewardSchedule{
+				Frontier:  ether(1),
+				Byzantium: ether(4),
+			},
+			difficulty: 1,
+			reward:     ether(4),
+		},
+		{
+			name:       "Disabled",
+			fork:       "Frontier",
+			schedule:   &runtime.RewardSchedule{},
+			difficulty: 1,
+			reward:     big.NewInt(0),
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			s, parentRoot := buildBlockState(t, map[types.Address]*GenesisAccount{})
+
+			params := &runtime.Params{Forks: Forks[c.fork], ChainID: 1, Rewards: c.schedule}
+			executor := state.NewExecutor(params, s)
+
+			res, err := executor.ApplyBlock(parentRoot, newEmptyBlock(1, c.difficulty))
+			assert.True(t, errors.Is(err, state.ErrStateRootMismatch))
+
+			snap, err := s.NewSnapshotAt(res.Root)
+			assert.NoError(t, err)
+
+			txn := state.NewTxn(snap)
+			assert.Equal(t, c.reward, txn.GetBalance(blockMiner))
+		})
+	}
+}
+
+func TestApplyBlockDAOFork(t *testing.T) {
+	drain1, drain2 := state.DAODrainList[0], state.DAODrainList[1]
+
+	s, parentRoot := buildBlockState(t, map[types.Address]*GenesisAccount{
+		drain1:                  {Balance: ether(1)},
+		drain2:                  {Balance: ether(2)},
+		state.DAORefundContract: {Balance: ether(3)},
+	})
+
+	forks := *Forks["Homestead"]
+	forks.DAOFork = runtime.NewFork(5)
+
+	params := &runtime.Params{Forks: &forks, ChainID: 1, Rewards: &runtime.RewardSchedule{}}
+	executor := state.NewExecutor(params, s)
+
+	balances := func(root types.Hash) []string {
+		snap, err := s.NewSnapshotAt(root)
+		assert.NoError(t, err)
+
+		txn := state.NewTxn(snap)
+		return []string{
+			txn.GetBalance(drain1).String(),
+			txn.GetBalance(drain2).String(),
+			txn.GetBalance(state.DAORefundContract).String(),
+		}
+	}
+	unchanged := []string{ether(1).String(), ether(2).String(), ether(3).String()}
+
+	// the blocks before the fork do not move the balances
+	res, err := executor.ApplyBlock(parentRoot, newEmptyBlock(4, 1))
+	assert.True(t, errors.Is(err, state.ErrStateRootMismatch))
+	assert.Equal(t, unchanged, balances(res.Root))
+
+	// the fork block requires the extra-data
+	_, err = executor.ApplyBlock(parentRoot, newEmptyBlock(5, 1))
+	assert.True(t, errors.Is(err, state.ErrDAOForkExtraData))
+
+	block := newEmptyBlock(5, 1)
+	block.Header.ExtraData = []byte("dao-hard-fork")
+
+	res, err = executor.ApplyBlock(parentRoot, block)
+	assert.True(t, errors.Is(err, state.ErrStateRootMismatch))
+	assert.Equal(t, []string{"0", "0", ether(6).String()}, balances(res.Root))
+
+	// the following blocks do not move the balances again
+	block = newEmptyBlock(6, 1)
+	block.Header.ExtraData = []byte("dao-hard-fork")
+
+	res, err = executor.ApplyBlock(parentRoot, block)
+	assert.True(t, errors.Is(err, state.ErrStateRootMismatch))
+	assert.Equal(t, unchanged, balances(res.Root))
+
+	// the extra-data is only required in the ten blocks from the fork
+	_, err = executor.ApplyBlock(parentRoot, newEmptyBlock(14, 1))
+	assert.True(t, errors.Is(err, state.ErrDAOForkExtraData))
+
+	_, err = executor.ApplyBlock(parentRoot, newEmptyBlock(15, 1))
+	assert.True(t, errors.Is(err, state.ErrStateRootMismatch))
+}
+
+func TestApplyBlockInvalidTransaction(t *testing.T) {
+	s, parentRoot := buildBlockState(t, map[types.Address]*GenesisAccount{
+		blockSender: {Balance: ether(1)},
+	})
+
+	params := &runtime.Params{Forks: Forks["Byzantium"], ChainID: 1}
+	executor := state.NewExecutor(params, s)
+
+	block := newTransferBlock(1)
+	block.Transactions[0].Nonce = 10
+
+	_, err := executor.ApplyBlock(parentRoot, block)
+	assert.Error(t, err)
+}
+
+func TestApplyBlockAccessListTransaction(t *testing.T) {
+	s, parentRoot := buildBlockState(t, map[types.Address]*GenesisAccount{
+		blockSender: {Balance: ether(1)},
+	})
+
+	params := &runtime.Params{Forks: Forks["Berlin"], ChainID: 1}
+	executor := state.NewExecutor(params, s)
+
+	block := newTransferBlock(1)
+	txn := block.Transactions[0]
+	txn.Type = types.AccessListTxType
+	txn.ChainID = big.NewInt(1)
+	txn.Gas = 25000
+	txn.AccessList = types.AccessList{
+		{Address: blockReceiver},
+	}
+
+	res, err := executor.ApplyBlock(parentRoot, block)
+	assert.True(t, errors.Is(err, state.ErrGasUsedMismatch))
+
+	// the intrinsic gas includes the address of the access list
+	assert.Equal(t, uint64(21000+2400), res.TotalGas)
+	assert.Equal(t, uint8(types.AccessListTxType), res.Receipts[0].Type)
+	assert.Equal(t, txn.Hash(), res.Receipts[0].TxHash)
+
+	// the receipt is encoded with the type of the transaction
+	receipt := res.Receipts[0].MarshalRlpTo(nil, true)
+	assert.Equal(t, byte(types.AccessListTxType), receipt[0])
+
+	sealBlock(block, res, true)
+	_, err = executor.ApplyBlock(parentRoot, block)
+	assert.NoError(t, err)
+
+	// the receipts root changes with the type of the receipt
+	res.Receipts[0].Type = types.LegacyTxType
+	assert.NotEqual(t, block.Header.ReceiptsRoot, state.ReceiptsRoot(res.Receipts, true))
+}
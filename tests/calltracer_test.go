@@ -0,0 +1,127 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	state "github.com/0xPolygon/eth-state-transition"
+	"github.com/0xPolygon/eth-state-transition/runtime"
+	"github.com/0xPolygon/eth-state-transition/runtime/evm/asm"
+	"github.com/0xPolygon/eth-state-transition/tracer"
+	"github.com/0xPolygon/eth-state-transition/types"
+)
+
+func TestCallTracer(t *testing.T) {
+	sender := types.StringToAddress("0x1000")
+	contract := types.StringToAddress("0x2000")
+	setter := types.StringToAddress("0x3000")
+	reverter := types.StringToAddress("0x4000")
+	beneficiary := types.StringToAddress("0x5000")
+
+	code := asm.MustAssemble(fmt.Sprintf(`
+		PUSH1 0x00 PUSH1 0x00 PUSH1 0x00 PUSH1 0x00 PUSH1 0x01  ; ret, args and value
+		PUSH20 %s GAS CALL POP                                  ; POP(CALL(GAS, setter, 1, ...))
+		PUSH1 0x00 PUSH1 0x00 PUSH1 0x00 PUSH1 0x00             ; ret and args
+		PUSH20 %s GAS STATICCALL POP                            ; POP(STATICCALL(GAS, reverter, ...))
+		PUSH20 %s SELFDESTRUCT                                  ; SELFDESTRUCT(beneficiary)
+	`, setter, reverter, beneficiary))
+
+	transition := newSimulateTransition(t, map[types.Address]*GenesisAccount{
+		sender:   {Balance: ether(1)},
+		contract: {Balance: big.NewInt(10), Code: code},
+		setter:   {Balance: big.NewInt(0), Code: setterCode},
+		reverter: {Balance: big.NewInt(0), Code: revertWithData(encodeRevertReason("boom"))},
+	})
+
+	callTracer := tracer.NewCallTracer()
+	transition.SetTracer(callTracer)
+
+	msg := &state.Transaction{
+		From:     sender,
+		To:       &contract,
+		Input:    []byte{0x1},
+		Gas:      100000,
+		GasPrice: big.NewInt(1),
+		Value:    big.NewInt(0),
+	}
+	res, err := transition.Write(msg)
+	assert.NoError(t, err)
+	assert.True(t, res.Success)
+
+	root := callTracer.Result()
+	assert.Equal(t, runtime.Call, root.Type)
+	assert.Equal(t, sender, root.From)
+	assert.Equal(t, contract, root.To)
+	assert.Equal(t, []byte{0x1}, root.Input)
+	assert.Equal(t, uint64(100000), root.Gas)
+	assert.Equal(t, res.GasUsed, root.GasUsed)
+	assert.NoError(t, root.Err)
+
+	assert.Len(t, root.Calls, 3)
+	call, static, selfdestruct := root.Calls[0], root.Calls[1], root.Calls[2]
+
+	assert.Equal(t, runtime.Call, call.Type)
+	assert.Equal(t, contract, call.From)
+	assert.Equal(t, setter, call.To)
+	assert.Equal(t, "1", call.Value.String())
+	assert.Equal(t, types.StringToHash("0x1").Bytes(), call.Output)
+	assert.Empty(t, call.Calls)
+
+	assert.Equal(t, runtime.StaticCall, static.Type)
+	assert.Equal(t, runtime.ErrExecutionReverted, static.Err)
+	assert.Equal(t, "boom", static.RevertReason)
+	assert.Nil(t, static.Value)
+
+	assert.Equal(t, runtime.Selfdestruct, selfdestruct.Type)
+	assert.Equal(t, contract, selfdestruct.From)
+	assert.Equal(t, beneficiary, selfdestruct.To)
+	assert.Equal(t, "9", selfdestruct.Value.String())
+
+	data, err := json.Marshal(selfdestruct)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"type": "SELFDESTRUCT",
+		"from": "0x0000000000000000000000000000000000002000",
+		"to": "0x0000000000000000000000000000000000005000",
+		"value": "0x9",
+		"gas": "0x0",
+		"gasUsed": "0x0",
+		"input": "0x"
+	}`, string(data))
+
+	data, err = json.Marshal(static)
+	assert.NoError(t, err)
+
+	var frame map[string]interface{}
+	assert.NoError(t, json.Unmarshal(data, &frame))
+	assert.Equal(t, "STATICCALL", frame["type"])
+	assert.Equal(t, "boom", frame["revertReason"])
+	assert.Equal(t, runtime.ErrExecutionReverted.Error(), frame["error"])
+	assert.NotContains(t, frame, "value")
+}
+
+func TestCallFrameFailedCreate(t *testing.T) {
+	frame := &tracer.CallFrame{
+		Type: runtime.Create,
+		From: types.StringToAddress("0x1000"),
+		To:   types.StringToAddress("0x2000"),
+		Gas:  100,
+		Err:  runtime.ErrOutOfGas,
+	}
+
+	// the address of a failed creation is not included
+	data, err := json.Marshal(frame)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"type": "CREATE",
+		"from": "0x0000000000000000000000000000000000001000",
+		"gas": "0x64",
+		"gasUsed": "0x0",
+		"input": "0x",
+		"error": "out of gas"
+	}`, string(data))
+}
@@ -0,0 +1,165 @@
+package tests
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	state "github.com/0xPolygon/eth-state-transition"
+	"github.com/0xPolygon/eth-state-transition/helper"
+	"github.com/0xPolygon/eth-state-transition/tracer"
+	"github.com/0xPolygon/eth-state-transition/types"
+)
+
+func TestCoverage(t *testing.T) {
+	sender := types.StringToAddress("0x1000")
+	caller := types.StringToAddress("0x2000")
+	setter := types.StringToAddress("0x3000")
+	revertCaller := types.StringToAddress("0x4000")
+	reverter := types.StringToAddress("0x5000")
+
+	transition := newSimulateTransition(t, map[types.Address]*GenesisAccount{
+		sender:       {Balance: ether(1)},
+		caller:       {Balance: big.NewInt(0), Code: callAndCheck(setter)},
+		setter:       {Balance: big.NewInt(0), Code: setterCode},
+		revertCaller: {Balance: big.NewInt(0), Code: callAndCheck(reverter)},
+		reverter:     {Balance: big.NewInt(0), Code: revertCode},
+	})
+
+	coverage := tracer.NewCoverage()
+	transition.SetTracer(coverage)
+
+	// the coverage accumulates over the transactions
+	for i, to := range []types.Address{caller, caller, revertCaller} {
+		to := to
+		msg := &state.Transaction{
+			From:     sender,
+			To:       &to,
+			Nonce:    uint64(i),
+			Gas:      100000,
+			GasPrice: big.NewInt(1),
+			Value:    big.NewInt(0),
+		}
+		_, err := transition.Write(msg)
+		assert.NoError(t, err)
+	}
+
+	unused := []byte{0x60, 0x00, 0x00}
+	unusedHash := coverage.AddCode(unused)
+
+	hash := func(code []byte) types.Hash {
+		return types.BytesToHash(helper.Keccak256(code))
+	}
+	codes := coverage.Codes()
+	assert.Len(t, codes, 5)
+
+	setterCov := codes[hash(setterCode)]
+	assert.Len(t, setterCov.Instructions(), 10)
+	assert.Equal(t, uint64(2), setterCov.Hits[0])
+	assert.Len(t, setterCov.Branches, 0)
+
+	// the JUMPI of the caller jumps if the call succeeds
+	callerCov := codes[hash(callAndCheck(setter))]
+	assert.Equal(t, &tracer.BranchCoverage{Taken: 2}, callerCov.Branches[35])
+	assert.Equal(t, uint64(2), callerCov.Hits[40])
+	assert.Equal(t, uint64(0), callerCov.Hits[36])
+
+	revertCallerCov := codes[hash(callAndCheck(reverter))]
+	assert.Equal(t, &tracer.BranchCoverage{NotTaken: 1}, revertCallerCov.Branches[35])
+	assert.Equal(t, uint64(1), revertCallerCov.Hits[39])
+
+	assert.Len(t, codes[unusedHash].Hits, 0)
+
+	var buf bytes.Buffer
+	assert.NoError(t, coverage.WriteReport(&buf))
+
+	report := buf.String()
+	assert.Contains(t, report, fmt.Sprintf("code %s: 10/10 instructions\n", hash(setterCode)))
+	assert.Contains(t, report, fmt.Sprintf("code %s: 12/15 instructions\n", hash(callAndCheck(setter))))
+	assert.Contains(t, report, fmt.Sprintf("code %s: 0/2 instructions\n", unusedHash))
+	assert.Contains(t, report, "    35 JUMPI          2 taken 2 not taken 0\n")
+}
+
+func TestCoverageLCOV(t *testing.T) {
+	sender := types.StringToAddress("0x1000")
+	caller := types.StringToAddress("0x2000")
+	setter := types.StringToAddress("0x3000")
+
+	transition := newSimulateTransition(t, map[types.Address]*GenesisAccount{
+		sender: {Balance: ether(1)},
+		caller: {Balance: big.NewInt(0), Code: callAndCheck(setter)},
+		setter: {Balance: big.NewInt(0), Code: setterCode},
+	})
+
+	coverage := tracer.NewCoverage()
+	transition.SetTracer(coverage)
+
+	msg := &state.Transaction{
+		From:     sender,
+		To:       &caller,
+		Gas:      100000,
+		GasPrice: big.NewInt(1),
+		Value:    big.NewInt(0),
+	}
+	_, err := transition.Write(msg)
+	assert.NoError(t, err)
+
+	setterSource := &tracer.Source{
+		Name:    "Setter.sol",
+		Content: []byte("contract Setter {\n  x = 1;\n  return x;\n}\n"),
+	}
+	callerSource := &tracer.Source{
+		Name:    "Caller.sol",
+		Content: []byte("contract Caller {\n  require(setter.call());\n}\n"),
+	}
+
+	// the store is in the line 2 and the return in the line 3
+	store := strings.Index(string(setterSource.Content), "x = 1")
+	ret := strings.Index(string(setterSource.Content), "return")
+	setterMap, err := tracer.ParseSourceMap(fmt.Sprintf("%d:5:0:-;;;;;%d:9;;;;", store, ret), []*tracer.Source{setterSource})
+	assert.NoError(t, err)
+	assert.Equal(t, tracer.SourceMapEntry{Start: ret, Length: 9, File: 0, Jump: '-'}, setterMap.Entries[9])
+
+	// all the instructions of the caller are in the line 2 but the ones
+	// generated by the compiler (the revert)
+	require := strings.Index(string(callerSource.Content), "require")
+	callerSourceMap := fmt.Sprintf("%d:22:0:-", require) + strings.Repeat(";", 9) + ";::-1;;;::0:i;"
+	callerMap, err := tracer.ParseSourceMap(callerSourceMap, []*tracer.Source{callerSource})
+	assert.NoError(t, err)
+
+	_, err = tracer.ParseSourceMap("0:1:x", nil)
+	assert.Error(t, err)
+
+	var buf bytes.Buffer
+	assert.NoError(t, coverage.WriteLCOV(&buf, map[types.Hash]*tracer.SourceMap{
+		types.BytesToHash(helper.Keccak256(setterCode)):           setterMap,
+		types.BytesToHash(helper.Keccak256(callAndCheck(setter))): callerMap,
+	}))
+
+	assert.Equal(t, strings.Join([]string{
+		"TN:",
+		"SF:Caller.sol",
+		"BRDA:2,35,0,1",
+		"BRDA:2,35,1,0",
+		"BRF:2",
+		"BRH:1",
+		"DA:2,1",
+		"LF:1",
+		"LH:1",
+		"end_of_record",
+		"TN:",
+		"SF:Setter.sol",
+		"BRF:0",
+		"BRH:0",
+		"DA:2,1",
+		"DA:3,1",
+		"LF:2",
+		"LH:2",
+		"end_of_record",
+		"",
+	}, "\n"), buf.String())
+}
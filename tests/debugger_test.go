@@ -0,0 +1,255 @@
+package tests
+
+import (
+	"bytes"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	state "github.com/0xPolygon/eth-state-transition"
+	"github.com/0xPolygon/eth-state-transition/debugger"
+	"github.com/0xPolygon/eth-state-transition/runtime/evm"
+	"github.com/0xPolygon/eth-state-transition/types"
+)
+
+func newDebuggerTransition(t *testing.T) (*state.Transition, *state.Transaction) {
+	sender := types.StringToAddress("0x1000")
+	caller := types.StringToAddress("0x2000")
+	setter := types.StringToAddress("0x3000")
+
+	transition := newSimulateTransition(t, map[types.Address]*GenesisAccount{
+		sender: {Balance: ether(1)},
+		caller: {Balance: big.NewInt(0), Code: callAndCheck(setter)},
+		setter: {Balance: big.NewInt(0), Code: setterCode},
+	})
+
+	msg := &state.Transaction{
+		From:     sender,
+		To:       &caller,
+		Gas:      100000,
+		GasPrice: big.NewInt(1),
+		Value:    big.NewInt(0),
+	}
+	return transition, msg
+}
+
+func TestDebuggerStep(t *testing.T) {
+	caller := types.StringToAddress("0x2000")
+	setter := types.StringToAddress("0x3000")
+
+	transition, msg := newDebuggerTransition(t)
+
+	d := debugger.New()
+	transition.SetTracer(d)
+
+	var (
+		res   *state.Result
+		txErr error
+	)
+	stop, err := d.Run(func() {
+		res, txErr = transition.Write(msg)
+	})
+	assert.NoError(t, err)
+
+	// the execution pauses before the first opcode
+	assert.Equal(t, debugger.StopStep, stop.Reason)
+	assert.Equal(t, uint64(0), stop.PC)
+	assert.Equal(t, 1, stop.Depth)
+	assert.Equal(t, caller, stop.Address)
+
+	_, err = d.Run(func() {})
+	assert.Equal(t, debugger.ErrRunning, err)
+
+	stop, err = d.Step()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), stop.PC)
+
+	// the address breakpoint stops at the first opcode of the frame
+	id, err := d.AddBreakpoint(&debugger.Breakpoint{Kind: debugger.BreakAddress, Address: &setter})
+	assert.NoError(t, err)
+
+	stop, err = d.Continue()
+	assert.NoError(t, err)
+	assert.Equal(t, debugger.StopBreakpoint, stop.Reason)
+	assert.Equal(t, id, stop.Breakpoint)
+	assert.Equal(t, uint64(0), stop.PC)
+	assert.Equal(t, 2, stop.Depth)
+	assert.Equal(t, setter, stop.Address)
+
+	stop, _ = d.Step()
+	stop, _ = d.Step()
+	assert.Equal(t, evm.OpCode(evm.SSTORE), stop.Op)
+
+	stack, err := d.Stack()
+	assert.NoError(t, err)
+	assert.Equal(t, []*big.Int{big.NewInt(1), big.NewInt(0)}, stack)
+
+	// the value stored is changed to 7
+	assert.NoError(t, d.SetStack(1, big.NewInt(7)))
+	assert.Equal(t, debugger.ErrStackOutOfRange, d.SetStack(2, big.NewInt(7)))
+	assert.Equal(t, debugger.ErrInvalidValue, d.SetStack(0, big.NewInt(-1)))
+
+	// the copy of the stack is not modified
+	assert.Equal(t, big.NewInt(1), stack[0])
+
+	// step out returns to the opcode after the call
+	stop, err = d.StepOut()
+	assert.NoError(t, err)
+	assert.Equal(t, debugger.StopStep, stop.Reason)
+	assert.Equal(t, uint64(33), stop.PC)
+	assert.Equal(t, 1, stop.Depth)
+
+	stop, err = d.Continue()
+	assert.NoError(t, err)
+	assert.Equal(t, debugger.StopDone, stop.Reason)
+
+	assert.NoError(t, txErr)
+	assert.True(t, res.Success)
+	assert.Equal(t, types.BytesToHash([]byte{7}), transition.Txn().GetState(setter, types.Hash{}))
+
+	_, err = d.Step()
+	assert.Equal(t, debugger.ErrNotPaused, err)
+	_, err = d.Stack()
+	assert.Equal(t, debugger.ErrNotPaused, err)
+}
+
+func TestDebuggerStepOver(t *testing.T) {
+	caller := types.StringToAddress("0x2000")
+
+	transition, msg := newDebuggerTransition(t)
+
+	d := debugger.New()
+	transition.SetTracer(d)
+
+	_, err := d.Run(func() {
+		transition.Write(msg)
+	})
+	assert.NoError(t, err)
+
+	_, err = d.AddBreakpoint(&debugger.Breakpoint{Kind: debugger.BreakPC, PC: 32, Address: &caller})
+	assert.NoError(t, err)
+
+	stop, err := d.Continue()
+	assert.NoError(t, err)
+	assert.Equal(t, debugger.StopBreakpoint, stop.Reason)
+	assert.Equal(t, evm.OpCode(evm.CALL), stop.Op)
+
+	// step over does not pause in the frame of the call
+	stop, err = d.StepOver()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(33), stop.PC)
+	assert.Equal(t, 1, stop.Depth)
+
+	assert.NoError(t, d.Detach())
+}
+
+func TestDebuggerBreakpoints(t *testing.T) {
+	setter := types.StringToAddress("0x3000")
+
+	transition, msg := newDebuggerTransition(t)
+
+	d := debugger.New()
+	transition.SetTracer(d)
+
+	_, err := d.AddBreakpoint(&debugger.Breakpoint{Kind: debugger.BreakAddress})
+	assert.Equal(t, debugger.ErrInvalidBreakpoint, err)
+
+	slot := types.Hash{}
+	store, err := d.AddBreakpoint(&debugger.Breakpoint{Kind: debugger.BreakStorageWrite, Key: &slot})
+	assert.NoError(t, err)
+	ret, err := d.AddBreakpoint(&debugger.Breakpoint{Kind: debugger.BreakOp, Op: evm.RETURN})
+	assert.NoError(t, err)
+
+	var res *state.Result
+	_, err = d.Run(func() {
+		res, _ = transition.Write(msg)
+	})
+	assert.NoError(t, err)
+
+	stop, err := d.Continue()
+	assert.NoError(t, err)
+	assert.Equal(t, store, stop.Breakpoint)
+	assert.Equal(t, uint64(4), stop.PC)
+	assert.Equal(t, setter, stop.Address)
+
+	value, err := d.Storage(slot)
+	assert.NoError(t, err)
+	assert.Equal(t, types.Hash{}, value)
+
+	one := types.BytesToHash([]byte{1})
+	assert.NoError(t, d.SetStorage(one, types.BytesToHash([]byte{5})))
+
+	value, err = d.Storage(one)
+	assert.NoError(t, err)
+	assert.Equal(t, types.BytesToHash([]byte{5}), value)
+
+	stop, err = d.Continue()
+	assert.NoError(t, err)
+	assert.Equal(t, ret, stop.Breakpoint)
+	assert.Equal(t, uint64(15), stop.PC)
+
+	memory, err := d.Memory()
+	assert.NoError(t, err)
+	assert.Equal(t, types.BytesToHash([]byte{1}).Bytes(), memory)
+
+	// the memory is not expanded
+	assert.NoError(t, d.SetMemory(31, []byte{0x2a}))
+	assert.Equal(t, debugger.ErrMemoryOutOfRange, d.SetMemory(31, []byte{1, 2}))
+
+	assert.NoError(t, d.RemoveBreakpoint(ret))
+	assert.Equal(t, debugger.ErrBreakpointNotFound, d.RemoveBreakpoint(ret))
+	assert.Len(t, d.Breakpoints(), 1)
+
+	stop, err = d.Continue()
+	assert.NoError(t, err)
+	assert.Equal(t, debugger.StopDone, stop.Reason)
+
+	assert.True(t, res.Success)
+	assert.Equal(t, types.BytesToHash([]byte{5}), transition.Txn().GetState(setter, one))
+}
+
+func TestDebuggerREPL(t *testing.T) {
+	transition, msg := newDebuggerTransition(t)
+
+	d := debugger.New()
+	transition.SetTracer(d)
+
+	input := strings.Join([]string{
+		"break op sstore",
+		"break pc 0x20 0x2000",
+		"breakpoints",
+		"c",
+		"delete 2",
+		"c",
+		"stack",
+		"setstack 0 1",
+		"storage 0x1",
+		"foo",
+		"q",
+	}, "\n")
+
+	var res *state.Result
+	out := &bytes.Buffer{}
+
+	repl := debugger.NewREPL(d, strings.NewReader(input), out)
+	assert.NoError(t, repl.Run(func() {
+		res, _ = transition.Write(msg)
+	}))
+
+	output := out.String()
+	assert.Contains(t, output, "breakpoint 1: op SSTORE")
+	assert.Contains(t, output, "breakpoint 2: pc 32 0x0000000000000000000000000000000000002000")
+	assert.Contains(t, output, "breakpoint 2: pc 32 CALL")
+	assert.Contains(t, output, "breakpoint 1: pc 4 SSTORE")
+	assert.Contains(t, output, "0: 0x0\n1: 0x1\n")
+	assert.Contains(t, output, "error: unknown command 'foo'")
+	assert.Contains(t, output, "execution finished")
+
+	// the slot 1 is set instead of the slot 0
+	setter := types.StringToAddress("0x3000")
+	assert.True(t, res.Success)
+	assert.Equal(t, types.Hash{}, transition.Txn().GetState(setter, types.Hash{}))
+	assert.Equal(t, types.BytesToHash([]byte{1}), transition.Txn().GetState(setter, types.BytesToHash([]byte{1})))
+}
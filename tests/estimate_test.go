@@ -0,0 +1,157 @@
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	state "github.com/0xPolygon/eth-state-transition"
+	"github.com/0xPolygon/eth-state-transition/helper"
+	"github.com/0xPolygon/eth-state-transition/runtime"
+	"github.com/0xPolygon/eth-state-transition/runtime/evm/asm"
+	"github.com/0xPolygon/eth-state-transition/types"
+)
+
+// revertWithData returns a contract that reverts with the data
+func revertWithData(data []byte) []byte {
+	return asm.MustAssemble(fmt.Sprintf(`
+		PUSH1 %d PUSH1 @data PUSH1 0x00 CODECOPY  ; CODECOPY(0, data, len)
+		PUSH1 %d PUSH1 0x00 REVERT                ; REVERT(0, len)
+	data:
+		#data %s
+	`, len(data), len(data), helper.EncodeToHex(data)))
+}
+
+// encodeRevertReason encodes a short revert reason as Error(string)
+func encodeRevertReason(reason string) []byte {
+	data := []byte{0x08, 0xc3, 0x79, 0xa0}
+	data = append(data, types.StringToHash("0x20").Bytes()...)
+	data = append(data, types.BytesToHash([]byte{byte(len(reason))}).Bytes()...)
+	return append(data, append([]byte(reason), make([]byte, 32-len(reason))...)...)
+}
+
+// callAndCheck returns a contract that calls the address with all
+// the gas and reverts if the call fails
+func callAndCheck(addr types.Address) []byte {
+	return asm.MustAssemble(fmt.Sprintf(`
+		PUSH1 0x00 PUSH1 0x00 PUSH1 0x00 PUSH1 0x00 PUSH1 0x00  ; ret, args and value
+		PUSH20 %s GAS CALL                                      ; CALL(GAS, addr, ...)
+		PUSH1 @success JUMPI                                    ; JUMPI(success, result)
+		PUSH1 0x00 DUP1 REVERT                                  ; REVERT(0, 0)
+	success:
+		JUMPDEST STOP
+	`, addr))
+}
+
+func TestEstimateGas(t *testing.T) {
+	sender := types.StringToAddress("0x1000")
+	receiver := types.StringToAddress("0x2000")
+	clearer := types.StringToAddress("0x3000")
+	caller := types.StringToAddress("0x4000")
+	setter := types.StringToAddress("0x5000")
+
+	transition := newSimulateTransition(t, map[types.Address]*GenesisAccount{
+		sender: {Balance: ether(1)},
+		clearer: {
+			Balance: big.NewInt(0),
+			Code:    asm.MustAssemble(`PUSH1 0x00 PUSH1 0x00 SSTORE`), // SSTORE(0, 0)
+			Storage: map[types.Hash]types.Hash{
+				{}: types.StringToHash("0x5"),
+			},
+		},
+		caller: {Balance: big.NewInt(0), Code: callAndCheck(setter)},
+		setter: {Balance: big.NewInt(0), Code: setterCode},
+	})
+
+	cases := []struct {
+		name string
+		to   types.Address
+	}{
+		{"Transfer", receiver},
+		{"Refund", clearer},
+		{"NestedCall", caller},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			to := c.to
+			msg := &state.Transaction{
+				From:     sender,
+				To:       &to,
+				GasPrice: big.NewInt(1),
+				Value:    big.NewInt(1),
+			}
+
+			gas, err := transition.EstimateGas(msg, nil)
+			assert.NoError(t, err)
+
+			// the transaction succeeds with the estimated gas but not with less
+			msg.Gas = gas
+			res, err := transition.Simulate(msg, nil)
+			assert.NoError(t, err)
+			assert.NoError(t, res.Err)
+
+			msg.Gas = gas - 1
+			res, err = transition.Simulate(msg, nil)
+			if err == nil {
+				assert.Error(t, res.Err)
+			}
+		})
+	}
+
+	// a transfer only needs the intrinsic gas
+	gas, err := transition.EstimateGas(&state.Transaction{From: sender, To: &receiver}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, state.TxGas, gas)
+
+	// the refund is only paid at the end of the transaction
+	gas, err = transition.EstimateGas(&state.Transaction{From: sender, To: &clearer}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(21000+3+3+5000), gas)
+}
+
+func TestEstimateGasFailures(t *testing.T) {
+	sender := types.StringToAddress("0x1000")
+	reverter := types.StringToAddress("0x2000")
+	looper := types.StringToAddress("0x3000")
+	setter := types.StringToAddress("0x4000")
+
+	reason := encodeRevertReason("boom")
+
+	transition := newSimulateTransition(t, map[types.Address]*GenesisAccount{
+		sender:   {Balance: big.NewInt(30000)},
+		reverter: {Balance: big.NewInt(0), Code: revertWithData(reason)},
+		looper: {Balance: big.NewInt(0), Code: asm.MustAssemble(`
+		loop:
+			JUMPDEST PUSH @loop JUMP  ; JUMP(loop)
+		`)},
+		setter: {Balance: big.NewInt(0), Code: setterCode},
+	})
+
+	// the revert reason is decoded
+	_, err := transition.EstimateGas(&state.Transaction{From: sender, To: &reverter}, nil)
+	assert.True(t, errors.Is(err, runtime.ErrExecutionReverted))
+	assert.True(t, strings.HasSuffix(err.Error(), ": boom"))
+
+	// the transaction runs out of gas with the block gas limit
+	_, err = transition.EstimateGas(&state.Transaction{From: sender, To: &looper}, nil)
+	assert.True(t, errors.Is(err, state.ErrGasRequiredExceedsAllowance))
+
+	// the funds of the sender only cover 30000 gas
+	msg := &state.Transaction{From: sender, To: &setter, GasPrice: big.NewInt(1)}
+	_, err = transition.EstimateGas(msg, nil)
+	assert.True(t, errors.Is(err, state.ErrGasRequiredExceedsAllowance))
+
+	// unless the balance is overridden
+	gas, err := transition.EstimateGas(msg, &state.CallOptions{
+		Overrides: state.StateOverride{
+			sender: {Balance: ether(1)},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Greater(t, gas, uint64(41000))
+}
@@ -0,0 +1,146 @@
+package tests
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	state "github.com/0xPolygon/eth-state-transition"
+	"github.com/0xPolygon/eth-state-transition/runtime"
+	"github.com/0xPolygon/eth-state-transition/runtime/evm/asm"
+	"github.com/0xPolygon/eth-state-transition/types"
+)
+
+var (
+	// counterContract increments the slot 0
+	counterContract = types.StringToAddress("0x4000")
+
+	// senderCounterContract increments the slot of the caller
+	senderCounterContract = types.StringToAddress("0x4001")
+
+	// suicideContract destroys itself and sends its balance to the caller
+	suicideContract = types.StringToAddress("0x4002")
+
+	counterCode = asm.MustAssemble(`
+		PUSH1 0x00 SLOAD PUSH1 0x01 ADD  ; SLOAD(0) + 1
+		PUSH1 0x00 SSTORE STOP           ; SSTORE(0, SLOAD(0) + 1)
+	`)
+)
+
+// newParallelBlock returns a block with many transactions of the senders
+// that conflict on the recipients, the coinbase and the contracts
+func newParallelBlock(senders []types.Address) *types.Block {
+	block := &types.Block{
+		Header: &types.Header{
+			Miner:      blockMiner,
+			Difficulty: big.NewInt(131072),
+			Number:     1,
+			GasLimit:   20000000,
+		},
+	}
+
+	recipients := []*types.Address{
+		&blockReceiver, &counterContract, &senderCounterContract, &blockMiner, nil, &suicideContract,
+	}
+	for nonce := 0; nonce < len(recipients); nonce++ {
+		for i, sender := range senders {
+			tx := &types.Transaction{
+				Nonce:    uint64(nonce),
+				GasPrice: big.NewInt(int64(1 + i%3)),
+				Gas:      100000,
+				To:       recipients[(nonce+i)%len(recipients)],
+				Value:    big.NewInt(int64(i)),
+				From:     sender,
+			}
+			if tx.To == nil {
+				// init code of a contract without code
+				tx.Input = asm.MustAssemble(`PUSH1 0x00 PUSH1 0x00 RETURN`)
+			}
+			block.Transactions = append(block.Transactions, tx)
+		}
+	}
+	return block
+}
+
+func TestApplyBlockParallel(t *testing.T) {
+	allocs := map[types.Address]*GenesisAccount{
+		counterContract: {
+			Code:    counterCode,
+			Balance: big.NewInt(0),
+		},
+		senderCounterContract: {
+			Code: asm.MustAssemble(`
+				CALLER SLOAD PUSH1 0x01 ADD  ; SLOAD(CALLER) + 1
+				CALLER SSTORE STOP           ; SSTORE(CALLER, SLOAD(CALLER) + 1)
+			`),
+			Balance: big.NewInt(0),
+		},
+		suicideContract: {
+			Code:    asm.MustAssemble(`CALLER SELFDESTRUCT`),
+			Balance: ether(1),
+		},
+	}
+
+	senders := []types.Address{}
+	for i := 0; i < 16; i++ {
+		sender := types.StringToAddress("0x1000").Bytes()
+		sender[0] = byte(i + 1)
+
+		addr := types.BytesToAddress(sender)
+		senders = append(senders, addr)
+		allocs[addr] = &GenesisAccount{Balance: ether(1)}
+	}
+
+	s, parentRoot := buildBlockState(t, allocs)
+	params := &runtime.Params{Forks: Forks["Istanbul"], ChainID: 1}
+
+	block := newParallelBlock(senders)
+	expected, err := state.NewExecutor(params, s).ApplyBlock(parentRoot, block)
+	assert.ErrorIs(t, err, state.ErrGasUsedMismatch)
+
+	for _, workers := range []int{2, 4, 8} {
+		executor := state.NewExecutor(params, s)
+		executor.Workers = workers
+
+		found, err := executor.ApplyBlock(parentRoot, block)
+		assert.ErrorIs(t, err, state.ErrGasUsedMismatch)
+		assert.Equal(t, expected, found, "%d workers", workers)
+
+		// some of the speculative executions are committed
+		stats := executor.Stats()
+		assert.NotZero(t, stats.Committed, "%d workers", workers)
+		assert.Equal(t, uint64(len(block.Transactions)), stats.Committed+stats.Reexecuted)
+	}
+
+	// all the calls to the counter are applied
+	snap, err := s.NewSnapshotAt(expected.Root)
+	assert.NoError(t, err)
+
+	txn := state.NewTxn(snap)
+	assert.Equal(t, types.BytesToHash([]byte{byte(len(senders))}), txn.GetState(counterContract, types.Hash{}))
+
+	// the destroyed contract only receives the next transfers
+	assert.Equal(t, 0, txn.GetCodeSize(suicideContract))
+}
+
+func TestApplyBlockParallelError(t *testing.T) {
+	s, parentRoot := buildBlockState(t, map[types.Address]*GenesisAccount{
+		blockSender: {Balance: ether(1)},
+	})
+	params := &runtime.Params{Forks: Forks["Istanbul"], ChainID: 1}
+
+	block := newParallelBlock([]types.Address{blockSender})
+
+	// the nonce of the third transaction is wrong
+	block.Transactions[2].Nonce = 5
+
+	_, expected := state.NewExecutor(params, s).ApplyBlock(parentRoot, block)
+	assert.Error(t, expected)
+
+	executor := state.NewExecutor(params, s)
+	executor.Workers = 4
+
+	_, err := executor.ApplyBlock(parentRoot, block)
+	assert.Equal(t, expected, err)
+}
@@ -0,0 +1,116 @@
+package tests
+
+import (
+	"math/big"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	state "github.com/0xPolygon/eth-state-transition"
+	itrie "github.com/0xPolygon/eth-state-transition/immutable-trie"
+	"github.com/0xPolygon/eth-state-transition/runtime"
+	"github.com/0xPolygon/eth-state-transition/types"
+)
+
+// countingStorage counts the nodes loaded from the storage
+type countingStorage struct {
+	itrie.Storage
+
+	gets uint64
+}
+
+func (c *countingStorage) Get(k []byte) ([]byte, bool) {
+	atomic.AddUint64(&c.gets, 1)
+	return c.Storage.Get(k)
+}
+
+func TestPrefetcher(t *testing.T) {
+	allocs := map[types.Address]*GenesisAccount{
+		counterContract: {
+			Code:    counterCode,
+			Balance: big.NewInt(0),
+		},
+	}
+
+	txs := []*state.Transaction{}
+	for i := 0; i < 50; i++ {
+		sender := types.BytesToAddress([]byte{0x10, byte(i)})
+		allocs[sender] = &GenesisAccount{Balance: ether(1)}
+
+		to := types.BytesToAddress([]byte{0x20, byte(i)})
+		if i%2 == 0 {
+			to = counterContract
+		}
+		txs = append(txs, &state.Transaction{
+			From:     sender,
+			To:       &to,
+			Value:    big.NewInt(1),
+			Gas:      100000,
+			GasPrice: big.NewInt(1),
+		})
+	}
+	storage := itrie.NewMemoryStorage()
+	_, root := buildStorageState(storage, allocs)
+
+	ctx := runtime.TxContext{Coinbase: blockMiner, GasLimit: 10000000, Number: 1}
+	forks := Forks["Istanbul"].At(1)
+
+	execute := func(prefetch bool) (*countingStorage, []*state.Result) {
+		// load the state from the storage without the cached tries
+		storage := &countingStorage{Storage: storage}
+		snap, err := itrie.NewArchiveState(storage).NewSnapshotAt(root)
+		assert.NoError(t, err)
+
+		if prefetch {
+			prefetcher := state.NewPrefetcher(forks, ctx, snap)
+			prefetcher.Start(txs, 4)
+			prefetcher.Wait()
+
+			stats := prefetcher.Stats()
+			assert.Equal(t, uint64(len(txs)), stats.Transactions)
+			assert.Equal(t, uint64(2*len(txs)+1), stats.Accounts)
+
+			atomic.StoreUint64(&storage.gets, 0)
+		}
+
+		transition := state.NewTransition(forks, ctx, snap)
+
+		receipts := []*state.Result{}
+		for _, tx := range txs {
+			receipt, err := transition.Write(tx)
+			assert.NoError(t, err)
+			receipts = append(receipts, receipt)
+		}
+		return storage, receipts
+	}
+
+	cold, expected := execute(false)
+	assert.NotZero(t, atomic.LoadUint64(&cold.gets))
+
+	// all the nodes read by the transactions are already loaded
+	warm, found := execute(true)
+	assert.Zero(t, atomic.LoadUint64(&warm.gets))
+	assert.Equal(t, expected, found)
+}
+
+func TestApplyBlockPrefetch(t *testing.T) {
+	// the state is committed after each transaction before byzantium
+	for _, fork := range []string{"Homestead", "Istanbul"} {
+		s, parentRoot := buildBlockState(t, map[types.Address]*GenesisAccount{
+			blockSender: {Balance: ether(1)},
+		})
+		params := &runtime.Params{Forks: Forks[fork], ChainID: 1}
+
+		block := newParallelBlock([]types.Address{blockSender})
+		expected, err := state.NewExecutor(params, s).ApplyBlock(parentRoot, block)
+		assert.ErrorIs(t, err, state.ErrGasUsedMismatch, fork)
+
+		executor := state.NewExecutor(params, s)
+		executor.PrefetchWorkers = 4
+
+		found, err := executor.ApplyBlock(parentRoot, block)
+		assert.ErrorIs(t, err, state.ErrGasUsedMismatch, fork)
+		assert.Equal(t, expected, found, fork)
+	}
+}
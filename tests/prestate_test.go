@@ -0,0 +1,135 @@
+package tests
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	state "github.com/0xPolygon/eth-state-transition"
+	"github.com/0xPolygon/eth-state-transition/tracer"
+	"github.com/0xPolygon/eth-state-transition/types"
+)
+
+func TestPrestateTracer(t *testing.T) {
+	sender := types.StringToAddress("0x1000")
+	contract := types.StringToAddress("0x2000")
+	coinbase := types.Address{}
+
+	slot := types.Hash{}
+
+	transition := newSimulateTransition(t, map[types.Address]*GenesisAccount{
+		sender: {Balance: ether(1)},
+		contract: {
+			Balance: big.NewInt(0),
+			Code:    setterCode,
+			Storage: map[types.Hash]types.Hash{
+				slot: types.StringToHash("0x5"),
+			},
+		},
+	})
+
+	prestateTracer := tracer.NewPrestateTracer(&tracer.PrestateTracerConfig{DiffMode: true})
+	transition.SetTracer(prestateTracer)
+
+	msg := &state.Transaction{
+		From:     sender,
+		To:       &contract,
+		Gas:      100000,
+		GasPrice: big.NewInt(1),
+		Value:    big.NewInt(0),
+	}
+	res, err := transition.Write(msg)
+	assert.NoError(t, err)
+	assert.True(t, res.Success)
+
+	// the gas bought by the sender is not included in the prestate
+	prestate := prestateTracer.Prestate()
+	assert.Len(t, prestate, 3)
+	assert.Equal(t, ether(1).String(), prestate[sender].Balance.String())
+	assert.Equal(t, uint64(0), prestate[sender].Nonce)
+	assert.Equal(t, setterCode, prestate[contract].Code)
+	assert.Equal(t, map[types.Hash]types.Hash{slot: types.StringToHash("0x5")}, prestate[contract].Storage)
+	assert.Equal(t, "0", prestate[coinbase].Balance.String())
+
+	data, err := json.Marshal(prestate[contract])
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"balance": "0x0",
+		"code": "0x600160005560005460005260206000f3",
+		"storage": {
+			"0x0000000000000000000000000000000000000000000000000000000000000000": "0x0000000000000000000000000000000000000000000000000000000000000005"
+		}
+	}`, string(data))
+
+	diff := prestateTracer.Diff()
+
+	// the coinbase did not exist before the transaction
+	assert.Len(t, diff.Pre, 2)
+	assert.Len(t, diff.Post, 3)
+
+	gasUsed := new(big.Int).SetUint64(res.GasUsed)
+	assert.Equal(t, new(big.Int).Sub(ether(1), gasUsed).String(), diff.Post[sender].Balance.String())
+	assert.Equal(t, uint64(1), diff.Post[sender].Nonce)
+	assert.Equal(t, gasUsed.String(), diff.Post[coinbase].Balance.String())
+
+	// only the modified fields are included in the post state
+	assert.Nil(t, diff.Post[contract].Balance)
+	assert.Nil(t, diff.Post[contract].Code)
+	assert.Equal(t, map[types.Hash]types.Hash{slot: types.StringToHash("0x1")}, diff.Post[contract].Storage)
+	assert.Equal(t, map[types.Hash]types.Hash{slot: types.StringToHash("0x5")}, diff.Pre[contract].Storage)
+
+	// the second transaction of the block starts from the state left by the first one
+	msg.Nonce = 1
+	_, err = transition.Write(msg)
+	assert.NoError(t, err)
+
+	prestate = prestateTracer.Prestate()
+	assert.Equal(t, uint64(1), prestate[sender].Nonce)
+	assert.Equal(t, map[types.Hash]types.Hash{slot: types.StringToHash("0x1")}, prestate[contract].Storage)
+
+	// the slot is not modified again
+	diff = prestateTracer.Diff()
+	assert.NotContains(t, diff.Post, contract)
+}
+
+func TestPrestateTracerSelfdestruct(t *testing.T) {
+	sender := types.StringToAddress("0x1000")
+	contract := types.StringToAddress("0x2000")
+	beneficiary := types.StringToAddress("0x3000")
+
+	// SELFDESTRUCT(beneficiary)
+	code := append([]byte{0x73}, beneficiary.Bytes()...)
+	code = append(code, 0xff)
+
+	transition := newSimulateTransition(t, map[types.Address]*GenesisAccount{
+		contract: {Balance: big.NewInt(10), Code: code},
+	})
+
+	prestateTracer := tracer.NewPrestateTracer(&tracer.PrestateTracerConfig{DiffMode: true})
+	transition.SetTracer(prestateTracer)
+
+	msg := &state.Transaction{
+		From:  sender,
+		To:    &contract,
+		Value: big.NewInt(0),
+	}
+	_, err := transition.Simulate(msg, nil)
+	assert.NoError(t, err)
+
+	prestate := prestateTracer.Prestate()
+	assert.Contains(t, prestate, beneficiary)
+	assert.Equal(t, "10", prestate[contract].Balance.String())
+
+	// the destroyed contract is only in the pre state
+	diff := prestateTracer.Diff()
+	assert.Contains(t, diff.Pre, contract)
+	assert.NotContains(t, diff.Post, contract)
+
+	assert.NotContains(t, diff.Pre, beneficiary)
+	assert.Equal(t, "10", diff.Post[beneficiary].Balance.String())
+
+	// the simulation does not modify the state of the transition
+	assert.Equal(t, "0", transition.Txn().GetBalance(beneficiary).String())
+}
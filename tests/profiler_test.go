@@ -0,0 +1,143 @@
+package tests
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/google/pprof/profile"
+	"github.com/stretchr/testify/assert"
+
+	state "github.com/0xPolygon/eth-state-transition"
+	"github.com/0xPolygon/eth-state-transition/runtime/evm/asm"
+	"github.com/0xPolygon/eth-state-transition/tracer"
+	"github.com/0xPolygon/eth-state-transition/types"
+)
+
+func TestGasProfiler(t *testing.T) {
+	sender := types.StringToAddress("0x1000")
+	caller := types.StringToAddress("0x2000")
+	setter := types.StringToAddress("0x3000")
+	identityCaller := types.StringToAddress("0x4000")
+	identity := types.StringToAddress("0x4")
+
+	transition := newSimulateTransition(t, map[types.Address]*GenesisAccount{
+		sender:         {Balance: ether(1)},
+		caller:         {Balance: big.NewInt(0), Code: callAndCheck(setter)},
+		setter:         {Balance: big.NewInt(0), Code: setterCode},
+		identityCaller: {Balance: big.NewInt(0), Code: callAndCheck(identity)},
+	})
+
+	profiler := tracer.NewGasProfiler()
+	transition.SetTracer(profiler)
+
+	msg := &state.Transaction{
+		From:     sender,
+		To:       &caller,
+		Gas:      100000,
+		GasPrice: big.NewInt(1),
+		Value:    big.NewInt(0),
+	}
+	res, err := transition.Write(msg)
+	assert.NoError(t, err)
+	assert.True(t, res.Success)
+
+	// the call only includes its own cost
+	assert.Equal(t, uint64(20000), profiler.ByOpcode()["SSTORE"])
+	assert.Equal(t, uint64(700), profiler.ByOpcode()["CALL"])
+
+	// all the gas of the transaction is attributed
+	p := profiler.Profile()
+	total := int64(0)
+	for _, sample := range p.Sample {
+		total += sample.Value[0]
+	}
+	assert.Equal(t, int64(res.GasUsed), total)
+
+	// the MSTORE also pays for the expansion of the memory
+	setterGas := uint64(3 + 3 + 20000 + 3 + 800 + 3 + 3 + 3 + 3 + 3)
+	assert.Equal(t, setterGas, profiler.ByContract()[setter])
+	assert.Equal(t, res.GasUsed-21000-setterGas, profiler.ByContract()[caller])
+
+	// the gas used by the precompiled contract is attributed to its frame
+	msg.To = &identityCaller
+	msg.Nonce = 1
+	_, err = transition.Write(msg)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(15), profiler.ByContract()[identity])
+
+	// the profile can be read by pprof
+	var buf bytes.Buffer
+	assert.NoError(t, profiler.WriteProfile(&buf))
+
+	p, err = profile.Parse(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, "gas", p.DefaultSampleType)
+
+	// the stack of the sstore is the opcode, the setter and the
+	// call site of the caller
+	var found bool
+	for _, sample := range p.Sample {
+		stack := []string{}
+		for _, loc := range sample.Location {
+			stack = append(stack, loc.Line[0].Function.Name)
+		}
+		if stack[0] != "SSTORE" {
+			continue
+		}
+		found = true
+		assert.Equal(t, []string{"SSTORE", setter.String(), caller.String()}, stack)
+		assert.Equal(t, int64(4), sample.Location[1].Line[0].Line)
+		assert.Equal(t, int64(32), sample.Location[2].Line[0].Line)
+		assert.Equal(t, []int64{20000, 1}, sample.Value)
+	}
+	assert.True(t, found)
+}
+
+func TestGasProfilerCallWithValue(t *testing.T) {
+	sender := types.StringToAddress("0x1000")
+	caller := types.StringToAddress("0x2000")
+	receiver := types.StringToAddress("0x3000")
+
+	transition := newSimulateTransition(t, map[types.Address]*GenesisAccount{
+		sender: {Balance: ether(1)},
+		caller: {
+			Balance: big.NewInt(1),
+			// POP(CALL(GAS, receiver, 1, 0, 0, 0, 0))
+			Code: asm.MustAssemble(fmt.Sprintf(`
+				PUSH1 0x00 PUSH1 0x00 PUSH1 0x00 PUSH1 0x00 PUSH1 0x01
+				PUSH20 %s GAS CALL POP
+			`, receiver)),
+		},
+		// POP(ADDRESS)
+		receiver: {Balance: big.NewInt(0), Code: asm.MustAssemble(`ADDRESS POP`)},
+	})
+
+	profiler := tracer.NewGasProfiler()
+	transition.SetTracer(profiler)
+
+	msg := &state.Transaction{
+		From:     sender,
+		To:       &caller,
+		Gas:      100000,
+		GasPrice: big.NewInt(1),
+		Value:    big.NewInt(0),
+	}
+	res, err := transition.Write(msg)
+	assert.NoError(t, err)
+	assert.True(t, res.Success)
+
+	// the caller pays the whole cost of the value transfer, the
+	// stipend is not part of the gas it gives to the receiver
+	assert.Equal(t, uint64(700+9000), profiler.ByOpcode()["CALL"])
+	assert.Equal(t, uint64(2+2), profiler.ByContract()[receiver])
+	assert.Equal(t, uint64(6*3+2+700+9000+2), profiler.ByContract()[caller])
+
+	// the stipend is a negative sample, so the profile adds up to the gas used
+	total := int64(0)
+	for _, sample := range profiler.Profile().Sample {
+		total += sample.Value[0]
+	}
+	assert.Equal(t, int64(res.GasUsed), total)
+}
@@ -0,0 +1,288 @@
+package tests
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	state "github.com/0xPolygon/eth-state-transition"
+	"github.com/0xPolygon/eth-state-transition/runtime"
+	"github.com/0xPolygon/eth-state-transition/runtime/evm/asm"
+	"github.com/0xPolygon/eth-state-transition/types"
+)
+
+var (
+	// returns the value of the slot 0
+	getterCode = asm.MustAssemble(`
+		PUSH1 0x00 SLOAD              ; SLOAD(0)
+		PUSH1 0x00 MSTORE             ; MSTORE(0)
+		PUSH1 0x20 PUSH1 0x00 RETURN  ; RETURN(0, 32)
+	`)
+
+	// sets the slot 0 to 1 and returns it
+	setterCode = append(asm.MustAssemble(`
+		PUSH1 0x01 PUSH1 0x00 SSTORE  ; SSTORE(0, 1)
+	`), getterCode...)
+
+	// reverts with 0x2a
+	revertCode = asm.MustAssemble(`
+		PUSH1 0x2a PUSH1 0x00 MSTORE  ; MSTORE(0, 0x2a)
+		PUSH1 0x20 PUSH1 0x00 REVERT  ; REVERT(0, 32)
+	`)
+)
+
+func newSimulateTransition(t *testing.T, allocs map[types.Address]*GenesisAccount) *state.Transition {
+	return newSimulateTransitionAt(t, "Istanbul", allocs)
+}
+
+func newSimulateTransitionAt(t *testing.T, fork string, allocs map[types.Address]*GenesisAccount) *state.Transition {
+	snap, _ := buildState(t, allocs)
+
+	ctx := runtime.TxContext{
+		Number:   1,
+		GasLimit: 1000000,
+		ChainID:  1,
+	}
+	return state.NewTransition(Forks[fork].At(1), ctx, snap)
+}
+
+func TestSimulate(t *testing.T) {
+	sender := types.StringToAddress("0x1000")
+	contract := types.StringToAddress("0x2000")
+
+	transition := newSimulateTransition(t, map[types.Address]*GenesisAccount{
+		contract: {
+			Balance: big.NewInt(0),
+			Code:    getterCode,
+			Storage: map[types.Hash]types.Hash{
+				{}: types.StringToHash("0x5"),
+			},
+		},
+	})
+
+	// the sender has no funds and the nonce is not checked
+	msg := &state.Transaction{
+		From:     sender,
+		To:       &contract,
+		Nonce:    10,
+		GasPrice: big.NewInt(100),
+		Value:    big.NewInt(0),
+	}
+
+	res, err := transition.Simulate(msg, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, res.Err)
+	assert.Equal(t, types.StringToHash("0x5").Bytes(), res.ReturnValue)
+	assert.Equal(t, uint64(21000+3+800+3+3+3+3+3), res.GasUsed)
+
+	// the gas is not charged to the sender
+	assert.Equal(t, uint64(0), transition.Txn().GetNonce(sender))
+	assert.Equal(t, big.NewInt(0), transition.Txn().GetBalance(sender))
+	assert.Equal(t, uint64(0), transition.TotalGas())
+}
+
+func TestSimulateChecks(t *testing.T) {
+	sender := types.StringToAddress("0x1000")
+	contract := types.StringToAddress("0x2000")
+
+	transition := newSimulateTransition(t, map[types.Address]*GenesisAccount{
+		sender:   {Balance: big.NewInt(100*50000 + 1), Nonce: 2},
+		contract: {Balance: big.NewInt(0), Code: getterCode},
+	})
+
+	msg := &state.Transaction{
+		From:     sender,
+		To:       &contract,
+		Nonce:    1,
+		Gas:      50000,
+		GasPrice: big.NewInt(100),
+		Value:    big.NewInt(1),
+	}
+
+	// the checks are disabled by default
+	_, err := transition.Simulate(msg, nil)
+	assert.NoError(t, err)
+
+	_, err = transition.Simulate(msg, &state.CallOptions{CheckNonce: true})
+	assert.Equal(t, state.ErrNonceIncorrect, err)
+
+	msg.Nonce = 2
+	res, err := transition.Simulate(msg, &state.CallOptions{CheckNonce: true, CheckBalance: true})
+	assert.NoError(t, err)
+	assert.NoError(t, res.Err)
+
+	// the sender cannot pay for the gas
+	msg.Gas = 50001
+	_, err = transition.Simulate(msg, &state.CallOptions{CheckBalance: true})
+	assert.Equal(t, state.ErrNotEnoughFundsForGas, err)
+
+	// the sender cannot pay for the value after the gas
+	msg.Gas = 50000
+	msg.Value = big.NewInt(2)
+	_, err = transition.Simulate(msg, &state.CallOptions{CheckBalance: true})
+	assert.Equal(t, state.ErrNotEnoughFunds, err)
+
+	// the balance of the sender is not modified
+	assert.Equal(t, big.NewInt(100*50000+1), transition.Txn().GetBalance(sender))
+}
+
+func TestSimulateDoesNotModifyState(t *testing.T) {
+	sender := types.StringToAddress("0x1000")
+	contract := types.StringToAddress("0x2000")
+
+	transition := newSimulateTransition(t, map[types.Address]*GenesisAccount{
+		contract: {Balance: big.NewInt(0), Code: setterCode},
+	})
+
+	// include a pending change in the transition
+	transition.Txn().SetBalance(sender, big.NewInt(1))
+
+	msg := &state.Transaction{
+		From:  sender,
+		To:    &contract,
+		Value: big.NewInt(1),
+	}
+
+	res, err := transition.Simulate(msg, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, res.Err)
+	assert.Equal(t, types.StringToHash("0x1").Bytes(), res.ReturnValue)
+
+	txn := transition.Txn()
+	assert.Equal(t, types.Hash{}, txn.GetState(contract, types.Hash{}))
+	assert.Equal(t, big.NewInt(1), txn.GetBalance(sender))
+	assert.Equal(t, uint64(0), txn.GetNonce(sender))
+	assert.Empty(t, txn.Logs())
+}
+
+func TestSimulateRevert(t *testing.T) {
+	sender := types.StringToAddress("0x1000")
+	contract := types.StringToAddress("0x2000")
+
+	transition := newSimulateTransition(t, map[types.Address]*GenesisAccount{
+		contract: {Balance: big.NewInt(0), Code: revertCode},
+	})
+
+	msg := &state.Transaction{
+		From: sender,
+		To:   &contract,
+	}
+
+	res, err := transition.Simulate(msg, nil)
+	assert.NoError(t, err)
+	assert.True(t, res.Reverted())
+	assert.Equal(t, types.StringToHash("0x2a").Bytes(), res.ReturnValue)
+}
+
+func TestSimulateGasCap(t *testing.T) {
+	sender := types.StringToAddress("0x1000")
+	contract := types.StringToAddress("0x2000")
+
+	transition := newSimulateTransition(t, map[types.Address]*GenesisAccount{
+		contract: {Balance: big.NewInt(0), Code: setterCode},
+	})
+
+	msg := &state.Transaction{
+		From: sender,
+		To:   &contract,
+		Gas:  1000000,
+	}
+
+	// the storage write does not fit in the gas cap
+	res, err := transition.Simulate(msg, &state.CallOptions{GasCap: 30000})
+	assert.NoError(t, err)
+	assert.Equal(t, runtime.ErrOutOfGas, res.Err)
+	assert.Equal(t, uint64(30000), res.GasUsed)
+
+	// the gas cap is lower than the intrinsic gas
+	_, err = transition.Simulate(msg, &state.CallOptions{GasCap: 20000})
+	assert.Equal(t, state.ErrNotEnoughIntrinsicGas, err)
+}
+
+func TestSimulateOverrides(t *testing.T) {
+	sender := types.StringToAddress("0x1000")
+	contract := types.StringToAddress("0x2000")
+
+	slot1 := types.StringToHash("0x1")
+
+	transition := newSimulateTransition(t, map[types.Address]*GenesisAccount{
+		contract: {
+			Balance: big.NewInt(0),
+			Code:    getterCode,
+			Storage: map[types.Hash]types.Hash{
+				{}:    types.StringToHash("0x5"),
+				slot1: types.StringToHash("0x6"),
+			},
+		},
+	})
+
+	// returns the balance of the caller
+	balanceCode := asm.MustAssemble(`
+		CALLER BALANCE PUSH1 0x00 MSTORE  ; MSTORE(0, BALANCE(CALLER))
+		PUSH1 0x20 PUSH1 0x00 RETURN      ; RETURN(0, 32)
+	`)
+
+	nonce := uint64(7)
+
+	cases := []struct {
+		name      string
+		overrides state.StateOverride
+		expected  types.Hash
+	}{
+		{
+			name: "StateDiff",
+			overrides: state.StateOverride{
+				contract: {
+					StateDiff: map[types.Hash]types.Hash{{}: types.StringToHash("0x7")},
+				},
+			},
+			expected: types.StringToHash("0x7"),
+		},
+		{
+			name: "State",
+			overrides: state.StateOverride{
+				contract: {
+					State: map[types.Hash]types.Hash{slot1: types.StringToHash("0x8")},
+				},
+			},
+			expected: types.Hash{},
+		},
+		{
+			name: "CodeAndBalance",
+			overrides: state.StateOverride{
+				contract: {Code: balanceCode},
+				sender:   {Balance: big.NewInt(100), Nonce: &nonce},
+			},
+			expected: types.StringToHash("0x64"),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			msg := &state.Transaction{
+				From: sender,
+				To:   &contract,
+			}
+
+			res, err := transition.Simulate(msg, &state.CallOptions{Overrides: c.overrides})
+			assert.NoError(t, err)
+			assert.NoError(t, res.Err)
+			assert.Equal(t, c.expected.Bytes(), res.ReturnValue)
+		})
+	}
+
+	// the overrides are not kept after the call
+	assert.Equal(t, types.StringToHash("0x6"), transition.Txn().GetState(contract, slot1))
+	assert.Equal(t, getterCode, transition.Txn().GetCode(contract))
+
+	_, err := transition.Simulate(&state.Transaction{From: sender, To: &contract}, &state.CallOptions{
+		Overrides: state.StateOverride{
+			contract: {
+				State:     map[types.Hash]types.Hash{},
+				StateDiff: map[types.Hash]types.Hash{},
+			},
+		},
+	})
+	assert.ErrorIs(t, err, state.ErrStateAndStateDiff)
+}
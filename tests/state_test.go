@@ -9,6 +9,7 @@ import (
 
 	state "github.com/0xPolygon/eth-state-transition"
 	"github.com/0xPolygon/eth-state-transition/helper"
+	"github.com/0xPolygon/eth-state-transition/runtime/evm"
 	"github.com/0xPolygon/eth-state-transition/types"
 	"github.com/stretchr/testify/assert"
 )
@@ -28,7 +29,7 @@ type stateCase struct {
 
 var ripemd = types.StringToAddress("0000000000000000000000000000000000000003")
 
-func RunSpecificTest(file string, t *testing.T, c stateCase, name, fork string, index int, p postEntry) {
+func RunSpecificTest(file string, t *testing.T, c stateCase, name, fork string, index int, p postEntry, mode evm.ExecutionMode) {
 	config, ok := Forks[fork]
 	if !ok {
 		t.Fatalf("config %s not found", fork)
@@ -48,6 +49,7 @@ func RunSpecificTest(file string, t *testing.T, c stateCase, name, fork string,
 	runtimeCtx.ChainID = 1
 
 	transition := state.NewTransition(forks, runtimeCtx, snap)
+	transition.SetExecutionMode(mode)
 
 	result, err := transition.Write(msg)
 	assert.NoError(t, err)
@@ -55,7 +57,7 @@ func RunSpecificTest(file string, t *testing.T, c stateCase, name, fork string,
 	// txn.CleanDeleteObjects(forks.EIP158)
 	_, root := snap.Commit(transition.Commit())
 	if !bytes.Equal(root, p.Root.Bytes()) {
-		t.Fatalf("root mismatch (%s %s %s %d): expected %s but found %s", file, name, fork, index, p.Root.String(), helper.EncodeToHex(root))
+		t.Fatalf("root mismatch (%s %s %s %d %s): expected %s but found %s", file, name, fork, index, mode, p.Root.String(), helper.EncodeToHex(root))
 	}
 
 	if logs := rlpHashLogs(result.Logs); logs != p.Logs {
@@ -64,6 +66,16 @@ func RunSpecificTest(file string, t *testing.T, c stateCase, name, fork string,
 }
 
 func TestState(t *testing.T) {
+	// the suite runs with the interpreter and with the basic blocks
+	// to check that both execution modes are the same
+	for _, mode := range []evm.ExecutionMode{evm.Interpreter, evm.BasicBlocks} {
+		t.Run(mode.String(), func(t *testing.T) {
+			testState(t, mode)
+		})
+	}
+}
+
+func testState(t *testing.T, mode evm.ExecutionMode) {
 	long := []string{
 		"static_Call50000",
 		"static_Return50000",
@@ -119,7 +131,7 @@ func TestState(t *testing.T) {
 				for name, i := range c {
 					for fork, f := range i.Post {
 						for indx, e := range f {
-							RunSpecificTest(file, t, i, name, fork, indx, e)
+							RunSpecificTest(file, t, i, name, fork, indx, e, mode)
 						}
 					}
 				}
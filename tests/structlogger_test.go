@@ -0,0 +1,146 @@
+package tests
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	state "github.com/0xPolygon/eth-state-transition"
+	"github.com/0xPolygon/eth-state-transition/tracer"
+	"github.com/0xPolygon/eth-state-transition/types"
+)
+
+func TestStructLogger(t *testing.T) {
+	sender := types.StringToAddress("0x1000")
+	contract := types.StringToAddress("0x2000")
+
+	transition := newSimulateTransition(t, map[types.Address]*GenesisAccount{
+		sender:   {Balance: ether(1)},
+		contract: {Balance: big.NewInt(0), Code: setterCode},
+	})
+
+	logger := tracer.NewStructLogger(nil)
+	transition.SetTracer(logger)
+
+	msg := &state.Transaction{
+		From:     sender,
+		To:       &contract,
+		Gas:      100000,
+		GasPrice: big.NewInt(1),
+		Value:    big.NewInt(0),
+	}
+	res, err := transition.Write(msg)
+	assert.NoError(t, err)
+	assert.True(t, res.Success)
+
+	result := logger.Result()
+	assert.False(t, result.Failed)
+	assert.Equal(t, res.GasUsed, result.Gas)
+	assert.Equal(t, "0000000000000000000000000000000000000000000000000000000000000001", result.ReturnValue)
+
+	ops := []string{}
+	for _, log := range result.StructLogs {
+		ops = append(ops, log.Op)
+	}
+	assert.Equal(t, []string{
+		"PUSH1", "PUSH1", "SSTORE", "PUSH1", "SLOAD", "PUSH1", "MSTORE", "PUSH1", "PUSH1", "RETURN",
+	}, ops)
+
+	slot := "0000000000000000000000000000000000000000000000000000000000000000"
+	one := "0000000000000000000000000000000000000000000000000000000000000001"
+
+	sstore := result.StructLogs[2]
+	assert.Equal(t, uint64(4), sstore.Pc)
+	assert.Equal(t, uint64(20000), sstore.GasCost)
+	assert.Equal(t, uint64(100000-21000-3-3), sstore.Gas)
+	assert.Equal(t, 1, sstore.Depth)
+	assert.Equal(t, []string{"0x1", "0x0"}, *sstore.Stack)
+	assert.Equal(t, map[string]string{slot: one}, *sstore.Storage)
+
+	// the slot read returns the value written before
+	sload := result.StructLogs[4]
+	assert.Equal(t, map[string]string{slot: one}, *sload.Storage)
+	assert.Nil(t, result.StructLogs[3].Storage)
+
+	ret := result.StructLogs[9]
+	assert.Equal(t, []string{one}, *ret.Memory)
+
+	data, err := json.Marshal(result.StructLogs[0])
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"pc":0,"op":"PUSH1","gas":79000,"gasCost":3,"depth":1,"stack":[],"memory":[]}`, string(data))
+}
+
+func TestStructLoggerConfig(t *testing.T) {
+	sender := types.StringToAddress("0x1000")
+	contract := types.StringToAddress("0x2000")
+
+	transition := newSimulateTransition(t, map[types.Address]*GenesisAccount{
+		contract: {Balance: big.NewInt(0), Code: setterCode},
+	})
+
+	logger := tracer.NewStructLogger(&tracer.StructLoggerConfig{
+		DisableStack:   true,
+		DisableMemory:  true,
+		DisableStorage: true,
+	})
+	transition.SetTracer(logger)
+
+	msg := &state.Transaction{
+		From:  sender,
+		To:    &contract,
+		Value: big.NewInt(0),
+	}
+	_, err := transition.Simulate(msg, nil)
+	assert.NoError(t, err)
+
+	logs := logger.StructLogs()
+	assert.Len(t, logs, 10)
+	for _, log := range logs {
+		assert.Nil(t, log.Stack)
+		assert.Nil(t, log.Memory)
+		assert.Nil(t, log.Storage)
+	}
+}
+
+func TestStructLoggerFailure(t *testing.T) {
+	sender := types.StringToAddress("0x1000")
+	reverter := types.StringToAddress("0x2000")
+	invalid := types.StringToAddress("0x3000")
+
+	transition := newSimulateTransition(t, map[types.Address]*GenesisAccount{
+		reverter: {Balance: big.NewInt(0), Code: revertCode},
+		invalid:  {Balance: big.NewInt(0), Code: []byte{0x60, 0x00, 0xfe}},
+	})
+
+	logger := tracer.NewStructLogger(nil)
+	transition.SetTracer(logger)
+
+	msg := &state.Transaction{
+		From:  sender,
+		To:    &reverter,
+		Value: big.NewInt(0),
+	}
+	_, err := transition.Simulate(msg, nil)
+	assert.NoError(t, err)
+
+	// the return value of a reverted transaction is the revert data
+	result := logger.Result()
+	assert.True(t, result.Failed)
+	assert.Equal(t, "000000000000000000000000000000000000000000000000000000000000002a", result.ReturnValue)
+	assert.Empty(t, result.StructLogs[len(result.StructLogs)-1].Error)
+
+	msg.To = &invalid
+	_, err = transition.Simulate(msg, nil)
+	assert.NoError(t, err)
+
+	result = logger.Result()
+	assert.True(t, result.Failed)
+	assert.Empty(t, result.ReturnValue)
+	assert.Len(t, result.StructLogs, 2)
+
+	last := result.StructLogs[1]
+	assert.Equal(t, "INVALID", last.Op)
+	assert.NotEmpty(t, last.Error)
+}
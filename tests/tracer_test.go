@@ -0,0 +1,117 @@
+package tests
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	state "github.com/0xPolygon/eth-state-transition"
+	"github.com/0xPolygon/eth-state-transition/runtime"
+	"github.com/0xPolygon/eth-state-transition/runtime/evm"
+	"github.com/0xPolygon/eth-state-transition/types"
+)
+
+type frame struct {
+	depth    int
+	callType runtime.CallType
+	gas      uint64
+	gasUsed  uint64
+	stepCost uint64
+}
+
+// frameTracer records the events of the call frames
+type frameTracer struct {
+	events []string
+	frames []*frame
+	open   []*frame
+}
+
+func (f *frameTracer) TxStart(gasLimit uint64) {
+	f.events = append(f.events, fmt.Sprintf("start %d", gasLimit))
+}
+
+func (f *frameTracer) TxEnd(gasLeft uint64) {
+	f.events = append(f.events, "end")
+}
+
+func (f *frameTracer) CallStart(depth int, from, to types.Address, callType runtime.CallType, gas uint64, value *big.Int, input []byte) {
+	f.events = append(f.events, fmt.Sprintf("enter %d", depth))
+
+	fr := &frame{depth: depth, callType: callType, gas: gas}
+	f.frames = append(f.frames, fr)
+	f.open = append(f.open, fr)
+}
+
+func (f *frameTracer) CallEnd(depth int, output []byte, gasUsed uint64, err error) {
+	f.events = append(f.events, fmt.Sprintf("exit %d", depth))
+
+	fr := f.open[len(f.open)-1]
+	fr.gasUsed = gasUsed
+	f.open = f.open[:len(f.open)-1]
+}
+
+func (f *frameTracer) CaptureState(scope runtime.ScopeContext, host runtime.Host) {
+	if evm.OpCode(scope.Op()) == evm.CALL {
+		f.events = append(f.events, "call")
+	}
+}
+
+func (f *frameTracer) ExecuteState(pc uint64, op byte, cost uint64) {
+	f.open[len(f.open)-1].stepCost += cost
+}
+
+func (f *frameTracer) CaptureFault(pc uint64, op byte, cost uint64, err error) {
+	f.events = append(f.events, "fault")
+}
+
+func TestTracerFrames(t *testing.T) {
+	sender := types.StringToAddress("0x1000")
+	caller := types.StringToAddress("0x2000")
+	setter := types.StringToAddress("0x3000")
+
+	transition := newSimulateTransition(t, map[types.Address]*GenesisAccount{
+		sender: {Balance: ether(1)},
+		caller: {Balance: big.NewInt(0), Code: callAndCheck(setter)},
+		setter: {Balance: big.NewInt(0), Code: setterCode},
+	})
+
+	tracer := &frameTracer{}
+	transition.SetTracer(tracer)
+
+	msg := &state.Transaction{
+		From:     sender,
+		To:       &caller,
+		Gas:      100000,
+		GasPrice: big.NewInt(1),
+		Value:    big.NewInt(0),
+	}
+	res, err := transition.Write(msg)
+	assert.NoError(t, err)
+	assert.True(t, res.Success)
+
+	assert.Equal(t, []string{"start 100000", "enter 1", "call", "enter 2", "exit 2", "exit 1", "end"}, tracer.events)
+
+	top, child := tracer.frames[0], tracer.frames[1]
+	assert.Equal(t, runtime.Call, top.callType)
+	assert.Equal(t, uint64(100000-21000), top.gas)
+
+	// the steps of the child account for all its gas
+	assert.Equal(t, child.gasUsed, child.stepCost)
+
+	// the cost of the call includes the gas sent to the child but the
+	// gas returned by the child is not used by the parent
+	assert.Equal(t, top.gasUsed+child.gas-child.gasUsed, top.stepCost)
+
+	// the read-only calls are traced but not the gas estimation
+	tracer.events = nil
+	_, err = transition.Simulate(msg, nil)
+	assert.NoError(t, err)
+	assert.Len(t, tracer.events, 7)
+
+	tracer.events = nil
+	_, err = transition.EstimateGas(msg, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, tracer.events)
+}
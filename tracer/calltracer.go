@@ -0,0 +1,165 @@
+package tracer
+
+import (
+	"encoding/json"
+	"math/big"
+	"strconv"
+
+	"github.com/0xPolygon/eth-state-transition/helper"
+	"github.com/0xPolygon/eth-state-transition/runtime"
+	"github.com/0xPolygon/eth-state-transition/types"
+)
+
+var _ runtime.Tracer = &CallTracer{}
+
+// CallFrame is a call frame of a transaction with the frames it started
+type CallFrame struct {
+	Type    runtime.CallType
+	From    types.Address
+	To      types.Address
+	Value   *big.Int
+	Gas     uint64
+	GasUsed uint64
+	Input   []byte
+	Output  []byte
+	Err     error
+
+	// RevertReason is the reason of the revert if it is encoded as Error(string)
+	RevertReason string
+
+	Calls []*CallFrame
+}
+
+type callFrameJSON struct {
+	Type         string       `json:"type"`
+	From         string       `json:"from"`
+	To           string       `json:"to,omitempty"`
+	Value        string       `json:"value,omitempty"`
+	Gas          string       `json:"gas"`
+	GasUsed      string       `json:"gasUsed"`
+	Input        string       `json:"input"`
+	Output       string       `json:"output,omitempty"`
+	Error        string       `json:"error,omitempty"`
+	RevertReason string       `json:"revertReason,omitempty"`
+	Calls        []*CallFrame `json:"calls,omitempty"`
+}
+
+// MarshalJSON encodes the frame in the format of the callTracer of geth
+func (c *CallFrame) MarshalJSON() ([]byte, error) {
+	frame := &callFrameJSON{
+		Type:         c.Type.String(),
+		From:         c.From.String(),
+		Gas:          uintToHex(c.Gas),
+		GasUsed:      uintToHex(c.GasUsed),
+		Input:        helper.EncodeToHex(c.Input),
+		RevertReason: c.RevertReason,
+		Calls:        c.Calls,
+	}
+
+	// the address of a failed creation is not included
+	isCreate := c.Type == runtime.Create || c.Type == runtime.Create2
+	if !isCreate || c.Err == nil {
+		frame.To = c.To.String()
+	}
+	if c.Value != nil {
+		frame.Value = "0x" + c.Value.Text(16)
+	}
+	if len(c.Output) != 0 {
+		frame.Output = helper.EncodeToHex(c.Output)
+	}
+	if c.Err != nil {
+		frame.Error = c.Err.Error()
+	}
+	return json.Marshal(frame)
+}
+
+// CallTracer is a tracer that records the tree of call frames of a transaction
+type CallTracer struct {
+	root  *CallFrame
+	stack []*CallFrame
+
+	gasLimit uint64
+}
+
+// NewCallTracer creates a new call tracer
+func NewCallTracer() *CallTracer {
+	return &CallTracer{}
+}
+
+// TxStart implements the Tracer interface
+func (c *CallTracer) TxStart(gasLimit uint64) {
+	c.root = nil
+	c.stack = c.stack[:0]
+	c.gasLimit = gasLimit
+}
+
+// TxEnd implements the Tracer interface
+func (c *CallTracer) TxEnd(gasLeft uint64) {
+	// the top-level frame includes the intrinsic gas and the refund
+	if c.root != nil {
+		c.root.Gas = c.gasLimit
+		c.root.GasUsed = c.gasLimit - gasLeft
+	}
+}
+
+// CallStart implements the Tracer interface
+func (c *CallTracer) CallStart(depth int, from, to types.Address, callType runtime.CallType, gas uint64, value *big.Int, input []byte) {
+	frame := &CallFrame{
+		Type:  callType,
+		From:  from,
+		To:    to,
+		Gas:   gas,
+		Input: append([]byte{}, input...),
+	}
+	if value != nil {
+		frame.Value = new(big.Int).Set(value)
+	}
+	c.stack = append(c.stack, frame)
+}
+
+// CallEnd implements the Tracer interface
+func (c *CallTracer) CallEnd(depth int, output []byte, gasUsed uint64, err error) {
+	frame := c.stack[len(c.stack)-1]
+	c.stack = c.stack[:len(c.stack)-1]
+
+	frame.GasUsed = gasUsed
+	frame.Err = err
+
+	// the output of a failed frame is only included if it reverted
+	if err == nil || err == runtime.ErrExecutionReverted {
+		frame.Output = append([]byte{}, output...)
+	}
+	if err == runtime.ErrExecutionReverted {
+		if reason, ok := runtime.UnpackRevertReason(output); ok {
+			frame.RevertReason = reason
+		}
+	}
+
+	if len(c.stack) == 0 {
+		c.root = frame
+	} else {
+		parent := c.stack[len(c.stack)-1]
+		parent.Calls = append(parent.Calls, frame)
+	}
+}
+
+// CaptureState implements the Tracer interface
+func (c *CallTracer) CaptureState(scope runtime.ScopeContext, host runtime.Host) {
+}
+
+// ExecuteState implements the Tracer interface
+func (c *CallTracer) ExecuteState(pc uint64, op byte, cost uint64) {
+}
+
+// CaptureFault implements the Tracer interface
+func (c *CallTracer) CaptureFault(pc uint64, op byte, cost uint64, err error) {
+}
+
+// Result returns the top-level frame of the last transaction
+func (c *CallTracer) Result() *CallFrame {
+	return c.root
+}
+
+func uintToHex(n uint64) string {
+	return "0x" + strconv.FormatUint(n, 16)
+}
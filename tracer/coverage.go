@@ -0,0 +1,324 @@
+package tracer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+
+	"github.com/0xPolygon/eth-state-transition/helper"
+	"github.com/0xPolygon/eth-state-transition/runtime"
+	"github.com/0xPolygon/eth-state-transition/runtime/evm"
+	"github.com/0xPolygon/eth-state-transition/types"
+)
+
+var _ runtime.Tracer = &Coverage{}
+
+// BranchCoverage is the number of times a JUMPI jumped or not
+type BranchCoverage struct {
+	Taken    uint64
+	NotTaken uint64
+}
+
+// CodeCoverage is the coverage of a code
+type CodeCoverage struct {
+	Code []byte
+
+	// Hits is the number of times the opcode at each pc was executed
+	Hits map[uint64]uint64
+
+	// Branches are the outcomes of the JUMPI opcodes by pc
+	Branches map[uint64]*BranchCoverage
+}
+
+func newCodeCoverage(code []byte) *CodeCoverage {
+	return &CodeCoverage{
+		Code:     code,
+		Hits:     map[uint64]uint64{},
+		Branches: map[uint64]*BranchCoverage{},
+	}
+}
+
+// Instructions returns the positions of the opcodes of the code
+func (c *CodeCoverage) Instructions() []uint64 {
+	pcs := []uint64{}
+	for pc := 0; pc < len(c.Code); pc++ {
+		pcs = append(pcs, uint64(pc))
+
+		// skip the data of the push
+		if op := c.Code[pc]; op >= evm.PUSH1 && op <= evm.PUSH32 {
+			pc += int(op) - evm.PUSH1 + 1
+		}
+	}
+	return pcs
+}
+
+type coverageBranch struct {
+	code  *CodeCoverage
+	pc    uint64
+	taken bool
+}
+
+// Coverage is a tracer that records the opcodes executed and the outcomes of
+// the conditional jumps of each code, keyed by the hash of the code. The
+// coverage accumulates over all the transactions traced.
+type Coverage struct {
+	codes map[types.Hash]*CodeCoverage
+
+	// stack is the code of each call frame, nil until its first opcode
+	stack []*CodeCoverage
+
+	// branch is the JUMPI being executed
+	branch *coverageBranch
+}
+
+// NewCoverage creates a new coverage tracer
+func NewCoverage() *Coverage {
+	return &Coverage{
+		codes: map[types.Hash]*CodeCoverage{},
+	}
+}
+
+// AddCode includes a code in the reports even if it is not executed
+// and returns its hash
+func (c *Coverage) AddCode(code []byte) types.Hash {
+	hash := types.BytesToHash(helper.Keccak256(code))
+	c.lookupCode(hash, code)
+	return hash
+}
+
+func (c *Coverage) lookupCode(hash types.Hash, code []byte) *CodeCoverage {
+	cov, ok := c.codes[hash]
+	if !ok {
+		cov = newCodeCoverage(append([]byte{}, code...))
+		c.codes[hash] = cov
+	}
+	return cov
+}
+
+// Codes returns the coverage of the codes by hash
+func (c *Coverage) Codes() map[types.Hash]*CodeCoverage {
+	return c.codes
+}
+
+// TxStart implements the Tracer interface
+func (c *Coverage) TxStart(gasLimit uint64) {
+	c.stack = c.stack[:0]
+	c.branch = nil
+}
+
+// TxEnd implements the Tracer interface
+func (c *Coverage) TxEnd(gasLeft uint64) {
+}
+
+// CallStart implements the Tracer interface
+func (c *Coverage) CallStart(depth int, from, to types.Address, callType runtime.CallType, gas uint64, value *big.Int, input []byte) {
+	c.stack = append(c.stack, nil)
+}
+
+// CallEnd implements the Tracer interface
+func (c *Coverage) CallEnd(depth int, output []byte, gasUsed uint64, err error) {
+	c.stack = c.stack[:len(c.stack)-1]
+}
+
+// CaptureState implements the Tracer interface
+func (c *Coverage) CaptureState(scope runtime.ScopeContext, host runtime.Host) {
+	cov := c.stack[len(c.stack)-1]
+	if cov == nil {
+		code := scope.Contract().Code
+		cov = c.lookupCode(types.BytesToHash(helper.Keccak256(code)), code)
+		c.stack[len(c.stack)-1] = cov
+	}
+	cov.Hits[scope.PC()]++
+
+	c.branch = nil
+	if evm.OpCode(scope.Op()) == evm.JUMPI {
+		// the condition is the second item of the stack
+		if stack := scope.Stack(); len(stack) >= 2 {
+			c.branch = &coverageBranch{
+				code:  cov,
+				pc:    scope.PC(),
+				taken: stack[len(stack)-2].Sign() != 0,
+			}
+		}
+	}
+}
+
+// ExecuteState implements the Tracer interface
+func (c *Coverage) ExecuteState(pc uint64, op byte, cost uint64) {
+	if c.branch == nil {
+		return
+	}
+	branch, ok := c.branch.code.Branches[c.branch.pc]
+	if !ok {
+		branch = &BranchCoverage{}
+		c.branch.code.Branches[c.branch.pc] = branch
+	}
+	if c.branch.taken {
+		branch.Taken++
+	} else {
+		branch.NotTaken++
+	}
+	c.branch = nil
+}
+
+// CaptureFault implements the Tracer interface
+func (c *Coverage) CaptureFault(pc uint64, op byte, cost uint64, err error) {
+	c.branch = nil
+}
+
+func (c *Coverage) sortedHashes() []types.Hash {
+	hashes := make([]types.Hash, 0, len(c.codes))
+	for hash := range c.codes {
+		hashes = append(hashes, hash)
+	}
+	sort.Slice(hashes, func(i, j int) bool {
+		return hashes[i].String() < hashes[j].String()
+	})
+	return hashes
+}
+
+// WriteReport writes the number of executions of each opcode
+// and the outcomes of the conditional jumps of every code
+func (c *Coverage) WriteReport(w io.Writer) error {
+	buf := bufio.NewWriter(w)
+
+	for _, hash := range c.sortedHashes() {
+		cov := c.codes[hash]
+		pcs := cov.Instructions()
+
+		fmt.Fprintf(buf, "code %s: %d/%d instructions\n", hash, len(cov.Hits), len(pcs))
+		for _, pc := range pcs {
+			fmt.Fprintf(buf, "%6d %-14s %d", pc, opName(evm.OpCode(cov.Code[pc])), cov.Hits[pc])
+			if branch, ok := cov.Branches[pc]; ok {
+				fmt.Fprintf(buf, " taken %d not taken %d", branch.Taken, branch.NotTaken)
+			}
+			fmt.Fprintln(buf)
+		}
+	}
+	return buf.Flush()
+}
+
+type lcovBranch struct {
+	pc       uint64
+	executed bool
+	branch   BranchCoverage
+}
+
+type lcovFile struct {
+	lines    map[int]uint64
+	branches map[int][]*lcovBranch
+}
+
+// WriteLCOV writes the coverage of the sources in the LCOV format. The source
+// maps are keyed by the hash of their code and the codes without a source map
+// are not included.
+func (c *Coverage) WriteLCOV(w io.Writer, sourceMaps map[types.Hash]*SourceMap) error {
+	files := map[string]*lcovFile{}
+
+	for _, hash := range c.sortedHashes() {
+		m, ok := sourceMaps[hash]
+		if !ok {
+			continue
+		}
+		cov := c.codes[hash]
+
+		// the hits of a line are the most executed instruction of the line
+		lines := map[*Source]map[int]uint64{}
+		for i, pc := range cov.Instructions() {
+			if i >= len(m.Entries) {
+				break
+			}
+			entry := m.Entries[i]
+			if entry.File < 0 || entry.File >= len(m.Sources) {
+				continue
+			}
+			source := m.Sources[entry.File]
+			line := source.line(entry.Start)
+
+			file, ok := files[source.Name]
+			if !ok {
+				file = &lcovFile{
+					lines:    map[int]uint64{},
+					branches: map[int][]*lcovBranch{},
+				}
+				files[source.Name] = file
+			}
+			if lines[source] == nil {
+				lines[source] = map[int]uint64{}
+			}
+			if hits := cov.Hits[pc]; hits > lines[source][line] {
+				lines[source][line] = hits
+			}
+			if _, ok := file.lines[line]; !ok {
+				file.lines[line] = 0
+			}
+
+			if cov.Code[pc] == evm.JUMPI {
+				branch := &lcovBranch{pc: pc}
+				if b, ok := cov.Branches[pc]; ok {
+					branch.executed = true
+					branch.branch = *b
+				}
+				file.branches[line] = append(file.branches[line], branch)
+			}
+		}
+
+		// the hits of the lines of different codes add up
+		for source, hits := range lines {
+			file := files[source.Name]
+			for line, n := range hits {
+				file.lines[line] += n
+			}
+		}
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	buf := bufio.NewWriter(w)
+	for _, name := range names {
+		file := files[name]
+
+		fmt.Fprintf(buf, "TN:\nSF:%s\n", name)
+
+		lines := make([]int, 0, len(file.lines))
+		for line := range file.lines {
+			lines = append(lines, line)
+		}
+		sort.Ints(lines)
+
+		var branchesFound, branchesHit int
+		for _, line := range lines {
+			for _, b := range file.branches[line] {
+				for i, n := range []uint64{b.branch.Taken, b.branch.NotTaken} {
+					taken := "-"
+					if b.executed {
+						taken = fmt.Sprintf("%d", n)
+					}
+					fmt.Fprintf(buf, "BRDA:%d,%d,%d,%s\n", line, b.pc, i, taken)
+
+					branchesFound++
+					if n != 0 {
+						branchesHit++
+					}
+				}
+			}
+		}
+		fmt.Fprintf(buf, "BRF:%d\nBRH:%d\n", branchesFound, branchesHit)
+
+		var linesHit int
+		for _, line := range lines {
+			fmt.Fprintf(buf, "DA:%d,%d\n", line, file.lines[line])
+			if file.lines[line] != 0 {
+				linesHit++
+			}
+		}
+		fmt.Fprintf(buf, "LF:%d\nLH:%d\nend_of_record\n", len(lines), linesHit)
+	}
+	return buf.Flush()
+}
@@ -0,0 +1,288 @@
+package tracer
+
+import (
+	"encoding/json"
+	"math/big"
+
+	state "github.com/0xPolygon/eth-state-transition"
+	"github.com/0xPolygon/eth-state-transition/helper"
+	"github.com/0xPolygon/eth-state-transition/runtime"
+	"github.com/0xPolygon/eth-state-transition/runtime/evm"
+	"github.com/0xPolygon/eth-state-transition/types"
+)
+
+var _ state.TxnTracer = &PrestateTracer{}
+
+// PrestateTracerConfig are the options of the prestate tracer
+type PrestateTracerConfig struct {
+	// DiffMode also computes the values before and after the
+	// transaction of the accounts it modified
+	DiffMode bool
+}
+
+// PrestateAccount is the state of an account
+type PrestateAccount struct {
+	Balance *big.Int
+	Nonce   uint64
+	Code    []byte
+	Storage map[types.Hash]types.Hash
+}
+
+type prestateAccountJSON struct {
+	Balance string                    `json:"balance,omitempty"`
+	Nonce   uint64                    `json:"nonce,omitempty"`
+	Code    string                    `json:"code,omitempty"`
+	Storage map[types.Hash]types.Hash `json:"storage,omitempty"`
+}
+
+// MarshalJSON encodes the account in the format of the prestateTracer of geth
+func (p *PrestateAccount) MarshalJSON() ([]byte, error) {
+	acc := &prestateAccountJSON{
+		Nonce:   p.Nonce,
+		Storage: p.Storage,
+	}
+	if p.Balance != nil {
+		acc.Balance = "0x" + p.Balance.Text(16)
+	}
+	if len(p.Code) != 0 {
+		acc.Code = helper.EncodeToHex(p.Code)
+	}
+	return json.Marshal(acc)
+}
+
+// Prestate is the state of a set of accounts
+type Prestate map[types.Address]*PrestateAccount
+
+// StateDiff are the values of the accounts modified by a transaction before and after
+// its execution. The post state only includes the modified fields. The accounts created
+// are not included in the pre state and the accounts destroyed in the post state.
+type StateDiff struct {
+	Pre  Prestate `json:"pre"`
+	Post Prestate `json:"post"`
+}
+
+// PrestateTracer is a tracer that records the state of the accounts and storage
+// slots accessed by a transaction before its execution
+type PrestateTracer struct {
+	config PrestateTracerConfig
+
+	// pre is a copy of the state before the transaction
+	pre *state.Txn
+
+	// txn is the state the transaction is applied to
+	txn *state.Txn
+
+	// accessed are the accounts and slots accessed by the transaction
+	accessed map[types.Address]map[types.Hash]struct{}
+
+	// created are the accounts created by the transaction
+	created map[types.Address]struct{}
+
+	prestate Prestate
+	diff     *StateDiff
+}
+
+// NewPrestateTracer creates a new prestate tracer
+func NewPrestateTracer(config *PrestateTracerConfig) *PrestateTracer {
+	p := &PrestateTracer{}
+	if config != nil {
+		p.config = *config
+	}
+	return p
+}
+
+// TxnStart implements the TxnTracer interface
+func (p *PrestateTracer) TxnStart(txn *state.Txn) {
+	p.txn = txn
+	p.pre = txn.Copy()
+}
+
+// TxStart implements the Tracer interface
+func (p *PrestateTracer) TxStart(gasLimit uint64) {
+	p.accessed = map[types.Address]map[types.Hash]struct{}{}
+	p.created = map[types.Address]struct{}{}
+	p.prestate = nil
+	p.diff = nil
+}
+
+// TxEnd implements the Tracer interface
+func (p *PrestateTracer) TxEnd(gasLeft uint64) {
+	// the accounts modified without being accessed by the execution
+	// (i.e. the coinbase) are found in the changes of the state
+	for _, obj := range p.txn.Copy().Commit() {
+		changed := p.pre.Exist(obj.Address) != p.txn.Exist(obj.Address) ||
+			p.txn.HasSuicided(obj.Address) ||
+			obj.Nonce != p.pre.GetNonce(obj.Address) ||
+			obj.Balance.Cmp(p.pre.GetBalance(obj.Address)) != 0 ||
+			obj.CodeHash != p.pre.GetCodeHash(obj.Address)
+
+		for _, entry := range obj.Storage {
+			key := types.BytesToHash(entry.Key)
+			if p.pre.GetState(obj.Address, key) != p.txn.GetState(obj.Address, key) {
+				p.addSlot(obj.Address, key)
+			}
+		}
+		if changed {
+			p.addAccount(obj.Address)
+		}
+	}
+
+	p.prestate = Prestate{}
+	for addr, slots := range p.accessed {
+		// the accounts created do not have a previous state
+		if _, ok := p.created[addr]; ok && !p.pre.Exist(addr) {
+			continue
+		}
+		acc := lookupAccount(p.pre, addr)
+		for key := range slots {
+			acc.Storage[key] = p.pre.GetState(addr, key)
+		}
+		p.prestate[addr] = acc
+	}
+
+	if p.config.DiffMode {
+		p.diff = p.stateDiff()
+	}
+}
+
+func (p *PrestateTracer) stateDiff() *StateDiff {
+	diff := &StateDiff{
+		Pre:  Prestate{},
+		Post: Prestate{},
+	}
+
+	for addr, slots := range p.accessed {
+		existed := p.pre.Exist(addr)
+		pre := lookupAccount(p.pre, addr)
+
+		if p.txn.HasSuicided(addr) || !p.txn.Exist(addr) {
+			if existed {
+				for key := range slots {
+					if value := p.pre.GetState(addr, key); value != (types.Hash{}) {
+						pre.Storage[key] = value
+					}
+				}
+				diff.Pre[addr] = pre
+			}
+			continue
+		}
+
+		post := &PrestateAccount{
+			Storage: map[types.Hash]types.Hash{},
+		}
+		modified := false
+
+		if balance := p.txn.GetBalance(addr); balance.Cmp(pre.Balance) != 0 {
+			post.Balance = new(big.Int).Set(balance)
+			modified = true
+		}
+		if nonce := p.txn.GetNonce(addr); nonce != pre.Nonce {
+			post.Nonce = nonce
+			modified = true
+		}
+		if p.txn.GetCodeHash(addr) != p.pre.GetCodeHash(addr) {
+			post.Code = p.txn.GetCode(addr)
+			modified = true
+		}
+		for key := range slots {
+			before, after := p.pre.GetState(addr, key), p.txn.GetState(addr, key)
+			if before == after {
+				continue
+			}
+			modified = true
+
+			// the empty slots are not included
+			if before != (types.Hash{}) {
+				pre.Storage[key] = before
+			}
+			if after != (types.Hash{}) {
+				post.Storage[key] = after
+			}
+		}
+
+		if !modified {
+			continue
+		}
+		if existed {
+			diff.Pre[addr] = pre
+		}
+		diff.Post[addr] = post
+	}
+	return diff
+}
+
+// CallStart implements the Tracer interface
+func (p *PrestateTracer) CallStart(depth int, from, to types.Address, callType runtime.CallType, gas uint64, value *big.Int, input []byte) {
+	p.addAccount(from)
+	p.addAccount(to)
+
+	if callType == runtime.Create || callType == runtime.Create2 {
+		p.created[to] = struct{}{}
+	}
+}
+
+// CallEnd implements the Tracer interface
+func (p *PrestateTracer) CallEnd(depth int, output []byte, gasUsed uint64, err error) {
+}
+
+// CaptureState implements the Tracer interface
+func (p *PrestateTracer) CaptureState(scope runtime.ScopeContext, host runtime.Host) {
+	stack := scope.Stack()
+
+	switch evm.OpCode(scope.Op()) {
+	case evm.SLOAD, evm.SSTORE:
+		if len(stack) >= 1 {
+			p.addSlot(scope.Contract().Address, bigToHash(stack[len(stack)-1]))
+		}
+
+	case evm.BALANCE, evm.EXTCODESIZE, evm.EXTCODECOPY, evm.EXTCODEHASH:
+		if len(stack) >= 1 {
+			p.addAccount(types.BytesToAddress(stack[len(stack)-1].Bytes()))
+		}
+
+	case evm.CALL, evm.CALLCODE, evm.DELEGATECALL, evm.STATICCALL:
+		// the calls that fail before starting a frame also read the account
+		if len(stack) >= 2 {
+			p.addAccount(types.BytesToAddress(stack[len(stack)-2].Bytes()))
+		}
+	}
+}
+
+// ExecuteState implements the Tracer interface
+func (p *PrestateTracer) ExecuteState(pc uint64, op byte, cost uint64) {
+}
+
+// CaptureFault implements the Tracer interface
+func (p *PrestateTracer) CaptureFault(pc uint64, op byte, cost uint64, err error) {
+}
+
+func (p *PrestateTracer) addAccount(addr types.Address) {
+	if _, ok := p.accessed[addr]; !ok {
+		p.accessed[addr] = map[types.Hash]struct{}{}
+	}
+}
+
+func (p *PrestateTracer) addSlot(addr types.Address, key types.Hash) {
+	p.addAccount(addr)
+	p.accessed[addr][key] = struct{}{}
+}
+
+// Prestate returns the state before the last transaction of the accounts
+// and slots it accessed
+func (p *PrestateTracer) Prestate() Prestate {
+	return p.prestate
+}
+
+// Diff returns the changes of the last transaction if the tracer is in diff mode
+func (p *PrestateTracer) Diff() *StateDiff {
+	return p.diff
+}
+
+func lookupAccount(txn *state.Txn, addr types.Address) *PrestateAccount {
+	return &PrestateAccount{
+		Balance: new(big.Int).Set(txn.GetBalance(addr)),
+		Nonce:   txn.GetNonce(addr),
+		Code:    txn.GetCode(addr),
+		Storage: map[types.Hash]types.Hash{},
+	}
+}
@@ -0,0 +1,271 @@
+package tracer
+
+import (
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/google/pprof/profile"
+
+	"github.com/0xPolygon/eth-state-transition/runtime"
+	"github.com/0xPolygon/eth-state-transition/runtime/evm"
+	"github.com/0xPolygon/eth-state-transition/types"
+)
+
+var _ runtime.Tracer = &GasProfiler{}
+
+// intrinsicFunction is the name of the pseudo function of the intrinsic gas
+const intrinsicFunction = "(intrinsic)"
+
+// stipendFunction is the name of the pseudo function of the stipend of the calls with value
+const stipendFunction = "(stipend)"
+
+// profileLocation is a position in the code of a contract or an opcode
+type profileLocation struct {
+	function string
+	line     int64
+}
+
+type profileSample struct {
+	stack []profileLocation
+	gas   int64
+	steps uint64
+}
+
+type profileFrame struct {
+	function string
+	address  types.Address
+	gas      uint64
+
+	// pc is the position of the opcode being executed
+	pc uint64
+
+	// attributed is the gas used by the opcodes of the frame and by its children
+	attributed uint64
+
+	// childGas is the gas given to the last child frame
+	childGas uint64
+
+	// stipend is the free gas of the frame if it is a call with value
+	stipend uint64
+}
+
+// GasProfiler is a tracer that attributes the gas used by the transactions
+// to the opcodes, code positions and call stacks of the contracts. The gas of
+// a call or a creation only includes its own costs, the gas used by the new
+// frame is attributed to the code of the frame. The gas used by a frame outside
+// of its opcodes (i.e. precompiled contracts or code deposit) is attributed to
+// the frame itself. The stipend given for free to the new frame of a call with
+// value is a negative sample of the caller, so the profile adds up to the gas used.
+type GasProfiler struct {
+	stack    []*profileFrame
+	gasLimit uint64
+
+	samples map[string]*profileSample
+
+	byOpcode   map[string]uint64
+	byContract map[types.Address]uint64
+}
+
+// NewGasProfiler creates a new gas profiler. The profile accumulates the
+// gas of all the transactions traced.
+func NewGasProfiler() *GasProfiler {
+	return &GasProfiler{
+		samples:    map[string]*profileSample{},
+		byOpcode:   map[string]uint64{},
+		byContract: map[types.Address]uint64{},
+	}
+}
+
+// TxStart implements the Tracer interface
+func (g *GasProfiler) TxStart(gasLimit uint64) {
+	g.stack = g.stack[:0]
+	g.gasLimit = gasLimit
+}
+
+// TxEnd implements the Tracer interface
+func (g *GasProfiler) TxEnd(gasLeft uint64) {
+}
+
+// CallStart implements the Tracer interface
+func (g *GasProfiler) CallStart(depth int, from, to types.Address, callType runtime.CallType, gas uint64, value *big.Int, input []byte) {
+	if len(g.stack) == 0 && g.gasLimit > gas {
+		g.record([]profileLocation{{function: intrinsicFunction}}, int64(g.gasLimit-gas), 0)
+	}
+
+	function := to.String()
+	if callType == runtime.Create || callType == runtime.Create2 {
+		// the init code is not the code of the contract
+		function += " (init)"
+	}
+	frame := &profileFrame{
+		function: function,
+		address:  to,
+		gas:      gas,
+	}
+	if len(g.stack) != 0 && (callType == runtime.Call || callType == runtime.CallCode) && value != nil && value.Sign() != 0 {
+		frame.stipend = runtime.CallStipend
+	}
+	g.stack = append(g.stack, frame)
+}
+
+// CallEnd implements the Tracer interface
+func (g *GasProfiler) CallEnd(depth int, output []byte, gasUsed uint64, err error) {
+	frame := g.stack[len(g.stack)-1]
+
+	if gasUsed > frame.attributed {
+		stack := g.locations()
+		stack[len(stack)-1].line = 0
+
+		g.record(stack, int64(gasUsed-frame.attributed), 0)
+		g.byContract[frame.address] += gasUsed - frame.attributed
+	}
+	g.stack = g.stack[:len(g.stack)-1]
+
+	if len(g.stack) != 0 {
+		parent := g.stack[len(g.stack)-1]
+		parent.attributed += gasUsed
+		parent.childGas = frame.gas - frame.stipend
+
+		// the stipend is free gas of the new frame that the caller gets back
+		// with the gas left, so it is not paid by the transaction
+		if frame.stipend != 0 {
+			parent.attributed -= frame.stipend
+			g.record(append(g.locations(), profileLocation{function: stipendFunction}), -int64(frame.stipend), 0)
+		}
+	}
+}
+
+// CaptureState implements the Tracer interface
+func (g *GasProfiler) CaptureState(scope runtime.ScopeContext, host runtime.Host) {
+	frame := g.stack[len(g.stack)-1]
+	frame.pc = scope.PC()
+	frame.childGas = 0
+}
+
+// ExecuteState implements the Tracer interface
+func (g *GasProfiler) ExecuteState(pc uint64, op byte, cost uint64) {
+	g.step(op, cost)
+}
+
+// CaptureFault implements the Tracer interface
+func (g *GasProfiler) CaptureFault(pc uint64, op byte, cost uint64, err error) {
+	g.step(op, cost)
+}
+
+func (g *GasProfiler) step(op byte, cost uint64) {
+	frame := g.stack[len(g.stack)-1]
+
+	// the gas given to a child frame is attributed to the child. The
+	// stipend of the calls with value is not taken from the gas of the
+	// caller, so the cost of the call is the same.
+	if cost > frame.childGas {
+		cost -= frame.childGas
+	} else {
+		cost = 0
+	}
+	frame.attributed += cost
+
+	name := opName(evm.OpCode(op))
+	g.record(append(g.locations(), profileLocation{function: name}), int64(cost), 1)
+
+	g.byOpcode[name] += cost
+	g.byContract[frame.address] += cost
+}
+
+// locations returns the call stack with the current position of each frame
+func (g *GasProfiler) locations() []profileLocation {
+	stack := make([]profileLocation, 0, len(g.stack)+1)
+	for _, frame := range g.stack {
+		stack = append(stack, profileLocation{
+			function: frame.function,
+			line:     int64(frame.pc),
+		})
+	}
+	return stack
+}
+
+func (g *GasProfiler) record(stack []profileLocation, gas int64, steps uint64) {
+	var key strings.Builder
+	for _, loc := range stack {
+		key.WriteString(loc.function)
+		key.WriteByte(':')
+		key.WriteString(strconv.FormatInt(loc.line, 10))
+		key.WriteByte(';')
+	}
+
+	sample, ok := g.samples[key.String()]
+	if !ok {
+		sample = &profileSample{stack: stack}
+		g.samples[key.String()] = sample
+	}
+	sample.gas += gas
+	sample.steps += steps
+}
+
+// ByOpcode returns the gas used by each opcode
+func (g *GasProfiler) ByOpcode() map[string]uint64 {
+	return g.byOpcode
+}
+
+// ByContract returns the gas used by the code of each contract
+func (g *GasProfiler) ByContract() map[types.Address]uint64 {
+	return g.byContract
+}
+
+// Profile returns the gas profile in the pprof format. Each contract is a function
+// with the positions in its code as lines and the opcodes are the leaf functions.
+func (g *GasProfiler) Profile() *profile.Profile {
+	p := &profile.Profile{
+		SampleType: []*profile.ValueType{
+			{Type: "gas", Unit: "gas"},
+			{Type: "steps", Unit: "count"},
+		},
+		DefaultSampleType: "gas",
+	}
+
+	functions := map[string]*profile.Function{}
+	locations := map[profileLocation]*profile.Location{}
+
+	location := func(loc profileLocation) *profile.Location {
+		if l, ok := locations[loc]; ok {
+			return l
+		}
+		fn, ok := functions[loc.function]
+		if !ok {
+			fn = &profile.Function{
+				ID:         uint64(len(p.Function) + 1),
+				Name:       loc.function,
+				SystemName: loc.function,
+			}
+			functions[loc.function] = fn
+			p.Function = append(p.Function, fn)
+		}
+		l := &profile.Location{
+			ID:   uint64(len(p.Location) + 1),
+			Line: []profile.Line{{Function: fn, Line: loc.line}},
+		}
+		locations[loc] = l
+		p.Location = append(p.Location, l)
+		return l
+	}
+
+	for _, sample := range g.samples {
+		// the locations of the samples go from the leaf to the root
+		locs := make([]*profile.Location, len(sample.stack))
+		for i, loc := range sample.stack {
+			locs[len(locs)-1-i] = location(loc)
+		}
+		p.Sample = append(p.Sample, &profile.Sample{
+			Location: locs,
+			Value:    []int64{sample.gas, int64(sample.steps)},
+		})
+	}
+	return p
+}
+
+// WriteProfile writes the gas profile in the gzipped pprof format
+func (g *GasProfiler) WriteProfile(w io.Writer) error {
+	return g.Profile().Write(w)
+}
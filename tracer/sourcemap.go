@@ -0,0 +1,100 @@
+package tracer
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Source is a source file of a contract
+type Source struct {
+	Name    string
+	Content []byte
+
+	// lines are the offsets of the start of each line
+	lines []int
+}
+
+// line returns the line number, starting at 1, of an offset of the source
+func (s *Source) line(offset int) int {
+	if s.lines == nil {
+		s.lines = []int{0}
+		for i, c := range s.Content {
+			if c == '\n' {
+				s.lines = append(s.lines, i+1)
+			}
+		}
+	}
+	return sort.SearchInts(s.lines, offset+1)
+}
+
+// SourceMapEntry is the location in the sources of an instruction
+type SourceMapEntry struct {
+	// Start and Length are the range of the instruction in the source
+	Start  int
+	Length int
+
+	// File is the index of the source, -1 if the instruction does not
+	// belong to any source. The instructions of the sources generated by
+	// the compiler have an index out of the sources given.
+	File int
+
+	// Jump is i for a jump into a function, o for a return and - otherwise
+	Jump byte
+}
+
+// SourceMap maps the instructions of a code to the locations in its sources
+type SourceMap struct {
+	Sources []*Source
+
+	// Entries are the locations of the instructions in their order in the code
+	Entries []SourceMapEntry
+}
+
+// ParseSourceMap parses a source map in the compressed format of solc
+// (s:l:f:j;...) with the sources in the order of their index
+func ParseSourceMap(str string, sources []*Source) (*SourceMap, error) {
+	m := &SourceMap{
+		Sources: sources,
+	}
+	if str == "" {
+		return m, nil
+	}
+
+	// the fields that are not set take the value of the previous entry
+	entry := SourceMapEntry{File: -1, Jump: '-'}
+	for i, item := range strings.Split(str, ";") {
+		for j, field := range strings.Split(item, ":") {
+			if field == "" {
+				continue
+			}
+			if j == 3 {
+				if field != "i" && field != "o" && field != "-" {
+					return nil, fmt.Errorf("invalid jump '%s' in entry %d", field, i)
+				}
+				entry.Jump = field[0]
+				continue
+			}
+			if j > 3 {
+				// the modifier depth is not used
+				continue
+			}
+
+			n, err := strconv.Atoi(field)
+			if err != nil {
+				return nil, fmt.Errorf("invalid field '%s' in entry %d", field, i)
+			}
+			switch j {
+			case 0:
+				entry.Start = n
+			case 1:
+				entry.Length = n
+			case 2:
+				entry.File = n
+			}
+		}
+		m.Entries = append(m.Entries, entry)
+	}
+	return m, nil
+}
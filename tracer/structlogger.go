@@ -0,0 +1,231 @@
+package tracer
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/0xPolygon/eth-state-transition/runtime"
+	"github.com/0xPolygon/eth-state-transition/runtime/evm"
+	"github.com/0xPolygon/eth-state-transition/types"
+)
+
+var _ runtime.Tracer = &StructLogger{}
+
+// StructLoggerConfig are the options of the struct logger
+type StructLoggerConfig struct {
+	DisableStack   bool
+	DisableMemory  bool
+	DisableStorage bool
+}
+
+// StructLog is the state of the EVM before the execution of an opcode in
+// the format of the debug_traceTransaction endpoint of geth
+type StructLog struct {
+	Pc            uint64             `json:"pc"`
+	Op            string             `json:"op"`
+	Gas           uint64             `json:"gas"`
+	GasCost       uint64             `json:"gasCost"`
+	Depth         int                `json:"depth"`
+	Error         string             `json:"error,omitempty"`
+	Stack         *[]string          `json:"stack,omitempty"`
+	Memory        *[]string          `json:"memory,omitempty"`
+	Storage       *map[string]string `json:"storage,omitempty"`
+	RefundCounter uint64             `json:"refund,omitempty"`
+}
+
+// ExecutionResult is the trace of a transaction
+type ExecutionResult struct {
+	Gas         uint64       `json:"gas"`
+	Failed      bool         `json:"failed"`
+	ReturnValue string       `json:"returnValue"`
+	StructLogs  []*StructLog `json:"structLogs"`
+}
+
+// refunder is implemented by the hosts that track the gas refund
+type refunder interface {
+	GetRefund() uint64
+}
+
+// StructLogger is a tracer that records the state of the EVM at each opcode
+type StructLogger struct {
+	config StructLoggerConfig
+
+	logs []*StructLog
+
+	// pending are the logs of the opcodes being executed, one per call frame
+	pending []*StructLog
+
+	// storage are the slots accessed by each contract
+	storage map[types.Address]map[types.Hash]types.Hash
+
+	gasLimit uint64
+	gasUsed  uint64
+	output   []byte
+	err      error
+}
+
+// NewStructLogger creates a new struct logger
+func NewStructLogger(config *StructLoggerConfig) *StructLogger {
+	s := &StructLogger{}
+	if config != nil {
+		s.config = *config
+	}
+	s.reset()
+	return s
+}
+
+func (s *StructLogger) reset() {
+	s.logs = []*StructLog{}
+	s.pending = s.pending[:0]
+	s.storage = map[types.Address]map[types.Hash]types.Hash{}
+	s.gasUsed = 0
+	s.output = nil
+	s.err = nil
+}
+
+// TxStart implements the Tracer interface
+func (s *StructLogger) TxStart(gasLimit uint64) {
+	s.reset()
+	s.gasLimit = gasLimit
+}
+
+// TxEnd implements the Tracer interface
+func (s *StructLogger) TxEnd(gasLeft uint64) {
+	s.gasUsed = s.gasLimit - gasLeft
+}
+
+// CallStart implements the Tracer interface
+func (s *StructLogger) CallStart(depth int, from, to types.Address, callType runtime.CallType, gas uint64, value *big.Int, input []byte) {
+}
+
+// CallEnd implements the Tracer interface
+func (s *StructLogger) CallEnd(depth int, output []byte, gasUsed uint64, err error) {
+	if depth == 1 {
+		s.output = append([]byte{}, output...)
+		s.err = err
+	}
+}
+
+// CaptureState implements the Tracer interface
+func (s *StructLogger) CaptureState(scope runtime.ScopeContext, host runtime.Host) {
+	op := evm.OpCode(scope.Op())
+
+	log := &StructLog{
+		Pc:    scope.PC(),
+		Op:    opName(op),
+		Gas:   scope.Gas(),
+		Depth: scope.Depth(),
+	}
+
+	stack := scope.Stack()
+	if !s.config.DisableStack {
+		items := make([]string, len(stack))
+		for i, item := range stack {
+			items[i] = "0x" + item.Text(16)
+		}
+		log.Stack = &items
+	}
+
+	if !s.config.DisableMemory {
+		memory := scope.Memory()
+		words := make([]string, 0, len(memory)/32)
+		for i := 0; i+32 <= len(memory); i += 32 {
+			words = append(words, fmt.Sprintf("%x", memory[i:i+32]))
+		}
+		log.Memory = &words
+	}
+
+	// the storage only includes the slots accessed by the contract so far
+	if !s.config.DisableStorage && (op == evm.SLOAD || op == evm.SSTORE) {
+		addr := scope.Contract().Address
+		slots, ok := s.storage[addr]
+		if !ok {
+			slots = map[types.Hash]types.Hash{}
+			s.storage[addr] = slots
+		}
+
+		captured := true
+		if op == evm.SLOAD && len(stack) >= 1 {
+			key := bigToHash(stack[len(stack)-1])
+			slots[key] = host.GetStorage(addr, key)
+		} else if op == evm.SSTORE && len(stack) >= 2 {
+			key := bigToHash(stack[len(stack)-1])
+			slots[key] = bigToHash(stack[len(stack)-2])
+		} else {
+			captured = false
+		}
+
+		if captured {
+			storage := make(map[string]string, len(slots))
+			for key, value := range slots {
+				storage[fmt.Sprintf("%x", key[:])] = fmt.Sprintf("%x", value[:])
+			}
+			log.Storage = &storage
+		}
+	}
+
+	if r, ok := host.(refunder); ok {
+		log.RefundCounter = r.GetRefund()
+	}
+
+	s.logs = append(s.logs, log)
+	s.pending = append(s.pending, log)
+}
+
+// ExecuteState implements the Tracer interface
+func (s *StructLogger) ExecuteState(pc uint64, op byte, cost uint64) {
+	log := s.pop()
+	log.GasCost = cost
+}
+
+// CaptureFault implements the Tracer interface
+func (s *StructLogger) CaptureFault(pc uint64, op byte, cost uint64, err error) {
+	log := s.pop()
+	log.GasCost = cost
+	log.Error = err.Error()
+}
+
+func (s *StructLogger) pop() *StructLog {
+	log := s.pending[len(s.pending)-1]
+	s.pending = s.pending[:len(s.pending)-1]
+	return log
+}
+
+// StructLogs returns the logs of the last transaction
+func (s *StructLogger) StructLogs() []*StructLog {
+	return s.logs
+}
+
+// Result returns the trace of the last transaction. The return value is
+// the output of the transaction if it succeeded or reverted.
+func (s *StructLogger) Result() *ExecutionResult {
+	failed := s.err != nil
+
+	returnValue := fmt.Sprintf("%x", s.output)
+	if failed && s.err != runtime.ErrExecutionReverted {
+		returnValue = ""
+	}
+
+	return &ExecutionResult{
+		Gas:         s.gasUsed,
+		Failed:      failed,
+		ReturnValue: returnValue,
+		StructLogs:  s.logs,
+	}
+}
+
+// opName returns the name of the opcode as reported by geth
+func opName(op evm.OpCode) string {
+	switch op {
+	case evm.SHA3:
+		return "KECCAK256"
+	}
+	if name := op.String(); name != "" {
+		return name
+	}
+	return fmt.Sprintf("opcode %#x not defined", int(op))
+}
+
+func bigToHash(b *big.Int) types.Hash {
+	return types.BytesToHash(b.Bytes())
+}
@@ -20,6 +20,10 @@ const (
 
 	// Per transaction that creates a contract
 	TxGasContractCreation uint64 = 53000
+
+	// Per address and storage key of the access list (EIP-2930)
+	TxAccessListAddressGas    uint64 = 2400
+	TxAccessListStorageKeyGas uint64 = 1900
 )
 
 var emptyCodeHashTwo = types.BytesToHash(helper.Keccak256(nil))
@@ -49,6 +53,25 @@ type Transition struct {
 
 	// counter on the total gas used so far
 	totalGas uint64
+
+	// tracer receives the events of the execution if it is set
+	tracer runtime.Tracer
+
+	// depth is the depth of the call frame being executed
+	depth int
+
+	// codeCache is the cache of the code of the contracts
+	codeCache *evm.CodeCache
+
+	// evmRuntime is the EVM runtime of the transition
+	evmRuntime *evm.EVM
+
+	// deferFee does not pay the fees to the coinbase, the parallel executor
+	// pays them when the speculative transactions are committed in order
+	deferFee bool
+
+	// parallelStats counts the transactions written by WriteParallel
+	parallelStats ParallelStats
 }
 
 // NewExecutor creates a new executor
@@ -63,7 +86,10 @@ func NewTransition(forks runtime.ForksInTime, ctx runtime.TxContext, snap Snapsh
 		totalGas: 0,
 	}
 
-	transition.SetRuntime(evm.NewEVM())
+	transition.evmRuntime = evm.NewEVM()
+	transition.codeCache = transition.evmRuntime.CodeCache()
+
+	transition.SetRuntime(transition.evmRuntime)
 	transition.SetRuntime(precompiled.NewPrecompiled())
 
 	// by default for getHash use a simple one
@@ -82,16 +108,46 @@ func (t *Transition) TotalGas() uint64 {
 	return t.totalGas
 }
 
+// ParallelStats returns the counters of the transactions written in parallel
+func (t *Transition) ParallelStats() ParallelStats {
+	return t.parallelStats
+}
+
 func (e *Transition) SetRuntime(r runtime.Runtime) {
 	e.runtimes = append([]runtime.Runtime{r}, e.runtimes...)
 }
 
+// SetCodeCache sets the cache of the code of the contracts, the cache
+// can be shared by many transitions
+func (t *Transition) SetCodeCache(cache *evm.CodeCache) {
+	t.codeCache = cache
+	t.evmRuntime.SetCodeCache(cache)
+}
+
+// SetExecutionMode sets how the EVM of the transition executes the code
+func (t *Transition) SetExecutionMode(mode evm.ExecutionMode) {
+	t.evmRuntime.SetExecutionMode(mode)
+}
+
 type BlockResult struct {
 	Root     types.Hash
 	Receipts []*Result
 	TotalGas uint64
 }
 
+// TxnTracer is a tracer that reads the state of the transactions it traces
+type TxnTracer interface {
+	runtime.Tracer
+
+	// TxnStart is called before each transaction with the state it is applied to
+	TxnStart(txn *Txn)
+}
+
+// SetTracer sets the tracer for the next transactions. A nil tracer disables the tracing.
+func (t *Transition) SetTracer(tracer runtime.Tracer) {
+	t.tracer = tracer
+}
+
 func (t *Transition) SetGetHash(helper GetHashByNumberHelper) {
 	t.getHash = helper(uint64(t.ctx.Number), t.ctx.Hash)
 }
@@ -125,13 +181,12 @@ func (t *Transition) Write(txn *Transaction) (*Result, error) {
 
 	logs := t.txn.Logs()
 
-	// var root []byte
-
 	receipt := &Result{
-		// CumulativeGasUsed: t.totalGas,
-		// TxHash:            txn.Hash,
-		GasUsed:     result.GasUsed,
-		ReturnValue: result.ReturnValue,
+		Type:              txn.Type,
+		CumulativeGasUsed: t.totalGas,
+		TxHash:            txn.Hash,
+		GasUsed:           result.GasUsed,
+		ReturnValue:       result.ReturnValue,
 	}
 
 	if t.forks.Byzantium {
@@ -145,17 +200,9 @@ func (t *Transition) Write(txn *Transaction) (*Result, error) {
 		}
 
 	} else {
-		// TODO: If byzntium is enabled you need a special step to commit the data yourself
+		// The receipt root is the intermediate state root after the transaction.
+		// It is set by the caller (i.e. ApplyBlock) after committing the state.
 		t.txn.CleanDeleteObjects(t.forks.EIP158)
-
-		/*
-			objs := t.txn.Commit(t.forks.EIP155)
-			ss, aux := t.txn.snapshot.Commit(objs)
-
-			t.txn = NewTxn(ss)
-			root = aux
-			receipt.Root = types.BytesToHash(root)
-		*/
 	}
 
 	// if the transaction created a contract, store the creation address in the receipt.
@@ -165,6 +212,7 @@ func (t *Transition) Write(txn *Transaction) (*Result, error) {
 
 	// Set the receipt logs and create a bloom for filtering
 	receipt.Logs = logs
+	receipt.LogsBloom = logsBloom(logs)
 
 	return receipt, nil
 }
@@ -236,7 +284,7 @@ func (t *Transition) apply(msg *Transaction) (*runtime.ExecutionResult, error) {
 		}
 
 		// 4. there is no overflow when calculating intrinsic gas
-		intrinsicGasCost, err := TransactionGasCost(msg, t.forks.Homestead, t.forks.Istanbul)
+		intrinsicGasCost, err := TransactionGasCost(msg, t.forks.Homestead, t.forks.Istanbul, t.forks.Berlin)
 		if err != nil {
 			return err
 		}
@@ -255,10 +303,19 @@ func (t *Transition) apply(msg *Transaction) (*runtime.ExecutionResult, error) {
 		return nil
 	}
 
+	// the tracer reads the state before the gas is bought
+	if tracer, ok := t.tracer.(TxnTracer); ok {
+		tracer.TxnStart(t.txn)
+	}
+
 	if err := preCheck(); err != nil {
 		return nil, err
 	}
 
+	if t.forks.Berlin {
+		t.prepareAccessList(msg)
+	}
+
 	gasPrice := new(big.Int).Set(msg.GasPrice)
 	value := new(big.Int).Set(msg.Value)
 
@@ -266,6 +323,10 @@ func (t *Transition) apply(msg *Transaction) (*runtime.ExecutionResult, error) {
 	t.ctx.GasPrice = types.BytesToHash(gasPrice.Bytes())
 	t.ctx.Origin = msg.From
 
+	if t.tracer != nil {
+		t.tracer.TxStart(msg.Gas)
+	}
+
 	var result *runtime.ExecutionResult = nil
 	if msg.IsContractCreation() {
 		result = t.Create(msg.From, msg.Input, value, gasLeft)
@@ -275,57 +336,109 @@ func (t *Transition) apply(msg *Transaction) (*runtime.ExecutionResult, error) {
 	}
 
 	// Update gas used depending on the refund.
-	refund := txn.GetRefund()
-	{
-		result.GasUsed = msg.Gas - result.GasLeft
-		maxRefund := result.GasUsed / 2
-		// Refund can go up to half the gas used
-		if refund > maxRefund {
-			refund = maxRefund
-		}
-
-		result.GasLeft += refund
-		result.GasUsed -= refund
-	}
+	t.refundGas(msg.Gas, result)
 
 	// refund the sender
 	remaining := new(big.Int).Mul(new(big.Int).SetUint64(result.GasLeft), gasPrice)
 	txn.AddBalance(msg.From, remaining)
 
 	// pay the coinbase for the transaction
-	coinbaseFee := new(big.Int).Mul(new(big.Int).SetUint64(result.GasUsed), gasPrice)
-	txn.AddBalance(t.ctx.Coinbase, coinbaseFee)
+	if !t.deferFee {
+		coinbaseFee := new(big.Int).Mul(new(big.Int).SetUint64(result.GasUsed), gasPrice)
+		txn.AddBalance(t.ctx.Coinbase, coinbaseFee)
+	}
 
 	// return gas to the pool
 	t.addGasPool(result.GasLeft)
 
+	if t.tracer != nil {
+		t.tracer.TxEnd(result.GasLeft)
+	}
+
 	return result, nil
 }
 
+// prepareAccessList adds to the access list of the transaction the accounts
+// accessed by default and the accounts and slots of its list (eip-2929, eip-2930)
+func (t *Transition) prepareAccessList(msg *Transaction) {
+	t.txn.resetAccessList()
+
+	t.txn.AccessAddress(msg.From)
+	if msg.To != nil {
+		t.txn.AccessAddress(*msg.To)
+	}
+	for _, addr := range precompiles.Addresses(&t.forks) {
+		t.txn.AccessAddress(addr)
+	}
+	for _, tuple := range msg.AccessList {
+		t.txn.AccessAddress(tuple.Address)
+		for _, key := range tuple.StorageKeys {
+			t.txn.AccessSlot(tuple.Address, key)
+		}
+	}
+}
+
+// refundGas sets the gas used by the result of a transaction with the given gas limit
+// after the refund and returns the refunded gas
+func (t *Transition) refundGas(gas uint64, result *runtime.ExecutionResult) uint64 {
+	refund := t.txn.GetRefund()
+
+	result.GasUsed = gas - result.GasLeft
+	maxRefund := result.GasUsed / 2
+	// Refund can go up to half the gas used
+	if refund > maxRefund {
+		refund = maxRefund
+	}
+
+	result.GasLeft += refund
+	result.GasUsed -= refund
+	return refund
+}
+
 func (t *Transition) Create(caller types.Address, code []byte, value *big.Int, gas uint64) *runtime.ExecutionResult {
+	return t.create(caller, code, value, gas, t)
+}
+
+func (t *Transition) create(caller types.Address, code []byte, value *big.Int, gas uint64, host runtime.Host) *runtime.ExecutionResult {
 	address := helper.CreateAddress(caller, t.txn.GetNonce(caller))
 	contract := runtime.NewContractCreation(1, caller, caller, address, value, gas, code)
+	contract.Type = runtime.Create
+	contract.Tracer = t.tracer
 
-	res := t.applyCreate(contract, t)
+	res := t.applyCreate(contract, host)
 	res.CreateAddress = address
 	return res
 }
 
 func (t *Transition) Call(caller types.Address, to types.Address, input []byte, value *big.Int, gas uint64) *runtime.ExecutionResult {
-	c := runtime.NewContractCall(1, caller, caller, to, value, gas, t.txn.GetCode(to), input)
-	return t.applyCall(c, runtime.Call, t)
+	return t.call(caller, to, input, value, gas, t)
+}
+
+func (t *Transition) call(caller types.Address, to types.Address, input []byte, value *big.Int, gas uint64, host runtime.Host) *runtime.ExecutionResult {
+	codeHash, code := t.codeCache.GetCode(t, to)
+
+	c := runtime.NewContractCall(1, caller, caller, to, value, gas, code, input)
+	c.CodeHash = codeHash
+	c.Tracer = t.tracer
+	return t.applyCall(c, runtime.Call, host)
 }
 
 func (t *Transition) run(contract *runtime.Contract, host runtime.Host) *runtime.ExecutionResult {
+	parent := t.depth
+	t.depth = contract.Depth
+
+	result := &runtime.ExecutionResult{
+		Err: fmt.Errorf("not found"),
+	}
 	for _, r := range t.runtimes {
 		if r.CanRun(contract, host, &t.forks) {
-			return r.Run(contract, host, &t.forks)
+			result = r.Run(contract, host, &t.forks)
+			break
 		}
 	}
 
-	return &runtime.ExecutionResult{
-		Err: fmt.Errorf("not found"),
-	}
+	t.depth = parent
+	return result
 }
 
 func (t *Transition) transfer(from, to types.Address, amount *big.Int) error {
@@ -365,11 +478,27 @@ func (t *Transition) applyCall(c *runtime.Contract, callType runtime.CallType, h
 		}
 	}
 
+	if c.Tracer != nil {
+		// the delegated call is made by the contract executing it
+		from := c.Caller
+		if callType == runtime.DelegateCall {
+			from = c.Address
+		}
+		c.Tracer.CallStart(c.Depth, from, c.CodeAddress, callType, c.Gas, c.Value, c.Input)
+	}
+
+	// the precompiled contracts consume the gas of the contract
+	gas := c.Gas
+
 	result := t.run(c, host)
 	if result.Failed() {
 		t.txn.RevertToSnapshot(snapshot)
 	}
 
+	if c.Tracer != nil {
+		c.Tracer.CallEnd(c.Depth, result.ReturnValue, gas-result.GasLeft, result.Err)
+	}
+
 	return result
 }
 
@@ -400,6 +529,11 @@ func (t *Transition) applyCreate(c *runtime.Contract, host runtime.Host) *runtim
 	// Increment the nonce of the caller
 	t.txn.IncrNonce(c.Caller)
 
+	// the created address is accessed even if the creation fails (eip-2929)
+	if t.forks.Berlin {
+		t.txn.AccessAddress(c.Address)
+	}
+
 	// Check if there if there is a collision and the address already exists
 	if t.hasCodeOrNonce(c.Address) {
 		return &runtime.ExecutionResult{
@@ -425,6 +559,21 @@ func (t *Transition) applyCreate(c *runtime.Contract, host runtime.Host) *runtim
 		}
 	}
 
+	if c.Tracer != nil {
+		c.Tracer.CallStart(c.Depth, c.Caller, c.Address, c.Type, c.Gas, c.Value, c.Code)
+	}
+
+	result := t.runCreate(c, host, snapshot)
+
+	if c.Tracer != nil {
+		c.Tracer.CallEnd(c.Depth, result.ReturnValue, gasLimit-result.GasLeft, result.Err)
+	}
+
+	return result
+}
+
+// runCreate runs the init code of the contract and stores the code it returns
+func (t *Transition) runCreate(c *runtime.Contract, host runtime.Host, snapshot int) *runtime.ExecutionResult {
 	result := t.run(c, host)
 
 	if result.Failed() {
@@ -510,7 +659,26 @@ func (t *Transition) GetNonce(addr types.Address) uint64 {
 	return t.txn.GetNonce(addr)
 }
 
+func (t *Transition) AccessAddress(addr types.Address) bool {
+	return t.txn.AccessAddress(addr)
+}
+
+func (t *Transition) AccessSlot(addr types.Address, key types.Hash) bool {
+	return t.txn.AccessSlot(addr, key)
+}
+
+// GetRefund returns the gas refund accumulated by the transaction
+func (t *Transition) GetRefund() uint64 {
+	return t.txn.GetRefund()
+}
+
 func (t *Transition) Selfdestruct(addr types.Address, beneficiary types.Address) {
+	if t.tracer != nil {
+		// the transfer of the balance is traced as a frame of the contract
+		t.tracer.CallStart(t.depth+1, addr, beneficiary, runtime.Selfdestruct, 0, t.txn.GetBalance(addr), nil)
+		t.tracer.CallEnd(t.depth+1, nil, 0, nil)
+	}
+
 	if !t.txn.HasSuicided(addr) {
 		t.txn.AddRefund(24000)
 	}
@@ -519,13 +687,13 @@ func (t *Transition) Selfdestruct(addr types.Address, beneficiary types.Address)
 }
 
 func (t *Transition) Callx(c *runtime.Contract, h runtime.Host) *runtime.ExecutionResult {
-	if c.Type == runtime.Create {
+	if c.Type == runtime.Create || c.Type == runtime.Create2 {
 		return t.applyCreate(c, h)
 	}
 	return t.applyCall(c, c.Type, h)
 }
 
-func TransactionGasCost(msg *Transaction, isHomestead, isIstanbul bool) (uint64, error) {
+func TransactionGasCost(msg *Transaction, isHomestead, isIstanbul, isBerlin bool) (uint64, error) {
 	cost := uint64(0)
 
 	// Contract creation is only paid on the homestead fork
@@ -563,5 +731,11 @@ func TransactionGasCost(msg *Transaction, isHomestead, isIstanbul bool) (uint64,
 		cost += zeros * 4
 	}
 
+	// the access list is only paid after berlin (eip-2930)
+	if isBerlin && len(msg.AccessList) != 0 {
+		cost += uint64(len(msg.AccessList)) * TxAccessListAddressGas
+		cost += uint64(msg.AccessList.StorageKeys()) * TxAccessListStorageKeyGas
+	}
+
 	return cost, nil
 }
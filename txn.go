@@ -18,6 +18,9 @@ var (
 
 	// refundIndex is the index of the refund
 	refundIndex = types.BytesToHash([]byte{3}).Bytes()
+
+	// accessListIndex is the prefix of the accounts and slots of the access list in the trie
+	accessListIndex = types.BytesToHash([]byte{4}).Bytes()
 )
 
 // Txn is a reference of the state
@@ -25,6 +28,10 @@ type Txn struct {
 	snapshot  Snapshot
 	snapshots []*iradix.Tree
 	txn       *iradix.Txn
+
+	// access records the accounts and storage slots accessed by the
+	// transaction, it is only set by the parallel executor
+	access *accessSet
 }
 
 func NewTxn(snapshot Snapshot) *Txn {
@@ -41,6 +48,25 @@ func newTxn(snapshot Snapshot) *Txn {
 	}
 }
 
+// newTxnAt creates a transaction on top of the changes of a tree
+func newTxnAt(snapshot Snapshot, tree *iradix.Tree) *Txn {
+	return &Txn{
+		snapshot:  snapshot,
+		snapshots: []*iradix.Tree{},
+		txn:       tree.Txn(),
+	}
+}
+
+// Copy returns a copy of the transaction. The changes made on the copy
+// do not modify the original one.
+func (txn *Txn) Copy() *Txn {
+	return &Txn{
+		snapshot:  txn.snapshot,
+		snapshots: []*iradix.Tree{},
+		txn:       txn.txn.CommitOnly().Txn(),
+	}
+}
+
 // Snapshot takes a snapshot at this point in time
 func (txn *Txn) Snapshot() int {
 	t := txn.txn.CommitOnly()
@@ -75,6 +101,13 @@ func (txn *Txn) GetAccount(addr types.Address) (*Account, bool) {
 }
 
 func (txn *Txn) getStateObject(addr types.Address) (*stateObject, bool) {
+	txn.access.readAccount(addr)
+	return txn.lookupStateObject(addr)
+}
+
+// lookupStateObject returns the object of an account without recording
+// the read of its fields, it is used to access the storage
+func (txn *Txn) lookupStateObject(addr types.Address) (*stateObject, bool) {
 	// Try to get state from radix tree which holds transient states during block processing first
 	val, exists := txn.txn.Get(addr.Bytes())
 	if exists {
@@ -115,8 +148,18 @@ func (txn *Txn) upsertAccount(addr types.Address, create bool, f func(object *st
 	f(object)
 
 	if object != nil {
-		txn.txn.Insert(addr.Bytes(), object)
+		txn.insertObject(addr.Bytes(), object)
+	}
+}
+
+// insertObject inserts the object of an account. The object must not be
+// modified after it is inserted, its storage is frozen.
+func (txn *Txn) insertObject(addr []byte, object *stateObject) {
+	if object.Txn != nil {
+		object.storage = object.Txn.CommitOnly()
 	}
+	txn.access.touchAccount(types.BytesToAddress(addr))
+	txn.txn.Insert(addr, object)
 }
 
 func (txn *Txn) AddSealingReward(addr types.Address, balance *big.Int) {
@@ -255,13 +298,17 @@ func (txn *Txn) SetStorage(addr types.Address, key types.Hash, value types.Hash,
 	if original == value {
 		if original == zeroHash { // reset to original nonexistent slot (2.2.2.1)
 			// Storage was used as memory (allocation and deallocation occurred within the same contract)
-			if config.Istanbul {
+			if config.Berlin {
+				txn.AddRefund(19900)
+			} else if config.Istanbul {
 				txn.AddRefund(19200)
 			} else {
 				txn.AddRefund(19800)
 			}
 		} else { // reset to original existing slot (2.2.2.2)
-			if config.Istanbul {
+			if config.Berlin {
+				txn.AddRefund(2800)
+			} else if config.Istanbul {
 				txn.AddRefund(4200)
 			} else {
 				txn.AddRefund(4800)
@@ -273,22 +320,44 @@ func (txn *Txn) SetStorage(addr types.Address, key types.Hash, value types.Hash,
 
 // SetState change the state of an address
 func (txn *Txn) SetState(addr types.Address, key, value types.Hash) {
+	txn.access.writeSlot(addr, key)
+
+	object, exists := txn.lookupStateObject(addr)
+	if !exists {
+		object = newStateObject(txn)
+	}
+	if object.Txn == nil {
+		object.Txn = iradix.New().Txn()
+	}
+
+	if value == zeroHash {
+		object.Txn.Insert(key.Bytes(), nil)
+	} else {
+		object.Txn.Insert(key.Bytes(), value.Bytes())
+	}
+	txn.insertObject(addr.Bytes(), object)
+}
+
+// SetFullState replaces the whole storage of the address
+func (txn *Txn) SetFullState(addr types.Address, storage map[types.Hash]types.Hash) {
+	txn.access.resetStorage(addr)
 	txn.upsertAccount(addr, true, func(object *stateObject) {
-		if object.Txn == nil {
-			object.Txn = iradix.New().Txn()
-		}
+		object.Account.Root = EmptyStateHash
+		object.Txn = iradix.New().Txn()
 
-		if value == zeroHash {
-			object.Txn.Insert(key.Bytes(), nil)
-		} else {
-			object.Txn.Insert(key.Bytes(), value.Bytes())
+		for key, value := range storage {
+			if value != zeroHash {
+				object.Txn.Insert(key.Bytes(), value.Bytes())
+			}
 		}
 	})
 }
 
 // GetState returns the state of the address at a given key
 func (txn *Txn) GetState(addr types.Address, key types.Hash) types.Hash {
-	object, exists := txn.getStateObject(addr)
+	txn.access.readSlot(addr, key)
+
+	object, exists := txn.lookupStateObject(addr)
 	if !exists {
 		return types.Hash{}
 	}
@@ -420,9 +489,44 @@ func (txn *Txn) GetRefund() uint64 {
 	return data.(uint64)
 }
 
+// AccessAddress adds an account to the access list of the transaction
+// and returns whether it was already in it (eip-2929)
+func (txn *Txn) AccessAddress(addr types.Address) bool {
+	return txn.accessKey(accessListKey(addr, nil))
+}
+
+// AccessSlot adds a storage slot to the access list of the transaction
+// and returns whether it was already in it (eip-2929)
+func (txn *Txn) AccessSlot(addr types.Address, key types.Hash) bool {
+	return txn.accessKey(accessListKey(addr, &key))
+}
+
+func accessListKey(addr types.Address, key *types.Hash) []byte {
+	k := append(append([]byte{}, accessListIndex...), addr.Bytes()...)
+	if key != nil {
+		k = append(k, key.Bytes()...)
+	}
+	return k
+}
+
+func (txn *Txn) accessKey(k []byte) bool {
+	if _, ok := txn.txn.Get(k); ok {
+		return true
+	}
+	txn.txn.Insert(k, struct{}{})
+	return false
+}
+
+// resetAccessList removes the accounts and slots of the access list
+func (txn *Txn) resetAccessList() {
+	txn.txn.DeletePrefix(accessListIndex)
+}
+
 // GetCommittedState returns the state of the address in the trie
 func (txn *Txn) GetCommittedState(addr types.Address, key types.Hash) types.Hash {
-	obj, ok := txn.getStateObject(addr)
+	txn.access.readSlot(addr, key)
+
+	obj, ok := txn.lookupStateObject(addr)
 	if !ok {
 		return types.Hash{}
 	}
@@ -473,8 +577,9 @@ func (txn *Txn) CreateAccount(addr types.Address) {
 	if ok {
 		obj.Account.Balance.SetBytes(prev.Account.Balance.Bytes())
 	}
+	txn.access.resetStorage(addr)
 
-	txn.txn.Insert(addr.Bytes(), obj)
+	txn.insertObject(addr.Bytes(), obj)
 }
 
 func (txn *Txn) CleanDeleteObjects(deleteEmptyObjects bool) {
@@ -502,11 +607,12 @@ func (txn *Txn) CleanDeleteObjects(deleteEmptyObjects bool) {
 
 		obj2 := obj.Copy()
 		obj2.Deleted = true
-		txn.txn.Insert(k, obj2)
+		txn.insertObject(k, obj2)
 	}
 
-	// delete refunds
+	// delete refunds and the access list
 	txn.txn.Delete(refundIndex)
+	txn.resetAccessList()
 }
 
 func (txn *Txn) Commit() []*Object {
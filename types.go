@@ -12,16 +12,25 @@ var (
 )
 
 type Transaction struct {
-	Nonce    uint64
-	GasPrice *big.Int
-	Gas      uint64
-	To       *types.Address
-	Value    *big.Int
-	Input    []byte
-	Hash     types.Hash
-	From     types.Address
+	Type       uint8
+	Nonce      uint64
+	GasPrice   *big.Int
+	Gas        uint64
+	To         *types.Address
+	Value      *big.Int
+	Input      []byte
+	AccessList AccessList
+	Hash       types.Hash
+	From       types.Address
 }
 
+// AccessList is the list of accounts and storage slots
+// accessed by a transaction (EIP-2930)
+type AccessList = types.AccessList
+
+// AccessTuple is an account of the access list and its storage slots
+type AccessTuple = types.AccessTuple
+
 func (t *Transaction) IsContractCreation() bool {
 	return t.To == nil
 }
@@ -40,3 +49,27 @@ func (t *Transaction) Copy() *Transaction {
 	copy(tt.Input[:], t.Input[:])
 	return tt
 }
+
+// NewTransaction creates the transaction to execute from a block transaction.
+// The sender of the block transaction must be set.
+func NewTransaction(txn *types.Transaction) *Transaction {
+	tt := &Transaction{
+		Type:       txn.Type,
+		Nonce:      txn.Nonce,
+		GasPrice:   new(big.Int),
+		Gas:        txn.Gas,
+		To:         txn.To,
+		Value:      new(big.Int),
+		Input:      txn.Input,
+		AccessList: txn.AccessList,
+		Hash:       txn.Hash(),
+		From:       txn.From,
+	}
+	if txn.GasPrice != nil {
+		tt.GasPrice.Set(txn.GasPrice)
+	}
+	if txn.Value != nil {
+		tt.Value.Set(txn.Value)
+	}
+	return tt
+}
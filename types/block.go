@@ -0,0 +1,203 @@
+package types
+
+import (
+	"fmt"
+
+	"github.com/umbracle/fastrlp"
+	"golang.org/x/crypto/sha3"
+)
+
+// EmptyUncleHash is the hash of an empty list of uncles
+var EmptyUncleHash = StringToHash("0x1dcc4de8dec75d7aab85b567b6ccd41ad312451b948a7413f0a142fd40d49347")
+
+// Withdrawal is a validator withdrawal from the beacon chain (EIP-4895)
+type Withdrawal struct {
+	Index          uint64
+	ValidatorIndex uint64
+	Address        Address
+
+	// Amount is denominated in gwei
+	Amount uint64
+}
+
+// MarshalWith encodes the withdrawal as an RLP value
+func (w *Withdrawal) MarshalWith(ar *fastrlp.Arena) *fastrlp.Value {
+	v := ar.NewArray()
+	v.Set(ar.NewUint(w.Index))
+	v.Set(ar.NewUint(w.ValidatorIndex))
+	v.Set(ar.NewBytes(w.Address.Bytes()))
+	v.Set(ar.NewUint(w.Amount))
+	return v
+}
+
+func (w *Withdrawal) unmarshalRlpFrom(v *fastrlp.Value) error {
+	elems, err := v.GetElems()
+	if err != nil {
+		return err
+	}
+	if len(elems) != 4 {
+		return fmt.Errorf("incorrect number of withdrawal fields %d", len(elems))
+	}
+
+	if w.Index, err = elems[0].GetUint64(); err != nil {
+		return err
+	}
+	if w.ValidatorIndex, err = elems[1].GetUint64(); err != nil {
+		return err
+	}
+	if err = elems[2].GetAddr(w.Address[:]); err != nil {
+		return err
+	}
+	if w.Amount, err = elems[3].GetUint64(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Block is a header with its body
+type Block struct {
+	Header       *Header
+	Transactions []*Transaction
+	Uncles       []*Header
+
+	// Withdrawals is only encoded if the header includes
+	// the withdrawals root (Shanghai)
+	Withdrawals []*Withdrawal
+}
+
+// Hash returns the hash of the block header
+func (b *Block) Hash() Hash {
+	return b.Header.Hash()
+}
+
+// Number returns the number of the block
+func (b *Block) Number() uint64 {
+	return b.Header.Number
+}
+
+var blockArenaPool fastrlp.ArenaPool
+
+// MarshalRlpTo appends the RLP encoding of the block to dst
+func (b *Block) MarshalRlpTo(dst []byte) []byte {
+	ar := blockArenaPool.Get()
+	defer blockArenaPool.Put(ar)
+
+	return b.MarshalWith(ar).MarshalTo(dst)
+}
+
+// MarshalWith encodes the block as an RLP value
+func (b *Block) MarshalWith(ar *fastrlp.Arena) *fastrlp.Value {
+	v := ar.NewArray()
+	v.Set(b.Header.MarshalWith(ar))
+
+	v.Set(marshalList(ar, len(b.Transactions), func(i int) *fastrlp.Value {
+		return b.Transactions[i].MarshalWith(ar)
+	}))
+	v.Set(marshalList(ar, len(b.Uncles), func(i int) *fastrlp.Value {
+		return b.Uncles[i].MarshalWith(ar)
+	}))
+
+	if b.Header.WithdrawalsRoot != nil {
+		v.Set(marshalList(ar, len(b.Withdrawals), func(i int) *fastrlp.Value {
+			return b.Withdrawals[i].MarshalWith(ar)
+		}))
+	}
+	return v
+}
+
+var blockParserPool fastrlp.ParserPool
+
+// UnmarshalRlp decodes the block from its RLP encoding
+func (b *Block) UnmarshalRlp(buf []byte) error {
+	p := blockParserPool.Get()
+	defer blockParserPool.Put(p)
+
+	v, err := p.Parse(buf)
+	if err != nil {
+		return err
+	}
+	elems, err := v.GetElems()
+	if err != nil {
+		return err
+	}
+	if len(elems) != 3 && len(elems) != 4 {
+		return fmt.Errorf("incorrect number of block fields %d", len(elems))
+	}
+
+	// header
+	b.Header = new(Header)
+	if err = b.Header.unmarshalRlpFrom(elems[0]); err != nil {
+		return err
+	}
+
+	// transactions
+	txns, err := elems[1].GetElems()
+	if err != nil {
+		return err
+	}
+	b.Transactions = make([]*Transaction, len(txns))
+	for i, elem := range txns {
+		b.Transactions[i] = new(Transaction)
+		if err = b.Transactions[i].unmarshalRlpFrom(elem); err != nil {
+			return err
+		}
+	}
+
+	// uncles
+	uncles, err := elems[2].GetElems()
+	if err != nil {
+		return err
+	}
+	b.Uncles = make([]*Header, len(uncles))
+	for i, elem := range uncles {
+		b.Uncles[i] = new(Header)
+		if err = b.Uncles[i].unmarshalRlpFrom(elem); err != nil {
+			return err
+		}
+	}
+
+	// withdrawals
+	if len(elems) == 4 {
+		withdrawals, err := elems[3].GetElems()
+		if err != nil {
+			return err
+		}
+		b.Withdrawals = make([]*Withdrawal, len(withdrawals))
+		for i, elem := range withdrawals {
+			b.Withdrawals[i] = new(Withdrawal)
+			if err = b.Withdrawals[i].unmarshalRlpFrom(elem); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// CalculateUncleRoot returns the hash of the RLP list of uncle headers
+func CalculateUncleRoot(uncles []*Header) Hash {
+	if len(uncles) == 0 {
+		return EmptyUncleHash
+	}
+
+	ar := blockArenaPool.Get()
+	defer blockArenaPool.Put(ar)
+
+	v := marshalList(ar, len(uncles), func(i int) *fastrlp.Value {
+		return uncles[i].MarshalWith(ar)
+	})
+
+	hash := sha3.NewLegacyKeccak256()
+	hash.Write(v.MarshalTo(nil))
+	return BytesToHash(hash.Sum(nil))
+}
+
+func marshalList(ar *fastrlp.Arena, num int, f func(i int) *fastrlp.Value) *fastrlp.Value {
+	if num == 0 {
+		return ar.NewNullArray()
+	}
+	v := ar.NewArray()
+	for i := 0; i < num; i++ {
+		v.Set(f(i))
+	}
+	return v
+}
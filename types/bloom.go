@@ -0,0 +1,43 @@
+package types
+
+import (
+	"encoding/hex"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// Add sets the three bits of the keccak256 hash of data in the bloom
+func (b *Bloom) Add(data []byte) {
+	h := sha3.NewLegacyKeccak256()
+	h.Write(data)
+	hash := h.Sum(nil)
+
+	for i := 0; i < 6; i += 2 {
+		bit := (uint(hash[i])<<8 | uint(hash[i+1])) & 2047
+		b[BloomByteLength-1-bit/8] |= 1 << (bit % 8)
+	}
+}
+
+// Or merges the bits of other into the bloom
+func (b *Bloom) Or(other *Bloom) {
+	for i := range b {
+		b[i] |= other[i]
+	}
+}
+
+// Test returns true if data might be included in the bloom
+func (b *Bloom) Test(data []byte) bool {
+	var other Bloom
+	other.Add(data)
+
+	for i := range b {
+		if b[i]&other[i] != other[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (b Bloom) String() string {
+	return "0x" + hex.EncodeToString(b[:])
+}
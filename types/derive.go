@@ -0,0 +1,142 @@
+package types
+
+import (
+	"github.com/umbracle/fastrlp"
+	"golang.org/x/crypto/sha3"
+)
+
+// emptyRootHash is the root of an empty merkle patricia trie
+var emptyRootHash = StringToHash("0x56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421")
+
+var deriveArenaPool fastrlp.ArenaPool
+
+// DeriveRoot returns the root of the merkle patricia trie that maps the RLP
+// encoded index of each item to its value, as used by the transactions, receipts
+// and withdrawals roots of a block. item returns the encoded value at index i.
+func DeriveRoot(num int, item func(i int) []byte) Hash {
+	if num == 0 {
+		return emptyRootHash
+	}
+
+	ar := deriveArenaPool.Get()
+	defer deriveArenaPool.Put(ar)
+
+	keys := make([][]byte, num)
+	values := make([][]byte, num)
+	for i := 0; i < num; i++ {
+		keys[i] = keybytesToHex(ar.NewUint(uint64(i)).MarshalTo(nil))
+		values[i] = item(i)
+	}
+
+	d := &deriver{ar: ar}
+	root := d.node(keys, values, 0).MarshalTo(nil)
+
+	return BytesToHash(d.hash(root))
+}
+
+type deriver struct {
+	ar *fastrlp.Arena
+}
+
+func (d *deriver) hash(b []byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	h.Write(b)
+	return h.Sum(nil)
+}
+
+// ref returns the reference to a node from its parent. Nodes whose
+// encoding is shorter than 32 bytes are embedded in the parent.
+func (d *deriver) ref(v *fastrlp.Value) *fastrlp.Value {
+	buf := v.MarshalTo(nil)
+	if len(buf) < 32 {
+		return v
+	}
+	return d.ar.NewCopyBytes(d.hash(buf))
+}
+
+// node builds the trie node for the (nibble) keys which share the first
+// depth nibbles. Keys always end with the terminator nibble.
+func (d *deriver) node(keys, values [][]byte, depth int) *fastrlp.Value {
+	if len(keys) == 1 {
+		// leaf node
+		v := d.ar.NewArray()
+		v.Set(d.ar.NewCopyBytes(hexToCompact(keys[0][depth:])))
+		v.Set(d.ar.NewBytes(values[0]))
+		return v
+	}
+
+	// length of the prefix shared by all the keys
+	prefix := len(keys[0])
+	for _, k := range keys[1:] {
+		if l := prefixLen(keys[0][depth:prefix], k[depth:]); depth+l < prefix {
+			prefix = depth + l
+		}
+	}
+	if prefix > depth {
+		// extension node
+		v := d.ar.NewArray()
+		v.Set(d.ar.NewCopyBytes(hexToCompact(keys[0][depth:prefix])))
+		v.Set(d.ref(d.node(keys, values, prefix)))
+		return v
+	}
+
+	// full node, split the keys by the next nibble
+	var childKeys, childValues [17][][]byte
+	for i, k := range keys {
+		childKeys[k[depth]] = append(childKeys[k[depth]], k)
+		childValues[k[depth]] = append(childValues[k[depth]], values[i])
+	}
+
+	v := d.ar.NewArray()
+	for i := 0; i < 16; i++ {
+		if len(childKeys[i]) == 0 {
+			v.Set(d.ar.NewNull())
+		} else {
+			v.Set(d.ref(d.node(childKeys[i], childValues[i], depth+1)))
+		}
+	}
+	if vals := childValues[16]; len(vals) != 0 {
+		v.Set(d.ar.NewBytes(vals[0]))
+	} else {
+		v.Set(d.ar.NewNull())
+	}
+	return v
+}
+
+func prefixLen(a, b []byte) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
+
+func keybytesToHex(str []byte) []byte {
+	l := len(str)*2 + 1
+	var nibbles = make([]byte, l)
+	for i, b := range str {
+		nibbles[i*2] = b / 16
+		nibbles[i*2+1] = b % 16
+	}
+	nibbles[l-1] = 16
+	return nibbles
+}
+
+func hexToCompact(hex []byte) []byte {
+	terminator := byte(0)
+	if len(hex) > 0 && hex[len(hex)-1] == 16 {
+		terminator = 1
+		hex = hex[:len(hex)-1]
+	}
+	buf := make([]byte, len(hex)/2+1)
+	buf[0] = terminator << 5 // the flag byte
+	if len(hex)&1 == 1 {
+		buf[0] |= 1 << 4 // odd flag
+		buf[0] |= hex[0] // first nibble is contained in the first byte
+		hex = hex[1:]
+	}
+	for bi, ni := 1, 0; ni < len(hex); bi, ni = bi+1, ni+2 {
+		buf[bi] = hex[ni]<<4 | hex[ni+1]
+	}
+	return buf
+}
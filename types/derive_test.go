@@ -0,0 +1,45 @@
+package types_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/umbracle/fastrlp"
+
+	itrie "github.com/0xPolygon/eth-state-transition/immutable-trie"
+	"github.com/0xPolygon/eth-state-transition/types"
+)
+
+func TestDeriveRootEmpty(t *testing.T) {
+	root := types.DeriveRoot(0, nil)
+	assert.Equal(t, types.StringToHash("0x56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421"), root)
+}
+
+func TestDeriveRootMatchesTrie(t *testing.T) {
+	ar := &fastrlp.Arena{}
+
+	// include small values (embedded nodes) and indexes over 127 and 255
+	// that change the length of the rlp encoded key
+	for _, num := range []int{1, 2, 3, 16, 17, 128, 129, 300} {
+		items := make([][]byte, num)
+		for i := range items {
+			items[i] = make([]byte, i%40)
+			for j := range items[i] {
+				items[i][j] = byte(i + j)
+			}
+		}
+
+		trie := itrie.NewTrie()
+		txn := trie.Txn()
+		for i, item := range items {
+			txn.Insert(ar.NewUint(uint64(i)).MarshalTo(nil), item)
+		}
+		expected, err := txn.Hash()
+		assert.NoError(t, err)
+
+		root := types.DeriveRoot(num, func(i int) []byte {
+			return items[i]
+		})
+		assert.Equal(t, types.BytesToHash(expected), root, "items %d", num)
+	}
+}
@@ -0,0 +1,338 @@
+package types
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/umbracle/fastrlp"
+	"golang.org/x/crypto/sha3"
+)
+
+const BloomByteLength = 256
+
+// Bloom is the logs bloom filter of a block or a receipt
+type Bloom [BloomByteLength]byte
+
+const NonceLength = 8
+
+// Nonce is the proof-of-work nonce of a block
+type Nonce [NonceLength]byte
+
+// Header is the header of a block. The optional fields at the end of the
+// struct are only set from the fork that introduced them onwards and are
+// only encoded when set.
+type Header struct {
+	ParentHash   Hash
+	Sha3Uncles   Hash
+	Miner        Address
+	StateRoot    Hash
+	TxRoot       Hash
+	ReceiptsRoot Hash
+	LogsBloom    Bloom
+	Difficulty   *big.Int
+	Number       uint64
+	GasLimit     uint64
+	GasUsed      uint64
+	Timestamp    uint64
+	ExtraData    []byte
+	MixHash      Hash
+	Nonce        Nonce
+
+	// BaseFee is included from London (EIP-1559)
+	BaseFee *big.Int
+
+	// WithdrawalsRoot is included from Shanghai (EIP-4895)
+	WithdrawalsRoot *Hash
+
+	// BlobGasUsed and ExcessBlobGas are included from Cancun (EIP-4844)
+	BlobGasUsed   *uint64
+	ExcessBlobGas *uint64
+
+	// ParentBeaconRoot is included from Cancun (EIP-4788)
+	ParentBeaconRoot *Hash
+
+	// RequestsHash is included from Prague (EIP-7685)
+	RequestsHash *Hash
+}
+
+// numLegacyHeaderFields is the number of fields in a header before London
+const numLegacyHeaderFields = 15
+
+// numFields returns the number of RLP fields of the header, which depends
+// on the last optional field that is set.
+func (h *Header) numFields() int {
+	switch {
+	case h.RequestsHash != nil:
+		return numLegacyHeaderFields + 6
+	case h.ParentBeaconRoot != nil:
+		return numLegacyHeaderFields + 5
+	case h.BlobGasUsed != nil || h.ExcessBlobGas != nil:
+		// the blob gas fields are always encoded together
+		return numLegacyHeaderFields + 4
+	case h.WithdrawalsRoot != nil:
+		return numLegacyHeaderFields + 2
+	case h.BaseFee != nil:
+		return numLegacyHeaderFields + 1
+	default:
+		return numLegacyHeaderFields
+	}
+}
+
+// Copy returns a deep copy of the header
+func (h *Header) Copy() *Header {
+	hh := new(Header)
+	*hh = *h
+
+	if h.Difficulty != nil {
+		hh.Difficulty = new(big.Int).Set(h.Difficulty)
+	}
+	if h.BaseFee != nil {
+		hh.BaseFee = new(big.Int).Set(h.BaseFee)
+	}
+	hh.ExtraData = append([]byte{}, h.ExtraData...)
+
+	copyHash := func(h *Hash) *Hash {
+		if h == nil {
+			return nil
+		}
+		hh := *h
+		return &hh
+	}
+	copyUint := func(i *uint64) *uint64 {
+		if i == nil {
+			return nil
+		}
+		ii := *i
+		return &ii
+	}
+
+	hh.WithdrawalsRoot = copyHash(h.WithdrawalsRoot)
+	hh.BlobGasUsed = copyUint(h.BlobGasUsed)
+	hh.ExcessBlobGas = copyUint(h.ExcessBlobGas)
+	hh.ParentBeaconRoot = copyHash(h.ParentBeaconRoot)
+	hh.RequestsHash = copyHash(h.RequestsHash)
+	return hh
+}
+
+var headerArenaPool fastrlp.ArenaPool
+
+// Hash returns the keccak256 hash of the RLP encoding of the header
+func (h *Header) Hash() Hash {
+	ar := headerArenaPool.Get()
+	defer headerArenaPool.Put(ar)
+
+	hash := sha3.NewLegacyKeccak256()
+	hash.Write(h.MarshalWith(ar).MarshalTo(nil))
+
+	return BytesToHash(hash.Sum(nil))
+}
+
+// MarshalRlpTo appends the RLP encoding of the header to dst
+func (h *Header) MarshalRlpTo(dst []byte) []byte {
+	ar := headerArenaPool.Get()
+	defer headerArenaPool.Put(ar)
+
+	return h.MarshalWith(ar).MarshalTo(dst)
+}
+
+// MarshalWith encodes the header as an RLP value
+func (h *Header) MarshalWith(ar *fastrlp.Arena) *fastrlp.Value {
+	v := ar.NewArray()
+	v.Set(ar.NewBytes(h.ParentHash.Bytes()))
+	v.Set(ar.NewBytes(h.Sha3Uncles.Bytes()))
+	v.Set(ar.NewBytes(h.Miner.Bytes()))
+	v.Set(ar.NewBytes(h.StateRoot.Bytes()))
+	v.Set(ar.NewBytes(h.TxRoot.Bytes()))
+	v.Set(ar.NewBytes(h.ReceiptsRoot.Bytes()))
+	v.Set(ar.NewCopyBytes(h.LogsBloom[:]))
+	v.Set(newBigInt(ar, h.Difficulty))
+	v.Set(ar.NewUint(h.Number))
+	v.Set(ar.NewUint(h.GasLimit))
+	v.Set(ar.NewUint(h.GasUsed))
+	v.Set(ar.NewUint(h.Timestamp))
+	v.Set(ar.NewCopyBytes(h.ExtraData))
+	v.Set(ar.NewBytes(h.MixHash.Bytes()))
+	v.Set(ar.NewCopyBytes(h.Nonce[:]))
+
+	// optional fields. If a later field is set, the ones before it
+	// are encoded with their zero value.
+	num := h.numFields()
+	if num > numLegacyHeaderFields {
+		v.Set(newBigInt(ar, h.BaseFee))
+	}
+	if num > numLegacyHeaderFields+1 {
+		v.Set(newHash(ar, h.WithdrawalsRoot))
+	}
+	if num > numLegacyHeaderFields+2 {
+		v.Set(newUint(ar, h.BlobGasUsed))
+		v.Set(newUint(ar, h.ExcessBlobGas))
+	}
+	if num > numLegacyHeaderFields+4 {
+		v.Set(newHash(ar, h.ParentBeaconRoot))
+	}
+	if num > numLegacyHeaderFields+5 {
+		v.Set(newHash(ar, h.RequestsHash))
+	}
+	return v
+}
+
+var headerParserPool fastrlp.ParserPool
+
+// UnmarshalRlp decodes the header from its RLP encoding
+func (h *Header) UnmarshalRlp(b []byte) error {
+	p := headerParserPool.Get()
+	defer headerParserPool.Put(p)
+
+	v, err := p.Parse(b)
+	if err != nil {
+		return err
+	}
+	return h.unmarshalRlpFrom(v)
+}
+
+func (h *Header) unmarshalRlpFrom(v *fastrlp.Value) error {
+	elems, err := v.GetElems()
+	if err != nil {
+		return err
+	}
+	if num := len(elems); num < numLegacyHeaderFields || num > numLegacyHeaderFields+6 || num == numLegacyHeaderFields+3 {
+		return fmt.Errorf("incorrect number of header fields %d", num)
+	}
+
+	// parentHash
+	if err = elems[0].GetHash(h.ParentHash[:]); err != nil {
+		return err
+	}
+	// sha3Uncles
+	if err = elems[1].GetHash(h.Sha3Uncles[:]); err != nil {
+		return err
+	}
+	// miner
+	if err = elems[2].GetAddr(h.Miner[:]); err != nil {
+		return err
+	}
+	// stateRoot
+	if err = elems[3].GetHash(h.StateRoot[:]); err != nil {
+		return err
+	}
+	// txRoot
+	if err = elems[4].GetHash(h.TxRoot[:]); err != nil {
+		return err
+	}
+	// receiptsRoot
+	if err = elems[5].GetHash(h.ReceiptsRoot[:]); err != nil {
+		return err
+	}
+	// logsBloom
+	if _, err = elems[6].GetBytes(h.LogsBloom[:0], BloomByteLength); err != nil {
+		return err
+	}
+	// difficulty
+	h.Difficulty = new(big.Int)
+	if err = elems[7].GetBigInt(h.Difficulty); err != nil {
+		return err
+	}
+	// number
+	if h.Number, err = elems[8].GetUint64(); err != nil {
+		return err
+	}
+	// gasLimit
+	if h.GasLimit, err = elems[9].GetUint64(); err != nil {
+		return err
+	}
+	// gasUsed
+	if h.GasUsed, err = elems[10].GetUint64(); err != nil {
+		return err
+	}
+	// timestamp
+	if h.Timestamp, err = elems[11].GetUint64(); err != nil {
+		return err
+	}
+	// extraData
+	if h.ExtraData, err = elems[12].GetBytes(h.ExtraData[:0]); err != nil {
+		return err
+	}
+	// mixHash
+	if err = elems[13].GetHash(h.MixHash[:]); err != nil {
+		return err
+	}
+	// nonce
+	if _, err = elems[14].GetBytes(h.Nonce[:0], NonceLength); err != nil {
+		return err
+	}
+
+	elems = elems[numLegacyHeaderFields:]
+
+	// baseFee
+	if len(elems) > 0 {
+		h.BaseFee = new(big.Int)
+		if err = elems[0].GetBigInt(h.BaseFee); err != nil {
+			return err
+		}
+	}
+	// withdrawalsRoot
+	if len(elems) > 1 {
+		if h.WithdrawalsRoot, err = getHash(elems[1]); err != nil {
+			return err
+		}
+	}
+	// blobGasUsed and excessBlobGas
+	if len(elems) > 3 {
+		if h.BlobGasUsed, err = getUint(elems[2]); err != nil {
+			return err
+		}
+		if h.ExcessBlobGas, err = getUint(elems[3]); err != nil {
+			return err
+		}
+	}
+	// parentBeaconRoot
+	if len(elems) > 4 {
+		if h.ParentBeaconRoot, err = getHash(elems[4]); err != nil {
+			return err
+		}
+	}
+	// requestsHash
+	if len(elems) > 5 {
+		if h.RequestsHash, err = getHash(elems[5]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newBigInt(ar *fastrlp.Arena, b *big.Int) *fastrlp.Value {
+	if b == nil {
+		return ar.NewNull()
+	}
+	return ar.NewBigInt(b)
+}
+
+func newHash(ar *fastrlp.Arena, h *Hash) *fastrlp.Value {
+	if h == nil {
+		return ar.NewBytes(ZeroHash.Bytes())
+	}
+	return ar.NewBytes(h.Bytes())
+}
+
+func newUint(ar *fastrlp.Arena, i *uint64) *fastrlp.Value {
+	if i == nil {
+		return ar.NewNull()
+	}
+	return ar.NewUint(*i)
+}
+
+func getHash(v *fastrlp.Value) (*Hash, error) {
+	h := new(Hash)
+	if err := v.GetHash(h[:]); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+func getUint(v *fastrlp.Value) (*uint64, error) {
+	i, err := v.GetUint64()
+	if err != nil {
+		return nil, err
+	}
+	return &i, nil
+}
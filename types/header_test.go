@@ -0,0 +1,172 @@
+package types
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func mustDecodeHex(t *testing.T, str string) []byte {
+	t.Helper()
+
+	buf, err := hex.DecodeString(str)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf
+}
+
+func TestHeaderHashMainnetGenesis(t *testing.T) {
+	h := &Header{
+		Sha3Uncles:   EmptyUncleHash,
+		StateRoot:    StringToHash("0xd7f8974fb5ac78d9ac099b9ad5018bedc2ce0a72dad1827a1709da30580f0544"),
+		TxRoot:       StringToHash("0x56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421"),
+		ReceiptsRoot: StringToHash("0x56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421"),
+		Difficulty:   big.NewInt(0x400000000),
+		GasLimit:     5000,
+		ExtraData:    mustDecodeHex(t, "11bbe8db4e347b4e8c937c1c8370e4b5ed33adb3db69cbdb7a38e1e50b1b82fa"),
+		Nonce:        Nonce{0, 0, 0, 0, 0, 0, 0, 0x42},
+	}
+
+	assert.Equal(t, StringToHash("0xd4e56740f876aef8c010b86a40d5f56745a118d0906a34e69aec8c0db1cb8fa3"), h.Hash())
+}
+
+func TestHeaderEncodingForks(t *testing.T) {
+	hash := StringToHash("0x1")
+	num := uint64(10)
+
+	base := func() *Header {
+		return &Header{
+			ParentHash: StringToHash("0x2"),
+			Miner:      StringToAddress("0x3"),
+			Difficulty: big.NewInt(100),
+			Number:     1,
+			GasLimit:   30000000,
+			GasUsed:    21000,
+			Timestamp:  1000,
+			ExtraData:  []byte{0x1, 0x2},
+			Nonce:      Nonce{1},
+		}
+	}
+
+	cases := []struct {
+		name   string
+		fields int
+		modify func(h *Header)
+	}{
+		{"frontier", 15, func(h *Header) {}},
+		{"london", 16, func(h *Header) {
+			h.BaseFee = big.NewInt(7)
+		}},
+		{"shanghai", 17, func(h *Header) {
+			h.BaseFee = big.NewInt(7)
+			h.WithdrawalsRoot = &hash
+		}},
+		{"cancun", 20, func(h *Header) {
+			h.BaseFee = big.NewInt(7)
+			h.WithdrawalsRoot = &hash
+			h.BlobGasUsed = &num
+			h.ExcessBlobGas = &num
+			h.ParentBeaconRoot = &hash
+		}},
+		{"prague", 21, func(h *Header) {
+			h.BaseFee = big.NewInt(7)
+			h.WithdrawalsRoot = &hash
+			h.BlobGasUsed = &num
+			h.ExcessBlobGas = &num
+			h.ParentBeaconRoot = &hash
+			h.RequestsHash = &hash
+		}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			h := base()
+			c.modify(h)
+
+			assert.Equal(t, c.fields, h.numFields())
+
+			h2 := new(Header)
+			assert.NoError(t, h2.UnmarshalRlp(h.MarshalRlpTo(nil)))
+			assert.Equal(t, h, h2)
+			assert.Equal(t, h.Hash(), h2.Hash())
+		})
+	}
+}
+
+func TestHeaderDecodeIncorrectFields(t *testing.T) {
+	h := new(Header)
+
+	// empty list
+	assert.Error(t, h.UnmarshalRlp([]byte{0xc0}))
+
+	// the blob gas fields must be encoded together
+	num := uint64(1)
+	h2 := &Header{Difficulty: big.NewInt(1), BaseFee: big.NewInt(1), BlobGasUsed: &num}
+
+	ar := headerArenaPool.Get()
+	defer headerArenaPool.Put(ar)
+
+	v := ar.NewArray()
+	elems, _ := h2.MarshalWith(ar).GetElems()
+	for _, elem := range elems[:numLegacyHeaderFields+3] {
+		v.Set(elem)
+	}
+	assert.Error(t, h.UnmarshalRlp(v.MarshalTo(nil)))
+}
+
+func TestBlockEncoding(t *testing.T) {
+	to := StringToAddress("0x5")
+	root := StringToHash("0x6")
+
+	b := &Block{
+		Header: &Header{
+			Difficulty:      big.NewInt(0),
+			Number:          5,
+			BaseFee:         big.NewInt(1),
+			WithdrawalsRoot: &root,
+		},
+		Transactions: []*Transaction{
+			{
+				Nonce:    1,
+				GasPrice: big.NewInt(10),
+				Gas:      21000,
+				To:       &to,
+				Value:    big.NewInt(1),
+				Input:    []byte{},
+				V:        big.NewInt(27),
+				R:        big.NewInt(1),
+				S:        big.NewInt(2),
+			},
+			{
+				Nonce:    2,
+				GasPrice: big.NewInt(10),
+				Gas:      53000,
+				Value:    big.NewInt(0),
+				Input:    []byte{0x60, 0x00},
+				V:        big.NewInt(28),
+				R:        big.NewInt(3),
+				S:        big.NewInt(4),
+			},
+		},
+		Uncles: []*Header{},
+		Withdrawals: []*Withdrawal{
+			{Index: 1, ValidatorIndex: 2, Address: to, Amount: 3},
+		},
+	}
+
+	b2 := new(Block)
+	assert.NoError(t, b2.UnmarshalRlp(b.MarshalRlpTo(nil)))
+
+	assert.Equal(t, b.Hash(), b2.Hash())
+	assert.Equal(t, b.Withdrawals, b2.Withdrawals)
+	assert.Len(t, b2.Transactions, 2)
+	assert.Nil(t, b2.Transactions[1].To)
+
+	for i, txn := range b.Transactions {
+		assert.Equal(t, txn.Hash(), b2.Transactions[i].Hash())
+	}
+	assert.Equal(t, EmptyUncleHash, CalculateUncleRoot(b2.Uncles))
+}
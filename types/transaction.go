@@ -0,0 +1,314 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/umbracle/fastrlp"
+	"golang.org/x/crypto/sha3"
+)
+
+const (
+	// LegacyTxType is the type of the transactions before EIP-2718
+	LegacyTxType = 0x00
+
+	// AccessListTxType is the type of the transactions with an access list (EIP-2930)
+	AccessListTxType = 0x01
+)
+
+// ErrTxTypeNotSupported is returned when decoding a typed transaction of an unknown type
+var ErrTxTypeNotSupported = errors.New("transaction type not supported")
+
+// AccessList is the list of accounts and storage slots
+// accessed by a transaction (EIP-2930)
+type AccessList []AccessTuple
+
+// AccessTuple is an account of the access list and its storage slots
+type AccessTuple struct {
+	Address     Address
+	StorageKeys []Hash
+}
+
+// StorageKeys returns the number of storage slots in the access list
+func (a AccessList) StorageKeys() int {
+	num := 0
+	for _, tuple := range a {
+		num += len(tuple.StorageKeys)
+	}
+	return num
+}
+
+// Transaction is a signed transaction as included in a block. Typed
+// transactions (EIP-2718) are encoded as the type followed by the RLP
+// encoding of their fields.
+type Transaction struct {
+	Type     uint8
+	Nonce    uint64
+	GasPrice *big.Int
+	Gas      uint64
+	To       *Address
+	Value    *big.Int
+	Input    []byte
+
+	// ChainID and AccessList are only encoded for the typed transactions
+	ChainID    *big.Int
+	AccessList AccessList
+
+	// V is the y parity of the signature for the typed transactions
+	V *big.Int
+	R *big.Int
+	S *big.Int
+
+	// From is the sender of the transaction. It is not part of the
+	// encoding and has to be set by the caller once the signature
+	// has been recovered.
+	From Address
+}
+
+// IsContractCreation returns true if the transaction deploys a contract
+func (t *Transaction) IsContractCreation() bool {
+	return t.To == nil
+}
+
+var txArenaPool fastrlp.ArenaPool
+
+// Hash returns the keccak256 hash of the encoding of the transaction
+func (t *Transaction) Hash() Hash {
+	hash := sha3.NewLegacyKeccak256()
+	hash.Write(t.MarshalRlpTo(nil))
+
+	return BytesToHash(hash.Sum(nil))
+}
+
+// MarshalRlpTo appends the encoding of the transaction to dst, the RLP list
+// of the fields for legacy transactions and the type followed by that list
+// for typed transactions
+func (t *Transaction) MarshalRlpTo(dst []byte) []byte {
+	ar := txArenaPool.Get()
+	defer txArenaPool.Put(ar)
+
+	if t.Type != LegacyTxType {
+		dst = append(dst, t.Type)
+	}
+	return t.marshalFields(ar).MarshalTo(dst)
+}
+
+// MarshalWith encodes the transaction as an RLP value. Typed transactions
+// are encoded as a byte string with their envelope, as in the blocks.
+func (t *Transaction) MarshalWith(ar *fastrlp.Arena) *fastrlp.Value {
+	v := t.marshalFields(ar)
+	if t.Type == LegacyTxType {
+		return v
+	}
+	return ar.NewBytes(v.MarshalTo([]byte{t.Type}))
+}
+
+func (t *Transaction) marshalFields(ar *fastrlp.Arena) *fastrlp.Value {
+	v := ar.NewArray()
+	if t.Type != LegacyTxType {
+		v.Set(newBigInt(ar, t.ChainID))
+	}
+	v.Set(ar.NewUint(t.Nonce))
+	v.Set(newBigInt(ar, t.GasPrice))
+	v.Set(ar.NewUint(t.Gas))
+
+	// address
+	if t.To == nil {
+		v.Set(ar.NewNull())
+	} else {
+		v.Set(ar.NewBytes(t.To.Bytes()))
+	}
+
+	v.Set(newBigInt(ar, t.Value))
+	v.Set(ar.NewCopyBytes(t.Input))
+
+	if t.Type != LegacyTxType {
+		v.Set(t.AccessList.MarshalWith(ar))
+	}
+
+	// signature values
+	v.Set(newBigInt(ar, t.V))
+	v.Set(newBigInt(ar, t.R))
+	v.Set(newBigInt(ar, t.S))
+	return v
+}
+
+var txParserPool fastrlp.ParserPool
+
+// UnmarshalRlp decodes the transaction from its encoding (see MarshalRlpTo)
+func (t *Transaction) UnmarshalRlp(b []byte) error {
+	if len(b) != 0 && b[0] < 0xc0 {
+		return t.unmarshalEnvelope(b)
+	}
+
+	p := txParserPool.Get()
+	defer txParserPool.Put(p)
+
+	v, err := p.Parse(b)
+	if err != nil {
+		return err
+	}
+	t.Type = LegacyTxType
+	return t.unmarshalFields(v)
+}
+
+func (t *Transaction) unmarshalRlpFrom(v *fastrlp.Value) error {
+	if v.Type() == fastrlp.TypeBytes {
+		// typed transaction
+		buf, err := v.Bytes()
+		if err != nil {
+			return err
+		}
+		return t.unmarshalEnvelope(buf)
+	}
+	t.Type = LegacyTxType
+	return t.unmarshalFields(v)
+}
+
+// unmarshalEnvelope decodes a typed transaction (EIP-2718)
+func (t *Transaction) unmarshalEnvelope(b []byte) error {
+	if len(b) == 0 {
+		return fmt.Errorf("empty typed transaction")
+	}
+	if b[0] != AccessListTxType {
+		return fmt.Errorf("%w: %d", ErrTxTypeNotSupported, b[0])
+	}
+
+	p := txParserPool.Get()
+	defer txParserPool.Put(p)
+
+	v, err := p.Parse(b[1:])
+	if err != nil {
+		return err
+	}
+	t.Type = b[0]
+	return t.unmarshalFields(v)
+}
+
+func (t *Transaction) unmarshalFields(v *fastrlp.Value) error {
+	elems, err := v.GetElems()
+	if err != nil {
+		return err
+	}
+
+	num := 9
+	if t.Type != LegacyTxType {
+		num = 11
+	}
+	if len(elems) != num {
+		return fmt.Errorf("incorrect number of transaction fields %d", len(elems))
+	}
+
+	getBigInt := func(v *fastrlp.Value) (*big.Int, error) {
+		b := new(big.Int)
+		if err := v.GetBigInt(b); err != nil {
+			return nil, err
+		}
+		return b, nil
+	}
+
+	if t.Type != LegacyTxType {
+		// chain id
+		if t.ChainID, err = getBigInt(elems[0]); err != nil {
+			return err
+		}
+		elems = elems[1:]
+	} else {
+		t.ChainID = nil
+	}
+
+	// nonce
+	if t.Nonce, err = elems[0].GetUint64(); err != nil {
+		return err
+	}
+	// gasPrice
+	if t.GasPrice, err = getBigInt(elems[1]); err != nil {
+		return err
+	}
+	// gas
+	if t.Gas, err = elems[2].GetUint64(); err != nil {
+		return err
+	}
+	// to
+	if vv, _ := elems[3].Bytes(); len(vv) == AddressLength {
+		to := BytesToAddress(vv)
+		t.To = &to
+	} else if len(vv) == 0 {
+		t.To = nil
+	} else {
+		return fmt.Errorf("incorrect length for the to address %d", len(vv))
+	}
+	// value
+	if t.Value, err = getBigInt(elems[4]); err != nil {
+		return err
+	}
+	// input
+	if t.Input, err = elems[5].GetBytes(t.Input[:0]); err != nil {
+		return err
+	}
+	if t.Type != LegacyTxType {
+		// access list
+		if t.AccessList, err = unmarshalAccessList(elems[6]); err != nil {
+			return err
+		}
+		elems = elems[1:]
+	} else {
+		t.AccessList = nil
+	}
+	// signature values
+	if t.V, err = getBigInt(elems[6]); err != nil {
+		return err
+	}
+	if t.R, err = getBigInt(elems[7]); err != nil {
+		return err
+	}
+	if t.S, err = getBigInt(elems[8]); err != nil {
+		return err
+	}
+	return nil
+}
+
+// MarshalWith encodes the access list as an RLP value
+func (a AccessList) MarshalWith(ar *fastrlp.Arena) *fastrlp.Value {
+	return marshalList(ar, len(a), func(i int) *fastrlp.Value {
+		tuple := ar.NewArray()
+		tuple.Set(ar.NewCopyBytes(a[i].Address.Bytes()))
+		tuple.Set(marshalList(ar, len(a[i].StorageKeys), func(j int) *fastrlp.Value {
+			return ar.NewCopyBytes(a[i].StorageKeys[j].Bytes())
+		}))
+		return tuple
+	})
+}
+
+func unmarshalAccessList(v *fastrlp.Value) (AccessList, error) {
+	elems, err := v.GetElems()
+	if err != nil {
+		return nil, err
+	}
+
+	list := make(AccessList, len(elems))
+	for i, elem := range elems {
+		tuple, err := elem.GetElems()
+		if err != nil {
+			return nil, err
+		}
+		if len(tuple) != 2 {
+			return nil, fmt.Errorf("incorrect number of access tuple fields %d", len(tuple))
+		}
+		if err = tuple[0].GetAddr(list[i].Address[:]); err != nil {
+			return nil, err
+		}
+		keys, err := tuple[1].GetElems()
+		if err != nil {
+			return nil, err
+		}
+		list[i].StorageKeys = make([]Hash, len(keys))
+		for j, key := range keys {
+			if err = key.GetHash(list[i].StorageKeys[j][:]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return list, nil
+}
@@ -0,0 +1,96 @@
+package types
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/sha3"
+)
+
+func newAccessListTx() *Transaction {
+	to := StringToAddress("0x5")
+	return &Transaction{
+		Type:     AccessListTxType,
+		ChainID:  big.NewInt(1),
+		Nonce:    3,
+		GasPrice: big.NewInt(10),
+		Gas:      25000,
+		To:       &to,
+		Value:    big.NewInt(1),
+		Input:    []byte{0x1},
+		AccessList: AccessList{
+			{Address: to, StorageKeys: []Hash{StringToHash("0x1"), StringToHash("0x2")}},
+			{Address: StringToAddress("0x6"), StorageKeys: []Hash{}},
+		},
+		V: big.NewInt(1),
+		R: big.NewInt(1),
+		S: big.NewInt(2),
+	}
+}
+
+func TestTransactionAccessListEncoding(t *testing.T) {
+	txn := newAccessListTx()
+
+	// the encoding is the type followed by the rlp list of the fields
+	buf := txn.MarshalRlpTo(nil)
+	assert.Equal(t, byte(AccessListTxType), buf[0])
+
+	hash := sha3.NewLegacyKeccak256()
+	hash.Write(buf)
+	assert.Equal(t, BytesToHash(hash.Sum(nil)), txn.Hash())
+
+	txn2 := new(Transaction)
+	assert.NoError(t, txn2.UnmarshalRlp(buf))
+	assert.Equal(t, txn.Type, txn2.Type)
+	assert.Equal(t, txn.ChainID, txn2.ChainID)
+	assert.Equal(t, txn.AccessList, txn2.AccessList)
+	assert.Equal(t, txn.Hash(), txn2.Hash())
+
+	// the legacy transactions have no type in the encoding
+	txn.Type = LegacyTxType
+	buf = txn.MarshalRlpTo(nil)
+	assert.Equal(t, byte(0xc0), buf[0]&0xc0)
+
+	assert.NoError(t, txn2.UnmarshalRlp(buf))
+	assert.Equal(t, uint8(LegacyTxType), txn2.Type)
+	assert.Nil(t, txn2.ChainID)
+	assert.Nil(t, txn2.AccessList)
+}
+
+func TestTransactionDecodeUnsupportedType(t *testing.T) {
+	buf := newAccessListTx().MarshalRlpTo(nil)
+	buf[0] = 0x02
+
+	err := new(Transaction).UnmarshalRlp(buf)
+	assert.True(t, errors.Is(err, ErrTxTypeNotSupported))
+
+	// a typed transaction with the fields of a legacy one
+	legacy := newAccessListTx()
+	legacy.Type = LegacyTxType
+	buf = append([]byte{AccessListTxType}, legacy.MarshalRlpTo(nil)...)
+	assert.Error(t, new(Transaction).UnmarshalRlp(buf))
+}
+
+func TestBlockEncodingTypedTransactions(t *testing.T) {
+	legacy := newAccessListTx()
+	legacy.Type = LegacyTxType
+
+	b := &Block{
+		Header:       &Header{Difficulty: big.NewInt(0), Number: 5},
+		Transactions: []*Transaction{legacy, newAccessListTx()},
+	}
+
+	b2 := new(Block)
+	assert.NoError(t, b2.UnmarshalRlp(b.MarshalRlpTo(nil)))
+
+	assert.Len(t, b2.Transactions, 2)
+	assert.Equal(t, uint8(LegacyTxType), b2.Transactions[0].Type)
+	assert.Equal(t, uint8(AccessListTxType), b2.Transactions[1].Type)
+	assert.Equal(t, b.Transactions[1].AccessList, b2.Transactions[1].AccessList)
+
+	for i, txn := range b.Transactions {
+		assert.Equal(t, txn.Hash(), b2.Transactions[i].Hash())
+	}
+}